@@ -17,3 +17,10 @@ func New(ctx context.Context, config *directory.Config, logger *zerolog.Logger)
 
 	return ds, nil
 }
+
+// Open, creates an edge directory instance owned by the caller, see directory.Open.
+func Open(ctx context.Context, config *directory.Config, logger *zerolog.Logger) (*directory.Directory, error) {
+	newLogger := logger.With().Str("component", "edge-ds").Logger()
+
+	return directory.Open(ctx, config, &newLogger)
+}
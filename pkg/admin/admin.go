@@ -0,0 +1,277 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	cerr "github.com/aserto-dev/errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Admin, edge directory administration service.
+//
+// Backup streams a consistent snapshot of the directory store (in the bbolt file format) to the caller,
+// as a sequence of chunks, Restore replaces the directory store by the snapshot streamed by the caller.
+// Verify cross-checks the relation indexes of the directory store and returns the detected integrity issues,
+// up to ds.MaxVerifyIssues issues along with the number of detected issues,
+// Repair rebuilds the relation indexes from the primary relation bucket, in batches.
+// The service uses well-known message types only, snapshot chunks are sent as google.protobuf.BytesValue,
+// verify and repair reports are returned as google.protobuf.Struct.
+//
+//	service Admin {
+//	  rpc Backup(google.protobuf.Empty) returns (stream google.protobuf.BytesValue);
+//	  rpc Restore(stream google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  rpc Verify(google.protobuf.Empty) returns (google.protobuf.Struct);
+//	  rpc Repair(google.protobuf.Empty) returns (google.protobuf.Struct);
+//	}
+const (
+	ServiceName           string = "aserto.directory.edge.admin.v1.Admin"
+	BackupFullMethodName  string = "/" + ServiceName + "/Backup"
+	RestoreFullMethodName string = "/" + ServiceName + "/Restore"
+	VerifyFullMethodName  string = "/" + ServiceName + "/Verify"
+	RepairFullMethodName  string = "/" + ServiceName + "/Repair"
+)
+
+// ChunkSize, maximum size of the snapshot chunks sent by Backup.
+const ChunkSize int = 64 * 1024
+
+// AdminServer, server API of the Admin service.
+type AdminServer interface {
+	Backup(*emptypb.Empty, Admin_BackupServer) error
+	Restore(Admin_RestoreServer) error
+	Verify(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	Repair(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+}
+
+type Admin_BackupServer interface { //nolint:revive,stylecheck // follows the naming of generated gRPC code.
+	Send(*wrapperspb.BytesValue) error
+	grpc.ServerStream
+}
+
+type Admin_RestoreServer interface { //nolint:revive,stylecheck // follows the naming of generated gRPC code.
+	SendAndClose(*emptypb.Empty) error
+	Recv() (*wrapperspb.BytesValue, error)
+	grpc.ServerStream
+}
+
+// ErrNotAuthorized, the Admin service call is rejected by the authorizer registered with the service.
+var ErrNotAuthorized = cerr.NewAsertoError("E20082", codes.PermissionDenied, http.StatusForbidden, "admin call not authorized")
+
+// Authorizer, authorizes the call of the Admin service method identified by fullMethod,
+// a non-nil error rejects the call and is returned to the caller.
+type Authorizer func(ctx context.Context, fullMethod string) error
+
+// RegisterAdminServer, registers the Admin service implementation with the gRPC server,
+// every call is authorized by authorize before it reaches srv, a nil authorize rejects all calls.
+//
+// The Admin service replaces and exposes the complete directory store, it is therefore not registered
+// by the edge directory itself, registering it is an explicit decision of the hosting server.
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer, authorize Authorizer) {
+	s.RegisterService(&Admin_ServiceDesc, &authorizedServer{srv: srv, authorize: authorize})
+}
+
+// authorizedServer, authorizes each call before dispatching it to the Admin service implementation.
+type authorizedServer struct {
+	srv       AdminServer
+	authorize Authorizer
+}
+
+var _ AdminServer = (*authorizedServer)(nil)
+
+func (a *authorizedServer) check(ctx context.Context, fullMethod string) error {
+	if a.authorize == nil {
+		return ErrNotAuthorized.Msgf("%s: no admin authorizer registered", fullMethod)
+	}
+
+	return a.authorize(ctx, fullMethod)
+}
+
+func (a *authorizedServer) Backup(req *emptypb.Empty, stream Admin_BackupServer) error {
+	if err := a.check(stream.Context(), BackupFullMethodName); err != nil {
+		return err
+	}
+
+	return a.srv.Backup(req, stream)
+}
+
+func (a *authorizedServer) Restore(stream Admin_RestoreServer) error {
+	if err := a.check(stream.Context(), RestoreFullMethodName); err != nil {
+		return err
+	}
+
+	return a.srv.Restore(stream)
+}
+
+func (a *authorizedServer) Verify(ctx context.Context, req *emptypb.Empty) (*structpb.Struct, error) {
+	if err := a.check(ctx, VerifyFullMethodName); err != nil {
+		return nil, err
+	}
+
+	return a.srv.Verify(ctx, req)
+}
+
+func (a *authorizedServer) Repair(ctx context.Context, req *emptypb.Empty) (*structpb.Struct, error) {
+	if err := a.check(ctx, RepairFullMethodName); err != nil {
+		return nil, err
+	}
+
+	return a.srv.Repair(ctx, req)
+}
+
+var Admin_ServiceDesc = grpc.ServiceDesc{ //nolint:revive,stylecheck // follows the naming of generated gRPC code.
+	ServiceName: ServiceName,
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Verify",
+			Handler:    verifyHandler,
+		},
+		{
+			MethodName: "Repair",
+			Handler:    repairHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Backup",
+			Handler:       backupHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       restoreHandler,
+			ClientStreams: true,
+		},
+	},
+}
+
+func backupHandler(srv any, stream grpc.ServerStream) error {
+	req := &emptypb.Empty{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(AdminServer).Backup(req, &grpc.GenericServerStream[emptypb.Empty, wrapperspb.BytesValue]{ServerStream: stream})
+}
+
+func restoreHandler(srv any, stream grpc.ServerStream) error {
+	return srv.(AdminServer).Restore(&grpc.GenericServerStream[wrapperspb.BytesValue, emptypb.Empty]{ServerStream: stream})
+}
+
+func verifyHandler(
+	srv any,
+	ctx context.Context, //nolint:revive // follows the signature of grpc.MethodHandler.
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	return unaryHandler(srv, ctx, dec, interceptor, VerifyFullMethodName, AdminServer.Verify)
+}
+
+func repairHandler(
+	srv any,
+	ctx context.Context, //nolint:revive // follows the signature of grpc.MethodHandler.
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	return unaryHandler(srv, ctx, dec, interceptor, RepairFullMethodName, AdminServer.Repair)
+}
+
+func unaryHandler(
+	srv any,
+	ctx context.Context, //nolint:revive // follows the signature of grpc.MethodHandler.
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+	fullMethod string,
+	method func(AdminServer, context.Context, *emptypb.Empty) (*structpb.Struct, error),
+) (any, error) {
+	in := &emptypb.Empty{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return method(srv.(AdminServer), ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return method(srv.(AdminServer), ctx, req.(*emptypb.Empty))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminClient, client API of the Admin service.
+type AdminClient interface {
+	Backup(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[wrapperspb.BytesValue], error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[wrapperspb.BytesValue, emptypb.Empty], error)
+	Verify(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+	Repair(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewAdminClient, returns an Admin service client using the client connection.
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc: cc}
+}
+
+func (c *adminClient) Backup(
+	ctx context.Context,
+	in *emptypb.Empty,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[wrapperspb.BytesValue], error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], BackupFullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &grpc.GenericClientStream[emptypb.Empty, wrapperspb.BytesValue]{ClientStream: stream}
+	if err := x.SendMsg(in); err != nil {
+		return nil, err
+	}
+
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (c *adminClient) Restore(
+	ctx context.Context,
+	opts ...grpc.CallOption,
+) (grpc.ClientStreamingClient[wrapperspb.BytesValue, emptypb.Empty], error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[1], RestoreFullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpc.GenericClientStream[wrapperspb.BytesValue, emptypb.Empty]{ClientStream: stream}, nil
+}
+
+func (c *adminClient) Verify(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := &structpb.Struct{}
+	if err := c.cc.Invoke(ctx, VerifyFullMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *adminClient) Repair(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := &structpb.Struct{}
+	if err := c.cc.Invoke(ctx, RepairFullMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
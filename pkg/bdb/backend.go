@@ -0,0 +1,71 @@
+package bdb
+
+import (
+	berr "go.etcd.io/bbolt/errors"
+)
+
+// Backend, transactional, bucketed key-value storage engine underneath the BoltDB store.
+//
+// Two backends are provided:
+// bolt   -- (default) persistent, file based bbolt backend.
+// memory -- ephemeral, pure in-memory backend, used for unit tests and sidecars which do not require persistence.
+type Backend interface {
+	// View, executes fn in the context of a managed read-only transaction.
+	View(fn func(Tx) error) error
+	// Update, executes fn in the context of a managed read-write transaction,
+	// the transaction is committed when fn returns nil and rolled back otherwise.
+	Update(fn func(Tx) error) error
+	// Batch, executes fn in the context of a managed read-write transaction,
+	// which may be combined with concurrent Batch calls.
+	Batch(fn func(Tx) error) error
+	// Close, releases all backend resources.
+	Close() error
+}
+
+// Tx, backend transaction.
+type Tx interface {
+	Writable() bool
+	Bucket(name []byte) Bucket
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+}
+
+// Bucket, ordered collection of key-value pairs and nested buckets.
+// Returned keys and values are only valid for the lifetime of the transaction and must not be modified.
+type Bucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	Bucket(name []byte) Bucket
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+	ForEachBucket(fn func(k []byte) error) error
+	Cursor() Cursor
+	KeyN() int
+}
+
+// Cursor, iterates the key-value pairs of a bucket in key order,
+// nested buckets are returned with a nil value.
+type Cursor interface {
+	First() (key, value []byte)
+	Last() (key, value []byte)
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+	Seek(seek []byte) (key, value []byte)
+	Delete() error
+}
+
+const (
+	BoltBackend   string = "bolt"
+	MemoryBackend string = "memory"
+)
+
+// backend errors, shared by all backend implementations.
+var (
+	ErrBucketNotFound     = berr.ErrBucketNotFound
+	ErrBucketNameRequired = berr.ErrBucketNameRequired
+	ErrKeyRequired        = berr.ErrKeyRequired
+	ErrIncompatibleValue  = berr.ErrIncompatibleValue
+	ErrTxNotWritable      = berr.ErrTxNotWritable
+	ErrDatabaseNotOpen    = berr.ErrDatabaseNotOpen
+)
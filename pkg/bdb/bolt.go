@@ -0,0 +1,255 @@
+package bdb
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/fs"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBackend, persistent bbolt backend.
+//
+// The database handle is guarded by mu, transactions hold the read lock, replacing the database file holds
+// the write lock, which waits for the running transactions to finish.
+// Write transactions additionally hold the read lock of writeMu, which is held exclusively to pause the writes
+// while the database file is replaced, and while a compaction catches up with the writes made during the copy,
+// which are recorded in the write journal. Compaction and replacement are serialized by swapMu.
+type boltBackend struct {
+	mu      sync.RWMutex
+	writeMu sync.RWMutex
+	swapMu  sync.Mutex
+	db      *bolt.DB
+	opts    *bolt.Options
+	notify  *notifier
+	journal *writeJournal // guarded by writeMu.
+}
+
+var _ Backend = (*boltBackend)(nil)
+
+func (b *boltBackend) View(fn func(Tx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(BoltTx(tx))
+	})
+}
+
+func (b *boltBackend) Update(fn func(Tx) error) error {
+	b.writeMu.RLock()
+	defer b.writeMu.RUnlock()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		tx.OnCommit(b.notify.broadcast)
+		return fn(b.writeTx(tx))
+	})
+}
+
+func (b *boltBackend) Batch(fn func(Tx) error) error {
+	b.writeMu.RLock()
+	defer b.writeMu.RUnlock()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.db.Batch(func(tx *bolt.Tx) error {
+		tx.OnCommit(b.notify.broadcast)
+		return fn(b.writeTx(tx))
+	})
+}
+
+// writeTx, wraps the write transaction, which records the written paths while a compaction is running,
+// the caller must hold the read lock of writeMu.
+func (b *boltBackend) writeTx(tx *bolt.Tx) Tx {
+	if b.journal == nil {
+		return BoltTx(tx)
+	}
+
+	return &journalTx{boltTx: boltTx{tx: tx}, j: b.journal}
+}
+
+func (b *boltBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.db.Close()
+}
+
+// WriteTo, writes a consistent snapshot of the database file to w, using a read-only transaction.
+func (b *boltBackend) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+
+	err := b.View(func(tx Tx) error {
+		var err error
+
+		n, err = tx.(*boltTx).tx.WriteTo(w)
+
+		return err
+	})
+
+	return n, err
+}
+
+// Replace, replaces the database file by the file at path, the current database is closed, the file is moved
+// in place of the database file, which is reopened, when the file cannot be moved the current database is reopened.
+// The file at path is prepared and swapped while holding the write lock of writeMu, so no write is lost in between.
+func (b *boltBackend) Replace(path string, prepare PrepareFunc, swapped func()) error {
+	b.swapMu.Lock()
+	defer b.swapMu.Unlock()
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	if prepare != nil {
+		if err := b.prepare(path, prepare); err != nil {
+			return err
+		}
+	}
+
+	return b.swap(path, swapped)
+}
+
+func (b *boltBackend) prepare(path string, fn PrepareFunc) error {
+	next, err := bolt.Open(path, fs.FileModeOwnerRW, b.opts)
+	if err != nil {
+		return err
+	}
+
+	err = b.View(func(cur Tx) error {
+		return next.Update(func(tx *bolt.Tx) error {
+			return fn(cur, BoltTx(tx))
+		})
+	})
+
+	if closeErr := next.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// swap, replaces the database file by the file at path, the caller must hold the write lock of writeMu.
+// The swapped function (optional) is called after the database has been reopened, before transactions are resumed.
+func (b *boltBackend) swap(path string, swapped func()) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dbPath := b.db.Path()
+
+	if err := b.db.Close(); err != nil {
+		return err
+	}
+
+	renameErr := os.Rename(path, dbPath)
+
+	db, err := bolt.Open(dbPath, fs.FileModeOwnerRW, b.opts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reopen directory '%s'", dbPath)
+	}
+
+	b.db = db
+
+	if renameErr != nil {
+		return renameErr
+	}
+
+	if swapped != nil {
+		swapped()
+	}
+
+	b.notify.broadcast()
+
+	return nil
+}
+
+// BoltTx, wraps a bbolt transaction as backend transaction,
+// used by the migrations, which operate on the bbolt database files directly.
+func BoltTx(tx *bolt.Tx) Tx {
+	return &boltTx{tx: tx}
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) Writable() bool {
+	return t.tx.Writable()
+}
+
+func (t *boltTx) Bucket(name []byte) Bucket {
+	return boltBucketOrNil(t.tx.Bucket(name))
+}
+
+func (t *boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltBucket{b: b}, nil
+}
+
+func (t *boltTx) DeleteBucket(name []byte) error {
+	return t.tx.DeleteBucket(name)
+}
+
+type boltBucket struct {
+	b *bolt.Bucket
+}
+
+// boltBucketOrNil, prevents returning a typed nil Bucket interface.
+func boltBucketOrNil(b *bolt.Bucket) Bucket {
+	if b == nil {
+		return nil
+	}
+
+	return &boltBucket{b: b}
+}
+
+func (b *boltBucket) Get(key []byte) []byte {
+	return b.b.Get(key)
+}
+
+func (b *boltBucket) Put(key, value []byte) error {
+	return b.b.Put(key, value)
+}
+
+func (b *boltBucket) Delete(key []byte) error {
+	return b.b.Delete(key)
+}
+
+func (b *boltBucket) Bucket(name []byte) Bucket {
+	return boltBucketOrNil(b.b.Bucket(name))
+}
+
+func (b *boltBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	nb, err := b.b.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltBucket{b: nb}, nil
+}
+
+func (b *boltBucket) DeleteBucket(name []byte) error {
+	return b.b.DeleteBucket(name)
+}
+
+func (b *boltBucket) ForEachBucket(fn func(k []byte) error) error {
+	return b.b.ForEachBucket(fn)
+}
+
+func (b *boltBucket) Cursor() Cursor {
+	return b.b.Cursor()
+}
+
+func (b *boltBucket) KeyN() int {
+	return b.b.Stats().KeyN
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	bolt "go.etcd.io/bbolt"
-	berr "go.etcd.io/bbolt/errors"
 	"google.golang.org/grpc/codes"
 )
 
@@ -26,18 +25,20 @@ var (
 )
 
 type Config struct {
+	Backend        string // storage backend, BoltBackend (default) or MemoryBackend.
 	DBPath         string
 	RequestTimeout time.Duration
 	MaxBatchSize   int           `json:"-"` // obsolete bbolt configuration value.
 	MaxBatchDelay  time.Duration `json:"-"` // obsolete bbolt configuration value.
 }
 
-// BoltDB based key-value store.
+// BoltDB based key-value store, the storage engine is provided by the configured Backend.
 type BoltDB struct {
 	logger *zerolog.Logger
 	config *Config
-	db     *bolt.DB
+	db     Backend
 	mc     *cache.Cache
+	notify *notifier
 }
 
 func New(config *Config, logger *zerolog.Logger) (*BoltDB, error) {
@@ -46,6 +47,7 @@ func New(config *Config, logger *zerolog.Logger) (*BoltDB, error) {
 		config: config,
 		logger: &newLogger,
 		mc:     cache.New(&model.Model{}),
+		notify: newNotifier(),
 	}
 
 	return &db, nil
@@ -53,6 +55,17 @@ func New(config *Config, logger *zerolog.Logger) (*BoltDB, error) {
 
 // Open BoltDB key-value store instance.
 func (s *BoltDB) Open() error {
+	switch s.config.Backend {
+	case "", BoltBackend:
+		return s.openBolt()
+	case MemoryBackend:
+		return s.openMemory()
+	default:
+		return errors.Errorf("unknown store backend '%s'", s.config.Backend)
+	}
+}
+
+func (s *BoltDB) openBolt() error {
 	s.logger.Info().Str("db_path", s.config.DBPath).Msg("open")
 
 	if s.config.DBPath == "" {
@@ -66,14 +79,40 @@ func (s *BoltDB) Open() error {
 		}
 	}
 
-	db, err := bolt.Open(s.config.DBPath, fs.FileModeOwnerRW, &bolt.Options{
+	opts := &bolt.Options{
 		Timeout:      s.config.RequestTimeout,
 		FreelistType: bolt.FreelistArrayType, // WARNING: using bolt.FreelistMapType resulted store corruptions in the migration path
-	})
+	}
+
+	db, err := bolt.Open(s.config.DBPath, fs.FileModeOwnerRW, opts)
 	if err != nil {
 		return errors.Wrapf(err, "failed to open directory '%s'", s.config.DBPath)
 	}
 
+	s.db = &boltBackend{db: db, opts: opts, notify: s.notify}
+
+	return nil
+}
+
+// openMemory, opens an empty in-memory store, containing all store buckets.
+// The in-memory store is created at the current schema version, migrations do not apply.
+func (s *BoltDB) openMemory() error {
+	s.logger.Info().Str("backend", MemoryBackend).Msg("open")
+
+	db := newMemoryBackend(s.notify)
+
+	if err := db.Update(func(tx Tx) error {
+		for _, path := range StorePaths {
+			if _, err := CreateBucket(tx, path); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	s.db = db
 
 	return nil
@@ -88,7 +127,7 @@ func (s *BoltDB) Close() {
 	}
 }
 
-func (s *BoltDB) DB() *bolt.DB {
+func (s *BoltDB) DB() Backend {
 	return s.db
 }
 
@@ -102,8 +141,8 @@ func (s *BoltDB) MC() *cache.Cache {
 }
 
 // SetBucket, set bucket context to path.
-func SetBucket(tx *bolt.Tx, path Path) (*bolt.Bucket, error) {
-	var b *bolt.Bucket
+func SetBucket(tx Tx, path Path) (Bucket, error) {
+	var b Bucket
 
 	for index, p := range path {
 		if index == 0 {
@@ -124,10 +163,29 @@ func SetBucket(tx *bolt.Tx, path Path) (*bolt.Bucket, error) {
 	return b, nil
 }
 
+// LookupBucket, returns the bucket at path, or nil when the path does not exist, without creating buckets.
+func LookupBucket(tx Tx, path Path) Bucket {
+	if len(path) == 0 {
+		return nil
+	}
+
+	b := tx.Bucket([]byte(path[0]))
+
+	for _, p := range path[1:] {
+		if b == nil {
+			return nil
+		}
+
+		b = b.Bucket([]byte(p))
+	}
+
+	return b
+}
+
 // CreateBucket, create bucket path if not exists.
-func CreateBucket(tx *bolt.Tx, path Path) (*bolt.Bucket, error) {
+func CreateBucket(tx Tx, path Path) (Bucket, error) {
 	var (
-		b   *bolt.Bucket
+		b   Bucket
 		err error
 	)
 
@@ -147,12 +205,12 @@ func CreateBucket(tx *bolt.Tx, path Path) (*bolt.Bucket, error) {
 }
 
 // DeleteBucket, delete tail bucket of path provided.
-func DeleteBucket(tx *bolt.Tx, path Path) error {
+func DeleteBucket(tx Tx, path Path) error {
 	if len(path) == 1 {
 		err := tx.DeleteBucket([]byte(path[0]))
 
 		switch {
-		case errors.Is(err, berr.ErrBucketNotFound):
+		case errors.Is(err, ErrBucketNotFound):
 			return nil
 		case err != nil:
 			return err
@@ -169,7 +227,7 @@ func DeleteBucket(tx *bolt.Tx, path Path) error {
 	err = b.DeleteBucket([]byte(path[len(path)-1]))
 
 	switch {
-	case errors.Is(err, berr.ErrBucketNotFound):
+	case errors.Is(err, ErrBucketNotFound):
 		return nil
 	case err != nil:
 		return err
@@ -179,7 +237,7 @@ func DeleteBucket(tx *bolt.Tx, path Path) error {
 }
 
 // BucketExists, check if bucket path exists.
-func BucketExists(tx *bolt.Tx, path Path) (bool, error) {
+func BucketExists(tx Tx, path Path) (bool, error) {
 	_, err := SetBucket(tx, path)
 
 	switch {
@@ -193,7 +251,7 @@ func BucketExists(tx *bolt.Tx, path Path) (bool, error) {
 }
 
 // ListBuckets, returns the bucket name underneath the path.
-func ListBuckets(tx *bolt.Tx, path Path) ([]string, error) {
+func ListBuckets(tx Tx, path Path) ([]string, error) {
 	results := []string{}
 
 	b, err := SetBucket(tx, path)
@@ -210,7 +268,7 @@ func ListBuckets(tx *bolt.Tx, path Path) ([]string, error) {
 }
 
 // SetKey, set key and value in the path specified bucket.
-func SetKey(tx *bolt.Tx, path Path, key, value []byte) error {
+func SetKey(tx Tx, path Path, key, value []byte) error {
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return err
@@ -224,7 +282,7 @@ func SetKey(tx *bolt.Tx, path Path, key, value []byte) error {
 }
 
 // DeleteKey, delete key and value in path specified bucket, when it exists. None existing keys will not raise an error.
-func DeleteKey(tx *bolt.Tx, path Path, key []byte) error {
+func DeleteKey(tx Tx, path Path, key []byte) error {
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return err
@@ -238,7 +296,7 @@ func DeleteKey(tx *bolt.Tx, path Path, key []byte) error {
 }
 
 // GetKey, get key and value from path specified bucket.
-func GetKey(tx *bolt.Tx, path Path, key []byte) ([]byte, error) {
+func GetKey(tx Tx, path Path, key []byte) ([]byte, error) {
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return []byte{}, err
@@ -257,7 +315,7 @@ func GetKey(tx *bolt.Tx, path Path, key []byte) ([]byte, error) {
 }
 
 // KeyExists, check if the key exists in the path specified bucket.
-func KeyExists(tx *bolt.Tx, path Path, key []byte) (bool, error) {
+func KeyExists(tx Tx, path Path, key []byte) (bool, error) {
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return false, err
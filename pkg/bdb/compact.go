@@ -0,0 +1,264 @@
+package bdb
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"time"
+
+	cerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-edge-ds/pkg/fs"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+)
+
+// compactTxMaxSize, maximum size of the write transactions used to copy the store into the compacted file.
+const compactTxMaxSize int64 = 64 * 1024
+
+var ErrCompactionNotSupported = cerr.NewAsertoError("E20075", codes.FailedPrecondition, http.StatusPreconditionFailed,
+	"compaction is not supported by the store backend")
+
+// CompactStats, file size and freelist statistics of the store, before and after compaction.
+type CompactStats struct {
+	Before    FileStats
+	After     FileStats
+	Journaled int // number of paths written during the copy, copied into the compacted file after the copy.
+	Duration  time.Duration
+}
+
+// FileStats, file size and freelist statistics of a store file.
+type FileStats struct {
+	Size          int64 // file size in bytes.
+	FreePages     int   // number of free pages on the freelist.
+	PendingPages  int   // number of pending pages on the freelist.
+	FreeAlloc     int   // bytes allocated in free pages.
+	FreelistInuse int   // bytes used by the freelist.
+}
+
+// compactor, backend which can compact its database file.
+type compactor interface {
+	Compact() (*CompactStats, error)
+}
+
+// Compact, compacts the store, the store is copied into a new file, which is verified against the store,
+// and replaces the store file. Reads and writes continue during the copy, writes are paused while the writes
+// made during the copy are copied into the new file and it is swapped in, reads continue until the swap.
+// Returns ErrCompactionNotSupported when the store backend does not support compaction.
+func (s *BoltDB) Compact() (*CompactStats, error) {
+	db, ok := s.db.(compactor)
+	if !ok {
+		return nil, ErrCompactionNotSupported.Msg(s.config.Backend)
+	}
+
+	stats, err := db.Compact()
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().
+		Int64("size_before", stats.Before.Size).
+		Int64("size_after", stats.After.Size).
+		Int("free_pages_before", stats.Before.FreePages).
+		Int("free_pages_after", stats.After.FreePages).
+		Int("journaled", stats.Journaled).
+		Dur("duration", stats.Duration).
+		Msg("compact")
+
+	return stats, nil
+}
+
+// Compact, copies the database into a new file using bolt.Compact, while writes continue and are recorded in the
+// write journal, the writes are then paused to copy the journaled paths into the new file, which is verified
+// and swapped in.
+func (b *boltBackend) Compact() (*CompactStats, error) {
+	b.swapMu.Lock()
+	defer b.swapMu.Unlock()
+
+	start := time.Now()
+
+	// the database handle is only replaced while holding swapMu.
+	src := b.db
+
+	before, err := fileStats(src)
+	if err != nil {
+		return nil, err
+	}
+
+	path := src.Path() + ".compact"
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	defer func() { _ = os.Remove(path) }()
+
+	dst, err := bolt.Open(path, fs.FileModeOwnerRW, b.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = dst.Close() }()
+
+	// the journal is started before the copy begins, writes committed before the copy are included in the copy,
+	// writes committed after are recorded.
+	b.writeMu.Lock()
+	b.journal = newWriteJournal()
+	b.writeMu.Unlock()
+
+	if err := b.copyTo(dst); err != nil {
+		b.stopJournal()
+		return nil, err
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	journal := b.journal
+	b.journal = nil
+
+	if err := b.catchUp(dst, journal); err != nil {
+		return nil, err
+	}
+
+	if err := dst.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := b.swap(path, nil); err != nil {
+		return nil, err
+	}
+
+	after, err := fileStats(b.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompactStats{Before: before, After: after, Journaled: journal.len(), Duration: time.Since(start)}, nil
+}
+
+// copyTo, copies the database into dst, and checks the consistency of dst, while writes continue.
+func (b *boltBackend) copyTo(dst *bolt.DB) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if err := bolt.Compact(dst, b.db, compactTxMaxSize); err != nil {
+		return errors.Wrap(err, "compaction failed")
+	}
+
+	if err := checkCompaction(dst); err != nil {
+		return errors.Wrap(err, "compaction verification failed")
+	}
+
+	return nil
+}
+
+// catchUp, copies the paths recorded in the journal into dst, and compares the buckets of dst with the database,
+// the caller must hold the write lock of writeMu.
+func (b *boltBackend) catchUp(dst *bolt.DB, journal *writeJournal) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	err := b.db.View(func(stx *bolt.Tx) error {
+		return dst.Update(func(dtx *bolt.Tx) error {
+			return journal.apply(stx, dtx)
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, "compaction catch-up failed")
+	}
+
+	if err := verifyCompaction(b.db, dst); err != nil {
+		return errors.Wrap(err, "compaction verification failed")
+	}
+
+	return nil
+}
+
+func (b *boltBackend) stopJournal() {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.journal = nil
+}
+
+// checkCompaction, checks the consistency of the compacted database.
+func checkCompaction(dst *bolt.DB) error {
+	return dst.View(func(dtx *bolt.Tx) error {
+		// the check channel is drained, the check holds the transaction until all errors are received.
+		var checkErr error
+
+		for err := range dtx.Check() {
+			if checkErr == nil {
+				checkErr = err
+			}
+		}
+
+		return checkErr
+	})
+}
+
+// verifyCompaction, compares the buckets and key counts of the compacted database with the source database.
+func verifyCompaction(src, dst *bolt.DB) error {
+	return src.View(func(stx *bolt.Tx) error {
+		return dst.View(func(dtx *bolt.Tx) error {
+			if err := compareBuckets(stx, dtx); err != nil {
+				return err
+			}
+
+			return compareBuckets(dtx, stx)
+		})
+	})
+}
+
+// compareBuckets, checks all top-level buckets of tx exist in other, with the same number of keys.
+func compareBuckets(tx, other *bolt.Tx) error {
+	return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		ob := other.Bucket(name)
+		if ob == nil {
+			return errors.Errorf("bucket %q not found", name)
+		}
+
+		if n, on := b.Stats().KeyN, ob.Stats().KeyN; n != on {
+			return errors.Errorf("bucket %q key count mismatch %d != %d", name, n, on)
+		}
+
+		// compare the first and last keys, which detects keys copied out of order.
+		c, oc := b.Cursor(), ob.Cursor()
+
+		key, _ := c.First()
+		otherKey, _ := oc.First()
+
+		if !bytes.Equal(key, otherKey) {
+			return errors.Errorf("bucket %q first key mismatch", name)
+		}
+
+		key, _ = c.Last()
+		otherKey, _ = oc.Last()
+
+		if !bytes.Equal(key, otherKey) {
+			return errors.Errorf("bucket %q last key mismatch", name)
+		}
+
+		return nil
+	})
+}
+
+func fileStats(db *bolt.DB) (FileStats, error) {
+	fi, err := os.Stat(db.Path())
+	if err != nil {
+		return FileStats{}, err
+	}
+
+	// the freelist stats are refreshed by write transactions, the free page count is also set when opening the file.
+	stats := db.Stats()
+
+	return FileStats{
+		Size:          fi.Size(),
+		FreePages:     stats.FreePageN,
+		PendingPages:  stats.PendingPageN,
+		FreeAlloc:     (stats.FreePageN + stats.PendingPageN) * db.Info().PageSize,
+		FreelistInuse: stats.FreelistInuse,
+	}, nil
+}
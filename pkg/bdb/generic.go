@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -46,7 +45,7 @@ func unmarshalTo[T any, M Message[T]](b []byte, dst M) error {
 	return dst.UnmarshalVT(b)
 }
 
-func Get[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, key []byte) (M, error) {
+func Get[T any, M Message[T]](ctx context.Context, tx Tx, path Path, key []byte) (M, error) {
 	buf, err := GetKey(tx, path, key)
 	if err != nil {
 		return nil, err
@@ -55,7 +54,7 @@ func Get[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, key [
 	return unmarshal[T, M](buf)
 }
 
-func List[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path) ([]M, error) {
+func List[T any, M Message[T]](ctx context.Context, tx Tx, path Path) ([]M, error) {
 	result := []M{}
 
 	b, err := SetBucket(tx, path)
@@ -77,7 +76,7 @@ func List[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path) ([]M
 	return result, nil
 }
 
-func Set[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, key []byte, t M) (M, error) {
+func Set[T any, M Message[T]](ctx context.Context, tx Tx, path Path, key []byte, t M) (M, error) {
 	buf, err := marshal(t)
 	if err != nil {
 		return nil, err
@@ -90,7 +89,7 @@ func Set[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, key [
 	return t, nil
 }
 
-func Delete(ctx context.Context, tx *bolt.Tx, path Path, key []byte) error {
+func Delete(ctx context.Context, tx Tx, path Path, key []byte) error {
 	return DeleteKey(tx, path, key)
 }
 
@@ -107,7 +106,7 @@ func unmarshalAny[T any](buf []byte) (*T, error) {
 	return &t, nil
 }
 
-func GetAny[T any](ctx context.Context, tx *bolt.Tx, path Path, key []byte) (*T, error) {
+func GetAny[T any](ctx context.Context, tx Tx, path Path, key []byte) (*T, error) {
 	buf, err := GetKey(tx, path, key)
 	if err != nil {
 		return nil, err
@@ -116,7 +115,7 @@ func GetAny[T any](ctx context.Context, tx *bolt.Tx, path Path, key []byte) (*T,
 	return unmarshalAny[T](buf)
 }
 
-func SetAny[T any](ctx context.Context, tx *bolt.Tx, path Path, key []byte, t *T) (*T, error) {
+func SetAny[T any](ctx context.Context, tx Tx, path Path, key []byte, t *T) (*T, error) {
 	buf, err := marshalAny(t)
 	if err != nil {
 		return nil, err
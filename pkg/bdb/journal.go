@@ -0,0 +1,291 @@
+package bdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// journalOp, kind of write recorded in the write journal.
+type journalOp byte
+
+const (
+	journalKey           journalOp = iota // a key was put or deleted.
+	journalBucketCreated                  // a bucket was created, when it did not exist.
+	journalBucketDeleted                  // a bucket was deleted, including its keys and nested buckets.
+)
+
+// writeJournal, records the paths written by the write transactions of the database while it is copied by a compaction,
+// the recorded paths are copied from the database into the compacted file, once the writes are paused.
+//
+// Paths are recorded when written, regardless of the outcome of the transaction, and are copied in the state of the
+// database at the time of the catch-up, recording a path which is not committed is harmless.
+type writeJournal struct {
+	mu      sync.Mutex
+	entries map[string]journalEntry
+}
+
+type journalEntry struct {
+	op   journalOp
+	path [][]byte
+}
+
+func newWriteJournal() *writeJournal {
+	return &writeJournal{entries: map[string]journalEntry{}}
+}
+
+func (j *writeJournal) record(op journalOp, path [][]byte, name []byte) {
+	full := make([][]byte, 0, len(path)+1)
+	for _, p := range path {
+		full = append(full, bytes.Clone(p))
+	}
+
+	full = append(full, bytes.Clone(name))
+
+	// the entry key is the op followed by the length prefixed path elements, which is unique for each op and path.
+	key := []byte{byte(op)}
+	for _, p := range full {
+		key = binary.AppendUvarint(key, uint64(len(p)))
+		key = append(key, p...)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries[string(key)] = journalEntry{op: op, path: full}
+}
+
+// len, returns the number of recorded paths.
+func (j *writeJournal) len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return len(j.entries)
+}
+
+// apply, copies the recorded paths from src to dst, each path is set to its state in src,
+// the outcome does not depend on the order in which the paths are copied.
+func (j *writeJournal) apply(src, dst *bolt.Tx) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, e := range j.entries {
+		if err := syncPath(src, dst, e.path, e.op == journalBucketDeleted); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// container, a transaction (root) or bucket, holding nested buckets.
+type container interface {
+	Bucket(name []byte) *bolt.Bucket
+	CreateBucketIfNotExists(name []byte) (*bolt.Bucket, error)
+	CreateBucket(name []byte) (*bolt.Bucket, error)
+	DeleteBucket(name []byte) error
+}
+
+// syncPath, sets the key or bucket at path in dst to its state in src, a nested bucket is copied including its
+// keys and nested buckets when deep is set, otherwise only its existence is synchronized.
+func syncPath(src, dst *bolt.Tx, path [][]byte, deep bool) error {
+	parent, name := path[:len(path)-1], path[len(path)-1]
+
+	sp := lookup(src, parent)
+	dp := lookup(dst, parent)
+
+	if sp != nil {
+		if sb := sp.Bucket(name); sb != nil {
+			if !deep {
+				_, err := create(dst, path)
+				return err
+			}
+
+			if dp != nil {
+				if err := remove(dp, name); err != nil {
+					return err
+				}
+			}
+
+			target, err := create(dst, parent)
+			if err != nil {
+				return err
+			}
+
+			return copyBucket(sb, target, name)
+		}
+
+		if sb, ok := sp.(*bolt.Bucket); ok {
+			if v := sb.Get(name); v != nil {
+				target, err := create(dst, parent)
+				if err != nil {
+					return err
+				}
+
+				if target.Bucket(name) != nil {
+					if err := target.DeleteBucket(name); err != nil {
+						return err
+					}
+				}
+
+				// keys are only held by buckets, the parent of a key is not the root.
+				return target.(*bolt.Bucket).Put(name, v)
+			}
+		}
+	}
+
+	// the path does not exist in src.
+	if dp == nil {
+		return nil
+	}
+
+	return remove(dp, name)
+}
+
+// lookup, returns the container at path, or nil when it does not exist.
+func lookup(tx *bolt.Tx, path [][]byte) container {
+	var c container = tx
+
+	for _, name := range path {
+		b := c.Bucket(name)
+		if b == nil {
+			return nil
+		}
+
+		c = b
+	}
+
+	return c
+}
+
+// create, returns the container at path, creating the missing buckets.
+func create(tx *bolt.Tx, path [][]byte) (container, error) {
+	var c container = tx
+
+	for _, name := range path {
+		b, err := c.CreateBucketIfNotExists(name)
+		if err != nil {
+			return nil, err
+		}
+
+		c = b
+	}
+
+	return c, nil
+}
+
+// remove, deletes the key or bucket name from c, when it exists.
+func remove(c container, name []byte) error {
+	if c.Bucket(name) != nil {
+		return c.DeleteBucket(name)
+	}
+
+	if b, ok := c.(*bolt.Bucket); ok {
+		return b.Delete(name)
+	}
+
+	return nil
+}
+
+// copyBucket, copies src, including its keys and nested buckets, into the new bucket name of dst.
+func copyBucket(src *bolt.Bucket, dst container, name []byte) error {
+	b, err := dst.CreateBucket(name)
+	if err != nil {
+		return err
+	}
+
+	if err := b.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+
+	return src.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return copyBucket(src.Bucket(k), b, k)
+		}
+
+		return b.Put(k, v)
+	})
+}
+
+// journalTx, write transaction recording the written paths in the write journal.
+type journalTx struct {
+	boltTx
+	j *writeJournal
+}
+
+func (t *journalTx) Bucket(name []byte) Bucket {
+	b := t.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+
+	return &journalBucket{boltBucket: boltBucket{b: b}, j: t.j, path: [][]byte{name}}
+}
+
+func (t *journalTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if t.tx.Bucket(name) == nil {
+		t.j.record(journalBucketCreated, nil, name)
+	}
+
+	b, err := t.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journalBucket{boltBucket: boltBucket{b: b}, j: t.j, path: [][]byte{name}}, nil
+}
+
+func (t *journalTx) DeleteBucket(name []byte) error {
+	t.j.record(journalBucketDeleted, nil, name)
+	return t.tx.DeleteBucket(name)
+}
+
+// journalBucket, bucket of a write transaction recording the written paths in the write journal.
+type journalBucket struct {
+	boltBucket
+	j    *writeJournal
+	path [][]byte
+}
+
+func (b *journalBucket) child(name []byte) [][]byte {
+	return append(b.path[:len(b.path):len(b.path)], name)
+}
+
+func (b *journalBucket) Put(key, value []byte) error {
+	b.j.record(journalKey, b.path, key)
+	return b.b.Put(key, value)
+}
+
+func (b *journalBucket) Delete(key []byte) error {
+	b.j.record(journalKey, b.path, key)
+	return b.b.Delete(key)
+}
+
+func (b *journalBucket) Bucket(name []byte) Bucket {
+	nb := b.b.Bucket(name)
+	if nb == nil {
+		return nil
+	}
+
+	return &journalBucket{boltBucket: boltBucket{b: nb}, j: b.j, path: b.child(name)}
+}
+
+func (b *journalBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if b.b.Bucket(name) == nil {
+		b.j.record(journalBucketCreated, b.path, name)
+	}
+
+	nb, err := b.b.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journalBucket{boltBucket: boltBucket{b: nb}, j: b.j, path: b.child(name)}, nil
+}
+
+func (b *journalBucket) DeleteBucket(name []byte) error {
+	b.j.record(journalBucketDeleted, b.path, name)
+	return b.b.DeleteBucket(name)
+}
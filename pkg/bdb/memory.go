@@ -0,0 +1,492 @@
+package bdb
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/google/btree"
+)
+
+// memoryBackend, ephemeral, pure in-memory backend.
+//
+// The backend uses copy-on-write snapshots: read-only transactions operate on the root node
+// committed at the start of the transaction, the (single) read-write transaction clones the nodes
+// along the path of each modified bucket and atomically swaps in its root node on commit.
+// The entries of a bucket node are kept in a copy-on-write B-tree, cloning a node is O(1),
+// a modification of a cloned node copies the O(log n) tree nodes along the path of the modified key only.
+// Committed nodes are never modified, readers therefore never block writers and vice versa.
+type memoryBackend struct {
+	mu     sync.RWMutex // protects root and closed.
+	wmu    sync.Mutex   // serializes read-write transactions.
+	root   *memNode
+	closed bool
+	notify *notifier
+}
+
+var _ Backend = (*memoryBackend)(nil)
+
+func newMemoryBackend(notify *notifier) *memoryBackend {
+	return &memoryBackend{root: newMemNode(), notify: notify}
+}
+
+func (m *memoryBackend) snapshot() (*memNode, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return nil, ErrDatabaseNotOpen
+	}
+
+	return m.root, nil
+}
+
+func (m *memoryBackend) View(fn func(Tx) error) error {
+	root, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+
+	return fn(&memTx{root: root})
+}
+
+func (m *memoryBackend) Update(fn func(Tx) error) error {
+	m.wmu.Lock()
+	defer m.wmu.Unlock()
+
+	root, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+
+	tx := &memTx{root: root, writable: true, owned: map[*memNode]struct{}{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.root = tx.root
+	m.mu.Unlock()
+
+	m.notify.broadcast()
+
+	return nil
+}
+
+func (m *memoryBackend) Batch(fn func(Tx) error) error {
+	return m.Update(fn)
+}
+
+func (m *memoryBackend) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.root = nil
+	m.closed = true
+
+	return nil
+}
+
+// memTreeDegree, degree of the B-tree of the bucket entries.
+const memTreeDegree int = 32
+
+// memNode, entries of a bucket, ordered by key.
+type memNode struct {
+	entries *btree.BTreeG[memEntry]
+}
+
+// memEntry, key-value pair, or nested bucket when child is set.
+type memEntry struct {
+	key   []byte
+	value []byte
+	child *memNode
+}
+
+func memEntryLess(a, b memEntry) bool {
+	return bytes.Compare(a.key, b.key) < 0
+}
+
+func newMemNode() *memNode {
+	return &memNode{entries: btree.NewG(memTreeDegree, memEntryLess)}
+}
+
+// get, returns the entry of the key and whether the key exists.
+func (n *memNode) get(key []byte) (memEntry, bool) {
+	return n.entries.Get(memEntry{key: key})
+}
+
+// clone, returns a lazy copy of the node, the tree nodes are shared until modified.
+func (n *memNode) clone() *memNode {
+	return &memNode{entries: n.entries.Clone()}
+}
+
+// ceil, returns the first entry with a key greater than (or equal to, when inclusive) the key.
+func (n *memNode) ceil(key []byte, inclusive bool) (memEntry, bool) {
+	var result memEntry
+
+	found := false
+
+	n.entries.AscendGreaterOrEqual(memEntry{key: key}, func(e memEntry) bool {
+		if !inclusive && bytes.Equal(e.key, key) {
+			return true
+		}
+
+		result, found = e, true
+
+		return false
+	})
+
+	return result, found
+}
+
+// floor, returns the last entry with a key less than the key.
+func (n *memNode) floor(key []byte) (memEntry, bool) {
+	var result memEntry
+
+	found := false
+
+	n.entries.DescendLessOrEqual(memEntry{key: key}, func(e memEntry) bool {
+		if bytes.Equal(e.key, key) {
+			return true
+		}
+
+		result, found = e, true
+
+		return false
+	})
+
+	return result, found
+}
+
+type memTx struct {
+	root     *memNode
+	writable bool
+	owned    map[*memNode]struct{} // nodes created by the transaction, which can be modified in place.
+}
+
+func (t *memTx) Writable() bool {
+	return t.writable
+}
+
+func (t *memTx) Bucket(name []byte) Bucket {
+	return (&memBucket{tx: t}).Bucket(name)
+}
+
+func (t *memTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	return (&memBucket{tx: t}).CreateBucketIfNotExists(name)
+}
+
+func (t *memTx) DeleteBucket(name []byte) error {
+	return (&memBucket{tx: t}).DeleteBucket(name)
+}
+
+func (t *memTx) own(n *memNode) *memNode {
+	if _, ok := t.owned[n]; ok {
+		return n
+	}
+
+	c := n.clone()
+	t.owned[c] = struct{}{}
+
+	return c
+}
+
+// memBucket, bucket handle, identified by its path from the transaction root,
+// the bucket node is resolved on each operation, so handles observe all writes of the transaction.
+type memBucket struct {
+	tx   *memTx
+	path [][]byte
+}
+
+// node, resolves the bucket node, returns nil when the bucket no longer exists.
+func (b *memBucket) node() *memNode {
+	n := b.tx.root
+
+	for _, name := range b.path {
+		e, ok := n.get(name)
+		if !ok || e.child == nil {
+			return nil
+		}
+
+		n = e.child
+	}
+
+	return n
+}
+
+// mutableNode, resolves the bucket node for modification, cloning the committed nodes along the bucket path.
+func (b *memBucket) mutableNode() (*memNode, error) {
+	if !b.tx.writable {
+		return nil, ErrTxNotWritable
+	}
+
+	b.tx.root = b.tx.own(b.tx.root)
+	n := b.tx.root
+
+	for _, name := range b.path {
+		e, ok := n.get(name)
+		if !ok || e.child == nil {
+			return nil, ErrBucketNotFound
+		}
+
+		if child := b.tx.own(e.child); child != e.child {
+			e.child = child
+			n.entries.ReplaceOrInsert(e)
+		}
+
+		n = e.child
+	}
+
+	return n, nil
+}
+
+func (b *memBucket) child(name []byte) *memBucket {
+	path := make([][]byte, 0, len(b.path)+1)
+	path = append(path, b.path...)
+
+	return &memBucket{tx: b.tx, path: append(path, bytes.Clone(name))}
+}
+
+func (b *memBucket) Get(key []byte) []byte {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+
+	e, ok := n.get(key)
+	if !ok || e.child != nil {
+		return nil
+	}
+
+	return e.value
+}
+
+func (b *memBucket) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrKeyRequired
+	}
+
+	n, err := b.mutableNode()
+	if err != nil {
+		return err
+	}
+
+	e, ok := n.get(key)
+
+	switch {
+	case ok && e.child != nil:
+		return ErrIncompatibleValue
+	case ok:
+		e.value = bytes.Clone(value)
+		n.entries.ReplaceOrInsert(e)
+	default:
+		n.entries.ReplaceOrInsert(memEntry{key: bytes.Clone(key), value: bytes.Clone(value)})
+	}
+
+	return nil
+}
+
+func (b *memBucket) Delete(key []byte) error {
+	n, err := b.mutableNode()
+	if err != nil {
+		return err
+	}
+
+	e, ok := n.get(key)
+
+	switch {
+	case !ok:
+		return nil
+	case e.child != nil:
+		return ErrIncompatibleValue
+	default:
+		n.entries.Delete(e)
+	}
+
+	return nil
+}
+
+func (b *memBucket) Bucket(name []byte) Bucket {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+
+	e, ok := n.get(name)
+	if !ok || e.child == nil {
+		return nil
+	}
+
+	return b.child(name)
+}
+
+func (b *memBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if len(name) == 0 {
+		return nil, ErrBucketNameRequired
+	}
+
+	n, err := b.mutableNode()
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := n.get(name)
+
+	switch {
+	case ok && e.child == nil:
+		return nil, ErrIncompatibleValue
+	case !ok:
+		child := newMemNode()
+		b.tx.owned[child] = struct{}{}
+		n.entries.ReplaceOrInsert(memEntry{key: bytes.Clone(name), child: child})
+	}
+
+	return b.child(name), nil
+}
+
+func (b *memBucket) DeleteBucket(name []byte) error {
+	n, err := b.mutableNode()
+	if err != nil {
+		return err
+	}
+
+	e, ok := n.get(name)
+
+	switch {
+	case !ok:
+		return ErrBucketNotFound
+	case e.child == nil:
+		return ErrIncompatibleValue
+	default:
+		n.entries.Delete(e)
+	}
+
+	return nil
+}
+
+func (b *memBucket) ForEachBucket(fn func(k []byte) error) error {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+
+	var err error
+
+	n.entries.Ascend(func(e memEntry) bool {
+		if e.child == nil {
+			return true
+		}
+
+		err = fn(e.key)
+
+		return err == nil
+	})
+
+	return err
+}
+
+func (b *memBucket) Cursor() Cursor {
+	return &memCursor{b: b}
+}
+
+func (b *memBucket) KeyN() int {
+	return keyN(b.node())
+}
+
+// keyN, returns the number of keys in the node and its nested buckets, equivalent to bolt.BucketStats.KeyN.
+func keyN(n *memNode) int {
+	if n == nil {
+		return 0
+	}
+
+	count := n.entries.Len()
+
+	n.entries.Ascend(func(e memEntry) bool {
+		count += keyN(e.child)
+		return true
+	})
+
+	return count
+}
+
+// memCursor, positions on keys rather than offsets, each move re-resolves the position in the
+// current bucket node, so modifying the bucket while iterating neither skips nor repeats entries.
+type memCursor struct {
+	b   *memBucket
+	key []byte
+}
+
+func (c *memCursor) at(e memEntry, ok bool) ([]byte, []byte) {
+	if !ok {
+		c.key = nil
+		return nil, nil
+	}
+
+	c.key = e.key
+
+	if e.child != nil {
+		return e.key, nil
+	}
+
+	return e.key, e.value
+}
+
+func (c *memCursor) First() ([]byte, []byte) {
+	n := c.b.node()
+	if n == nil {
+		return c.at(memEntry{}, false)
+	}
+
+	return c.at(n.entries.Min())
+}
+
+func (c *memCursor) Last() ([]byte, []byte) {
+	n := c.b.node()
+	if n == nil {
+		return c.at(memEntry{}, false)
+	}
+
+	return c.at(n.entries.Max())
+}
+
+func (c *memCursor) Next() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+
+	n := c.b.node()
+	if n == nil {
+		return c.at(memEntry{}, false)
+	}
+
+	return c.at(n.ceil(c.key, false))
+}
+
+func (c *memCursor) Prev() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+
+	n := c.b.node()
+	if n == nil {
+		return c.at(memEntry{}, false)
+	}
+
+	return c.at(n.floor(c.key))
+}
+
+func (c *memCursor) Seek(seek []byte) ([]byte, []byte) {
+	n := c.b.node()
+	if n == nil {
+		return c.at(memEntry{}, false)
+	}
+
+	return c.at(n.ceil(seek, true))
+}
+
+func (c *memCursor) Delete() error {
+	if c.key == nil {
+		return nil
+	}
+
+	return c.b.Delete(c.key)
+}
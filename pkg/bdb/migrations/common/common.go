@@ -252,7 +252,7 @@ func MigrateModelV1(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
 	}
 
 	if err := rwDB.Update(func(tx *bolt.Tx) error {
-		_, err := bdb.SetAny(ctx, tx, bdb.ManifestPathV1, bdb.ModelKey, m)
+		_, err := bdb.SetAny(ctx, bdb.BoltTx(tx), bdb.ManifestPathV1, bdb.ModelKey, m)
 		return err
 	}); err != nil {
 		return err
@@ -264,7 +264,7 @@ func MigrateModelV1(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
 func loadModelV1(ctx context.Context, roDB *bolt.DB) (*model.Model, error) {
 	var m *model.Model
 	if err := roDB.View(func(rtx *bolt.Tx) error {
-		manifestBody, err := bdb.Get[dsm3.Body](ctx, rtx, bdb.ManifestPathV1, bdb.BodyKey)
+		manifestBody, err := bdb.Get[dsm3.Body](ctx, bdb.BoltTx(rtx), bdb.ManifestPathV1, bdb.BodyKey)
 		if err != nil {
 			return err
 		}
@@ -293,7 +293,7 @@ func MigrateModelV2(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
 	}
 
 	if err := rwDB.Update(func(tx *bolt.Tx) error {
-		_, err := bdb.SetAny(ctx, tx, bdb.ManifestPathV2, bdb.ModelKey, m)
+		_, err := bdb.SetAny(ctx, bdb.BoltTx(tx), bdb.ManifestPathV2, bdb.ModelKey, m)
 		return err
 	}); err != nil {
 		return err
@@ -305,7 +305,7 @@ func MigrateModelV2(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
 func loadModelV2(ctx context.Context, roDB *bolt.DB) (*model.Model, error) {
 	var m *model.Model
 	if err := roDB.View(func(rtx *bolt.Tx) error {
-		manifestBody, err := bdb.Get[dsm3.Body](ctx, rtx, bdb.ManifestPathV2, bdb.BodyKey)
+		manifestBody, err := bdb.Get[dsm3.Body](ctx, bdb.BoltTx(rtx), bdb.ManifestPathV2, bdb.BodyKey)
 		if err != nil {
 			return err
 		}
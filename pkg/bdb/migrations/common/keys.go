@@ -0,0 +1,88 @@
+package common
+
+import (
+	"bytes"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+)
+
+// Legacy key encodings, the separator based relation key encodings used by the schema versions before 0.0.15,
+// which replaced them by the compact interned key encoding. Used by the migrations of these schema versions.
+
+const maxLegacyKeySize int = 384
+
+// LegacyObjKey
+// format: obj_type : obj_id | relation | sub_type : sub_id (| sub_relation).
+func LegacyObjKey(rel *dsc3.Relation) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, maxLegacyKeySize))
+
+	buf.WriteString(rel.GetObjectType())
+	buf.WriteByte(ds.TypeIDSeparator)
+	buf.WriteString(rel.GetObjectId())
+
+	buf.WriteByte(ds.InstanceSeparator)
+	buf.WriteString(rel.GetRelation())
+	buf.WriteByte(ds.InstanceSeparator)
+
+	buf.WriteString(rel.GetSubjectType())
+	buf.WriteByte(ds.TypeIDSeparator)
+	buf.WriteString(rel.GetSubjectId())
+
+	if rel.GetSubjectRelation() != "" {
+		buf.WriteByte(ds.InstanceSeparator)
+		buf.WriteString(rel.GetSubjectRelation())
+	}
+
+	return buf.Bytes()
+}
+
+// LegacySubKey
+// format: sub_type : sub_id | relation | obj_type : obj_id (| sub_relation).
+func LegacySubKey(rel *dsc3.Relation) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, maxLegacyKeySize))
+
+	buf.WriteString(rel.GetSubjectType())
+	buf.WriteByte(ds.TypeIDSeparator)
+	buf.WriteString(rel.GetSubjectId())
+
+	buf.WriteByte(ds.InstanceSeparator)
+	buf.WriteString(rel.GetRelation())
+	buf.WriteByte(ds.InstanceSeparator)
+
+	buf.WriteString(rel.GetObjectType())
+	buf.WriteByte(ds.TypeIDSeparator)
+	buf.WriteString(rel.GetObjectId())
+
+	if rel.GetSubjectRelation() != "" {
+		buf.WriteByte(ds.InstanceSeparator)
+		buf.WriteString(rel.GetSubjectRelation())
+	}
+
+	return buf.Bytes()
+}
+
+// LegacyRelKey
+// format: obj_type # relation | sub_type : sub_id (# sub_relation) | obj_id.
+func LegacyRelKey(rel *dsc3.Relation) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, maxLegacyKeySize))
+
+	buf.WriteString(rel.GetObjectType())
+	buf.WriteByte(ds.RelationSeparator)
+	buf.WriteString(rel.GetRelation())
+	buf.WriteByte(ds.InstanceSeparator)
+
+	buf.WriteString(rel.GetSubjectType())
+	buf.WriteByte(ds.TypeIDSeparator)
+	buf.WriteString(rel.GetSubjectId())
+
+	if rel.GetSubjectRelation() != "" {
+		buf.WriteByte(ds.RelationSeparator)
+		buf.WriteString(rel.GetSubjectRelation())
+	}
+
+	buf.WriteByte(ds.InstanceSeparator)
+	buf.WriteString(rel.GetObjectId())
+
+	return buf.Bytes()
+}
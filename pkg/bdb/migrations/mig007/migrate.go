@@ -6,7 +6,6 @@ import (
 
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
-	"github.com/aserto-dev/go-edge-ds/pkg/ds"
 
 	"github.com/rs/zerolog"
 	bolt "go.etcd.io/bbolt"
@@ -217,11 +216,11 @@ func updateEncodingRelations() func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
 					return err
 				}
 
-				if err := common.SetKey(wtx, bdb.RelationsObjPath, ds.Relation(rel).ObjKey(), val); err != nil {
+				if err := common.SetKey(wtx, bdb.RelationsObjPath, common.LegacyObjKey(rel), val); err != nil {
 					return err
 				}
 
-				if err := common.SetKey(wtx, bdb.RelationsSubPath, ds.Relation(rel).SubKey(), val); err != nil {
+				if err := common.SetKey(wtx, bdb.RelationsSubPath, common.LegacySubKey(rel), val); err != nil {
 					return err
 				}
 			}
@@ -0,0 +1,122 @@
+package mig010
+
+import (
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/*
+mig010
+
+add updated_at ordered index buckets, used by incremental (watermark) exports.
+
+* objects_updated_at	key: updated_at | object key		value: object key
+* relations_updated_at	key: updated_at | relation obj key	value: relation obj key
+*/
+
+const (
+	Version string = "0.0.10"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+
+	common.DeleteBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	indexObjects(),
+
+	common.DeleteBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	indexRelations(),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// indexObjects, read objects from read-only backup, write updated_at index entries.
+func indexObjects() func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
+	return func(log *zerolog.Logger, roDB *bolt.DB, rwDB *bolt.DB) error {
+		log.Info().Str("version", Version).Msg("indexObjects")
+
+		if roDB == nil {
+			log.Info().Bool("roDB", roDB == nil).Msg("indexObjects")
+			return nil
+		}
+
+		return index[dsc3.Object](roDB, rwDB, bdb.ObjectsPath, bdb.ObjectsUpdatedAtPath)
+	}
+}
+
+// indexRelations, read relations from read-only backup, write updated_at index entries.
+func indexRelations() func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
+	return func(log *zerolog.Logger, roDB *bolt.DB, rwDB *bolt.DB) error {
+		log.Info().Str("version", Version).Msg("indexRelations")
+
+		if roDB == nil {
+			log.Info().Bool("roDB", roDB == nil).Msg("indexRelations")
+			return nil
+		}
+
+		return index[dsc3.Relation](roDB, rwDB, bdb.RelationsObjPath, bdb.RelationsUpdatedAtPath)
+	}
+}
+
+type message[T any] interface {
+	proto.Message
+	GetUpdatedAt() *timestamppb.Timestamp
+	*T
+}
+
+func index[T any, M message[T]](roDB, rwDB *bolt.DB, srcPath, idxPath bdb.Path) error {
+	return roDB.View(func(rtx *bolt.Tx) error {
+		wtx, err := rwDB.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = wtx.Rollback() }()
+
+		b, err := common.SetBucket(rtx, srcPath)
+		if err != nil {
+			return err
+		}
+
+		c := b.Cursor()
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			var t T
+
+			msg := M(&t)
+			if err := proto.Unmarshal(value, msg); err != nil {
+				return err
+			}
+
+			if err := common.SetKey(wtx, idxPath, ds.TimestampKey(msg.GetUpdatedAt(), key), key); err != nil {
+				return err
+			}
+		}
+
+		return wtx.Commit()
+	})
+}
@@ -0,0 +1,46 @@
+package mig012
+
+import (
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// mig012
+//
+// add change log bucket, recording all store mutations keyed by revision, used by watchers.
+const (
+	Version string = "0.0.12"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+
+	common.CreateBucket(bdb.ChangeLogPath),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
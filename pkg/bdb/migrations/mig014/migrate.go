@@ -0,0 +1,99 @@
+package mig014
+
+import (
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+mig014
+
+add relation name index bucket, used by relation queries and graph searches which specify
+the object type and relation, but no complete object or subject identifier.
+
+* relations_rel	key: obj_type # relation | sub_type : sub_id (# sub_relation) | obj_id	value: relation instance
+*/
+
+const (
+	Version string = "0.0.14"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.RelationsExpiryPath),
+
+	common.DeleteBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	indexRelations(),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// indexRelations, read relations from read-only backup, write relation name index entries.
+func indexRelations() func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
+	return func(log *zerolog.Logger, roDB *bolt.DB, rwDB *bolt.DB) error {
+		log.Info().Str("version", Version).Msg("indexRelations")
+
+		if roDB == nil {
+			log.Info().Bool("roDB", roDB == nil).Msg("indexRelations")
+			return nil
+		}
+
+		return roDB.View(func(rtx *bolt.Tx) error {
+			wtx, err := rwDB.Begin(true)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = wtx.Rollback() }()
+
+			b, err := common.SetBucket(rtx, bdb.RelationsObjPath)
+			if err != nil {
+				return err
+			}
+
+			c := b.Cursor()
+			for key, value := c.First(); key != nil; key, value = c.Next() {
+				var rel dsc3.Relation
+				if err := proto.Unmarshal(value, &rel); err != nil {
+					return err
+				}
+
+				if err := common.SetKey(wtx, bdb.RelationsRelPath, common.LegacyRelKey(&rel), value); err != nil {
+					return err
+				}
+			}
+
+			return wtx.Commit()
+		})
+	}
+}
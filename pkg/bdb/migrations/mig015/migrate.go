@@ -0,0 +1,230 @@
+package mig015
+
+import (
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+mig015
+
+intern object type and relation names into name ids and rewrite the object and relation keys
+using the compact, length-prefixed key encoding.
+
+* _system/names		key: name	value: uvarint name id
+
+the data buckets are recreated and repopulated from the instances in the read-only backup,
+keyed by the new key encoding:
+
+* objects			key: type_id | len(id) id
+* relations_obj			key: obj_type_id | len(obj_id) obj_id | relation_id | sub_type_id | len(sub_id) sub_id | sub_relation_id
+* relations_sub			key: sub_type_id | len(sub_id) sub_id | relation_id | obj_type_id | len(obj_id) obj_id | sub_relation_id
+* relations_rel			key: obj_type_id | relation_id | sub_type_id | len(sub_id) sub_id | sub_relation_id | len(obj_id) obj_id
+* objects_updated_at		key: updated_at | object key
+* relations_updated_at		key: updated_at | relation obj key
+* objects_tombstones		key: object key
+* relations_tombstones		key: relation obj key
+* relations_expiry		key: relation obj key
+
+the new key encoding changes the iteration order of the data buckets, which is no longer lexical:
+object types and relations are ordered by name id, object ids by length first, then lexically.
+the key order is the order of unordered GetObjects and GetRelations listings and their pages,
+page tokens of the previous key encoding are translated (ds.TranslateKey), a listing paged across
+the migration resumes at the translated key in the new order, and can skip or repeat instances.
+*/
+
+const (
+	Version string = "0.0.15"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.DeleteBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.NamesPath),
+
+	common.DeleteBucket(bdb.ObjectsPath),
+	common.DeleteBucket(bdb.RelationsObjPath),
+	common.DeleteBucket(bdb.RelationsSubPath),
+	common.DeleteBucket(bdb.RelationsRelPath),
+	common.DeleteBucket(bdb.ObjectsUpdatedAtPath),
+	common.DeleteBucket(bdb.RelationsUpdatedAtPath),
+	common.DeleteBucket(bdb.ObjectTombstonesPath),
+	common.DeleteBucket(bdb.RelationTombstonesPath),
+	common.DeleteBucket(bdb.RelationsExpiryPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+
+	rewriteKeys(),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// rewriteKeys, read objects, relations and tombstones from read-only backup, intern their names,
+// write them and their index entries using the compact key encoding.
+func rewriteKeys() func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
+	return func(log *zerolog.Logger, roDB *bolt.DB, rwDB *bolt.DB) error {
+		log.Info().Str("version", Version).Msg("rewriteKeys")
+
+		if roDB == nil {
+			log.Info().Bool("roDB", roDB == nil).Msg("rewriteKeys")
+			return nil
+		}
+
+		return roDB.View(func(rtx *bolt.Tx) error {
+			wtx, err := rwDB.Begin(true)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = wtx.Rollback() }()
+
+			tx := bdb.BoltTx(wtx)
+
+			for _, fn := range []func(*bolt.Tx, bdb.Tx) error{
+				rewriteObjects,
+				rewriteRelations,
+				rewriteObjectTombstones,
+				rewriteRelationTombstones,
+			} {
+				if err := fn(rtx, tx); err != nil {
+					return err
+				}
+			}
+
+			return wtx.Commit()
+		})
+	}
+}
+
+func rewriteObjects(rtx *bolt.Tx, tx bdb.Tx) error {
+	return forEach(rtx, bdb.ObjectsPath, func(_, value []byte) error {
+		var obj dsc3.Object
+		if err := proto.Unmarshal(value, &obj); err != nil {
+			return err
+		}
+
+		if err := ds.Intern(tx, obj.GetType()); err != nil {
+			return err
+		}
+
+		key := ds.Object(&obj).Key(tx)
+
+		if err := bdb.SetKey(tx, bdb.ObjectsPath, key, value); err != nil {
+			return err
+		}
+
+		return bdb.SetKey(tx, bdb.ObjectsUpdatedAtPath, ds.TimestampKey(obj.GetUpdatedAt(), key), key)
+	})
+}
+
+func rewriteRelations(rtx *bolt.Tx, tx bdb.Tx) error {
+	expiry, _ := common.SetBucket(rtx, bdb.RelationsExpiryPath)
+
+	return forEach(rtx, bdb.RelationsObjPath, func(legacyKey, value []byte) error {
+		var rel dsc3.Relation
+		if err := proto.Unmarshal(value, &rel); err != nil {
+			return err
+		}
+
+		if err := ds.Intern(tx, rel.GetObjectType(), rel.GetRelation(), rel.GetSubjectType(), rel.GetSubjectRelation()); err != nil {
+			return err
+		}
+
+		r := ds.Relation(&rel)
+		objKey := r.ObjKey(tx)
+
+		if err := bdb.SetKey(tx, bdb.RelationsObjPath, objKey, value); err != nil {
+			return err
+		}
+
+		if err := bdb.SetKey(tx, bdb.RelationsSubPath, r.SubKey(tx), value); err != nil {
+			return err
+		}
+
+		if err := bdb.SetKey(tx, bdb.RelationsRelPath, r.RelKey(tx), value); err != nil {
+			return err
+		}
+
+		if err := bdb.SetKey(tx, bdb.RelationsUpdatedAtPath, ds.TimestampKey(rel.GetUpdatedAt(), objKey), objKey); err != nil {
+			return err
+		}
+
+		if expiry == nil {
+			return nil
+		}
+
+		if v := expiry.Get(legacyKey); v != nil {
+			return bdb.SetKey(tx, bdb.RelationsExpiryPath, objKey, v)
+		}
+
+		return nil
+	})
+}
+
+func rewriteObjectTombstones(rtx *bolt.Tx, tx bdb.Tx) error {
+	return forEach(rtx, bdb.ObjectTombstonesPath, func(_, value []byte) error {
+		var obj dsc3.Object
+		if err := proto.Unmarshal(value, &obj); err != nil {
+			return err
+		}
+
+		if err := ds.Intern(tx, obj.GetType()); err != nil {
+			return err
+		}
+
+		return bdb.SetKey(tx, bdb.ObjectTombstonesPath, ds.Object(&obj).Key(tx), value)
+	})
+}
+
+func rewriteRelationTombstones(rtx *bolt.Tx, tx bdb.Tx) error {
+	return forEach(rtx, bdb.RelationTombstonesPath, func(_, value []byte) error {
+		var rel dsc3.Relation
+		if err := proto.Unmarshal(value, &rel); err != nil {
+			return err
+		}
+
+		if err := ds.Intern(tx, rel.GetObjectType(), rel.GetRelation(), rel.GetSubjectType(), rel.GetSubjectRelation()); err != nil {
+			return err
+		}
+
+		return bdb.SetKey(tx, bdb.RelationTombstonesPath, ds.Relation(&rel).ObjKey(tx), value)
+	})
+}
+
+// forEach, calls fn for each key and value of the bucket in the read-only backup, when the bucket exists.
+func forEach(rtx *bolt.Tx, path bdb.Path, fn func(key, value []byte) error) error {
+	b, err := common.SetBucket(rtx, path)
+	if err != nil {
+		return nil //nolint:nilerr // buckets introduced by later schema versions do not exist in older backups.
+	}
+
+	return b.ForEach(fn)
+}
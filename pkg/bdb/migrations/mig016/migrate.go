@@ -0,0 +1,135 @@
+package mig016
+
+import (
+	"encoding/binary"
+	"strings"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+mig016
+
+add stats bucket, containing the object and relation counters maintained by the write path.
+
+* stats		key: o|obj_type						value: object count
+* stats		key: r|obj_type|relation|sub_type|sub_relation		value: relation count
+*/
+
+const (
+	Version string = "0.0.16"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+
+	common.DeleteBucket(bdb.StatsPath),
+	common.CreateBucket(bdb.StatsPath),
+	countStats(),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// countStats, read objects and relations from read-only backup, write the stats counters.
+func countStats() func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
+	return func(log *zerolog.Logger, roDB *bolt.DB, rwDB *bolt.DB) error {
+		log.Info().Str("version", Version).Msg("countStats")
+
+		if roDB == nil {
+			log.Info().Bool("roDB", roDB == nil).Msg("countStats")
+			return nil
+		}
+
+		counts := map[string]uint64{}
+
+		if err := roDB.View(func(rtx *bolt.Tx) error {
+			if err := count(rtx, bdb.ObjectsPath, counts, func(obj *dsc3.Object) string {
+				return strings.Join([]string{"o", obj.GetType()}, "|")
+			}); err != nil {
+				return err
+			}
+
+			return count(rtx, bdb.RelationsObjPath, counts, func(rel *dsc3.Relation) string {
+				subType := rel.GetSubjectType()
+				if rel.GetSubjectId() == "*" {
+					subType += ":*"
+				}
+
+				return strings.Join([]string{"r", rel.GetObjectType(), rel.GetRelation(), subType, rel.GetSubjectRelation()}, "|")
+			})
+		}); err != nil {
+			return err
+		}
+
+		return rwDB.Update(func(wtx *bolt.Tx) error {
+			for key, n := range counts {
+				buf := make([]byte, 8)
+				binary.BigEndian.PutUint64(buf, n)
+
+				if err := common.SetKey(wtx, bdb.StatsPath, []byte(key), buf); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+}
+
+type message[T any] interface {
+	proto.Message
+	*T
+}
+
+// count, increments the counter of the stats key of each instance in the bucket.
+func count[T any, M message[T]](rtx *bolt.Tx, path bdb.Path, counts map[string]uint64, key func(M) string) error {
+	b, err := common.SetBucket(rtx, path)
+	if err != nil {
+		return err
+	}
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var t T
+
+		msg := M(&t)
+		if err := proto.Unmarshal(v, msg); err != nil {
+			return err
+		}
+
+		counts[key(msg)]++
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package mig017
+
+import (
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/*
+mig017
+
+add created_at timestamp indexes, used to list objects and relations in creation order.
+
+* objects_created_at		key: created_at | object key		value: object key
+* relations_created_at		key: created_at | relation obj key	value: relation obj key
+*/
+
+const (
+	Version string = "0.0.17"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+	common.CreateBucket(bdb.StatsPath),
+
+	common.CreateBucket(bdb.ObjectsCreatedAtPath),
+	common.CreateBucket(bdb.RelationsCreatedAtPath),
+	indexCreatedAt(bdb.ObjectsPath, bdb.ObjectsCreatedAtPath, func() createdAt { return &dsc3.Object{} }),
+	indexCreatedAt(bdb.RelationsObjPath, bdb.RelationsCreatedAtPath, func() createdAt { return &dsc3.Relation{} }),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+type createdAt interface {
+	proto.Message
+	GetCreatedAt() *timestamppb.Timestamp
+}
+
+// indexCreatedAt, add the instances of the migrated bucket to the created_at index.
+func indexCreatedAt(path, idxPath bdb.Path, newMsg func() createdAt) func(*zerolog.Logger, *bolt.DB, *bolt.DB) error {
+	return func(log *zerolog.Logger, roDB *bolt.DB, rwDB *bolt.DB) error {
+		log.Info().Str("version", Version).Strs("path", path).Msg("indexCreatedAt")
+
+		if roDB == nil {
+			log.Info().Bool("roDB", roDB == nil).Msg("indexCreatedAt")
+			return nil
+		}
+
+		return rwDB.Update(func(wtx *bolt.Tx) error {
+			b, err := common.SetBucket(wtx, path)
+			if err != nil {
+				return err
+			}
+
+			idx, err := common.SetBucket(wtx, idxPath)
+			if err != nil {
+				return err
+			}
+
+			return b.ForEach(func(key, value []byte) error {
+				msg := newMsg()
+				if err := proto.Unmarshal(value, msg); err != nil {
+					return err
+				}
+
+				return idx.Put(ds.TimestampKey(msg.GetCreatedAt(), key), key)
+			})
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package mig018
+
+import (
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+/*
+mig018
+
+add property index buckets, the declared property indexes and the index entries are maintained by the directory,
+indexes are built from the objects when they are declared in the directory config.
+
+* _system/property_indexes	key: len(obj_type) obj_type | len(property) property
+* objects_properties		key: type_id | len(property) property | len(value) value | len(id) id	value: object key
+*/
+
+const (
+	Version string = "0.0.18"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectsCreatedAtPath),
+	common.CreateBucket(bdb.RelationsCreatedAtPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+	common.CreateBucket(bdb.StatsPath),
+
+	common.CreateBucket(bdb.PropertyIndexesPath),
+	common.CreateBucket(bdb.ObjectPropertiesPath),
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
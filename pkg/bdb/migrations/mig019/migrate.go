@@ -0,0 +1,132 @@
+package mig019
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+mig019
+
+add full-text search index buckets, and index the display names of the existing objects,
+search properties are indexed when they are declared in the directory config.
+
+* _system/search_properties	key: len(obj_type) obj_type | len(property) property
+* objects_search		key: term | 0x00 | uvarint(type_id) | id	value: object key
+
+terms are the lower cased sequences of letters and digits of the display name,
+type_id is the interned name id of the object type (_system/names).
+*/
+
+const (
+	Version string = "0.0.19"
+)
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.PropertyIndexesPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectsCreatedAtPath),
+	common.CreateBucket(bdb.RelationsCreatedAtPath),
+	common.CreateBucket(bdb.ObjectPropertiesPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+	common.CreateBucket(bdb.StatsPath),
+
+	common.CreateBucket(bdb.SearchPropertiesPath),
+	common.DeleteBucket(bdb.ObjectsSearchPath),
+	common.CreateBucket(bdb.ObjectsSearchPath),
+	indexDisplayNames,
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// indexDisplayNames, read objects from read-only backup, write the search index entries of their display names.
+func indexDisplayNames(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("indexDisplayNames")
+
+	if roDB == nil {
+		log.Info().Bool("roDB", roDB == nil).Msg("indexDisplayNames")
+		return nil
+	}
+
+	return roDB.View(func(rtx *bolt.Tx) error {
+		wtx, err := rwDB.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = wtx.Rollback() }()
+
+		objects, err := common.SetBucket(rtx, bdb.ObjectsPath)
+		if err != nil {
+			return err
+		}
+
+		names, err := common.SetBucket(rtx, bdb.NamesPath)
+		if err != nil {
+			return err
+		}
+
+		c := objects.Cursor()
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			obj := &dsc3.Object{}
+			if err := proto.Unmarshal(value, obj); err != nil {
+				return err
+			}
+
+			typeID, _ := binary.Uvarint(names.Get([]byte(obj.GetType())))
+
+			for _, term := range terms(obj.GetDisplayName()) {
+				buf := bytes.NewBufferString(term)
+				buf.WriteByte(0x00)
+				buf.Write(binary.AppendUvarint(nil, typeID))
+				buf.WriteString(obj.GetId())
+
+				if err := common.SetKey(wtx, bdb.ObjectsSearchPath, buf.Bytes(), bytes.Clone(key)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return wtx.Commit()
+	})
+}
+
+// terms, returns the lower cased sequences of letters and digits of the text.
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
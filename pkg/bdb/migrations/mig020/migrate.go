@@ -0,0 +1,106 @@
+package mig020
+
+import (
+	"bytes"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+/*
+mig020
+
+add the expiration time index bucket of the time-bounded relations, and index the existing expiration times.
+
+* relations_expires_at	key: big-endian unix nano expiration timestamp | relation obj key	value: relation obj key
+*/
+
+const (
+	Version string = "0.0.20"
+)
+
+const timestampSize int = 8
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.PropertyIndexesPath),
+	common.CreateBucket(bdb.SearchPropertiesPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectsCreatedAtPath),
+	common.CreateBucket(bdb.RelationsCreatedAtPath),
+	common.CreateBucket(bdb.ObjectPropertiesPath),
+	common.CreateBucket(bdb.ObjectsSearchPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+	common.CreateBucket(bdb.StatsPath),
+
+	common.DeleteBucket(bdb.RelationsExpiresAtPath),
+	common.CreateBucket(bdb.RelationsExpiresAtPath),
+	indexExpirationTimes,
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// indexExpirationTimes, read the expiration times from read-only backup, write their expiration time index entries.
+func indexExpirationTimes(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("indexExpirationTimes")
+
+	if roDB == nil {
+		log.Info().Bool("roDB", roDB == nil).Msg("indexExpirationTimes")
+		return nil
+	}
+
+	return roDB.View(func(rtx *bolt.Tx) error {
+		wtx, err := rwDB.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = wtx.Rollback() }()
+
+		expiry, err := common.SetBucket(rtx, bdb.RelationsExpiryPath)
+		if err != nil {
+			return err
+		}
+
+		c := expiry.Cursor()
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			if len(value) != timestampSize {
+				continue
+			}
+
+			buf := bytes.NewBuffer(bytes.Clone(value))
+			buf.Write(key)
+
+			if err := common.SetKey(wtx, bdb.RelationsExpiresAtPath, buf.Bytes(), bytes.Clone(key)); err != nil {
+				return err
+			}
+		}
+
+		return wtx.Commit()
+	})
+}
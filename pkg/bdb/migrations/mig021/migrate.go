@@ -0,0 +1,151 @@
+package mig021
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/rs/zerolog"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/*
+mig021
+
+add the deletion time index buckets of the tombstones, and index the existing tombstones by their deletion timestamp
+(the updated_at timestamp of the tombstone instance).
+
+* objects_deleted_at		key: big-endian unix nano deletion timestamp | object key	value: object key
+* relations_deleted_at		key: big-endian unix nano deletion timestamp | relation obj key	value: relation obj key
+*/
+
+const (
+	Version string = "0.0.21"
+)
+
+const timestampSize int = 8
+
+var fnMap = []func(*zerolog.Logger, *bolt.DB, *bolt.DB) error{
+	common.CreateBucket(bdb.SystemPath),
+	common.CreateBucket(bdb.NamesPath),
+	common.CreateBucket(bdb.PropertyIndexesPath),
+	common.CreateBucket(bdb.SearchPropertiesPath),
+	common.CreateBucket(bdb.ManifestPathV2),
+	common.CreateBucket(bdb.ChangeLogPath),
+
+	common.CreateBucket(bdb.ObjectsPath),
+	common.CreateBucket(bdb.RelationsObjPath),
+	common.CreateBucket(bdb.RelationsSubPath),
+	common.CreateBucket(bdb.RelationsRelPath),
+	common.CreateBucket(bdb.ObjectsUpdatedAtPath),
+	common.CreateBucket(bdb.RelationsUpdatedAtPath),
+	common.CreateBucket(bdb.ObjectsCreatedAtPath),
+	common.CreateBucket(bdb.RelationsCreatedAtPath),
+	common.CreateBucket(bdb.ObjectPropertiesPath),
+	common.CreateBucket(bdb.ObjectsSearchPath),
+	common.CreateBucket(bdb.ObjectTombstonesPath),
+	common.CreateBucket(bdb.RelationTombstonesPath),
+	common.CreateBucket(bdb.RelationsExpiryPath),
+	common.CreateBucket(bdb.RelationsExpiresAtPath),
+	common.CreateBucket(bdb.StatsPath),
+
+	common.DeleteBucket(bdb.ObjectsDeletedAtPath),
+	common.CreateBucket(bdb.ObjectsDeletedAtPath),
+	common.DeleteBucket(bdb.RelationsDeletedAtPath),
+	common.CreateBucket(bdb.RelationsDeletedAtPath),
+	indexDeletionTimes,
+}
+
+func Migrate(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("StartMigration")
+
+	for _, fn := range fnMap {
+		if err := fn(log, roDB, rwDB); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("version", Version).Msg("FinishedMigration")
+
+	return nil
+}
+
+// indexDeletionTimes, read the tombstones from read-only backup, write their deletion time index entries.
+func indexDeletionTimes(log *zerolog.Logger, roDB, rwDB *bolt.DB) error {
+	log.Info().Str("version", Version).Msg("indexDeletionTimes")
+
+	if roDB == nil {
+		log.Info().Bool("roDB", roDB == nil).Msg("indexDeletionTimes")
+		return nil
+	}
+
+	return roDB.View(func(rtx *bolt.Tx) error {
+		wtx, err := rwDB.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = wtx.Rollback() }()
+
+		if err := indexTombstones(rtx, wtx, bdb.ObjectTombstonesPath, bdb.ObjectsDeletedAtPath, objectDeletedAt); err != nil {
+			return err
+		}
+
+		if err := indexTombstones(rtx, wtx, bdb.RelationTombstonesPath, bdb.RelationsDeletedAtPath, relationDeletedAt); err != nil {
+			return err
+		}
+
+		return wtx.Commit()
+	})
+}
+
+// indexTombstones, writes the deletion time index entry at idxPath of each tombstone at path.
+func indexTombstones(
+	rtx *bolt.Tx,
+	wtx *bolt.Tx,
+	path, idxPath bdb.Path,
+	deletedAt func([]byte) (*timestamppb.Timestamp, error),
+) error {
+	tombstones, err := common.SetBucket(rtx, path)
+	if err != nil {
+		return err
+	}
+
+	c := tombstones.Cursor()
+	for key, value := c.First(); key != nil; key, value = c.Next() {
+		ts, err := deletedAt(value)
+		if err != nil {
+			return err
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, timestampSize+len(key)))
+		_ = binary.Write(buf, binary.BigEndian, uint64(ts.AsTime().UnixNano())) //nolint:gosec // G115: timestamps are always after the epoch.
+		buf.Write(key)
+
+		if err := common.SetKey(wtx, idxPath, buf.Bytes(), bytes.Clone(key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// objectDeletedAt, returns the deletion timestamp of the object tombstone.
+func objectDeletedAt(value []byte) (*timestamppb.Timestamp, error) {
+	var obj dsc3.Object
+	err := proto.Unmarshal(value, &obj)
+
+	return obj.GetUpdatedAt(), err
+}
+
+// relationDeletedAt, returns the deletion timestamp of the relation tombstone.
+func relationDeletedAt(value []byte) (*timestamppb.Timestamp, error) {
+	var rel dsc3.Relation
+	err := proto.Unmarshal(value, &rel)
+
+	return rel.GetUpdatedAt(), err
+}
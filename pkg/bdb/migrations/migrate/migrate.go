@@ -13,6 +13,18 @@ import (
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig007"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig008"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig009"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig010"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig011"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig012"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig013"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig014"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig015"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig016"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig017"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig018"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig019"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig020"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/mig021"
 	"github.com/aserto-dev/go-edge-ds/pkg/fs"
 
 	"github.com/Masterminds/semver/v3"
@@ -31,6 +43,18 @@ var migMap = map[string]Migration{
 	mig007.Version: mig007.Migrate,
 	mig008.Version: mig008.Migrate,
 	mig009.Version: mig009.Migrate,
+	mig010.Version: mig010.Migrate,
+	mig011.Version: mig011.Migrate,
+	mig012.Version: mig012.Migrate,
+	mig013.Version: mig013.Migrate,
+	mig014.Version: mig014.Migrate,
+	mig015.Version: mig015.Migrate,
+	mig016.Version: mig016.Migrate,
+	mig017.Version: mig017.Migrate,
+	mig018.Version: mig018.Migrate,
+	mig019.Version: mig019.Migrate,
+	mig020.Version: mig020.Migrate,
+	mig021.Version: mig021.Migrate,
 }
 
 //nolint:lll // single line readability more important.
@@ -54,17 +78,13 @@ func CheckSchemaVersion(config *bdb.Config, logger *zerolog.Logger, reqVersion *
 		return true, nil
 	}
 
-	boltdb, err := bdb.New(config, logger)
+	db, err := common.OpenDB(config)
 	if err != nil {
 		return false, err
 	}
+	defer db.Close()
 
-	if err := boltdb.Open(); err != nil {
-		return false, err
-	}
-	defer boltdb.Close()
-
-	curVersion, err := common.GetVersion(boltdb.DB())
+	curVersion, err := common.GetVersion(db)
 	if err != nil {
 		return false, err
 	}
@@ -132,19 +152,15 @@ func Migrate(config *bdb.Config, logger *zerolog.Logger, reqVersion *semver.Vers
 	return nil
 }
 
-func getCurrent(config *bdb.Config, logger *zerolog.Logger) (*semver.Version, error) {
-	boltdb, err := bdb.New(config, logger)
+func getCurrent(config *bdb.Config, _ *zerolog.Logger) (*semver.Version, error) {
+	db, err := common.OpenDB(config)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := boltdb.Open(); err != nil {
-		return nil, err
-	}
-
-	defer boltdb.Close()
+	defer db.Close()
 
-	return common.GetVersion(boltdb.DB())
+	return common.GetVersion(db)
 }
 
 func create(config *bdb.Config, log *zerolog.Logger, version *semver.Version) error {
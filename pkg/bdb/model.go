@@ -4,27 +4,26 @@ import (
 	"context"
 
 	"github.com/aserto-dev/azm/model"
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 // LoadModel, reads the serialized model from the store
 // and swaps the model instance in the cache.Cache using
-// cache.UpdateModel.
+// cache.UpdateModel, an empty model is swapped in when the store contains no model.
 func (s *BoltDB) LoadModel() error {
 	ctx := context.Background()
 
-	err := s.db.View(func(tx *bolt.Tx) error {
+	err := s.db.View(func(tx Tx) error {
 		if ok, _ := BucketExists(tx, ManifestPath); !ok {
-			return nil
+			return s.mc.UpdateModel(&model.Model{})
 		}
 
 		mod, err := GetAny[model.Model](ctx, tx, ManifestPath, ModelKey)
 
 		switch {
 		case status.Code(err) == codes.NotFound:
-			return nil
+			return s.mc.UpdateModel(&model.Model{})
 		case err != nil:
 			return err
 		}
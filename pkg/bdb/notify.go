@@ -0,0 +1,36 @@
+package bdb
+
+import (
+	"sync"
+)
+
+// notifier broadcasts committed write transactions to waiting watchers.
+type notifier struct {
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{changed: make(chan struct{})}
+}
+
+// wait, returns a channel which is closed on the next broadcast.
+func (n *notifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.changed
+}
+
+func (n *notifier) broadcast() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// Changed, returns a channel which is closed when the next write transaction has been committed.
+func (s *BoltDB) Changed() <-chan struct{} {
+	return s.notify.wait()
+}
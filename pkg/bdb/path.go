@@ -8,17 +8,68 @@ const (
 )
 
 var (
-	SystemPath        Path = []string{"_system"}
-	ManifestPath      Path = ManifestPathV2                                         // current path
-	ManifestPathV1    Path = []string{"_manifest", manifestName, manifestVersionV1} // migration path V1, OBSOLETE per migration 0.0.8
-	ManifestPathV2    Path = []string{"_manifest", manifestName}                    // migration path V2
-	ObjectTypesPath   Path = []string{"object_types"}                               // OBSOLETE
-	PermissionsPath   Path = []string{"permissions"}                                // OBSOLETE
-	RelationTypesPath Path = []string{"relation_types"}                             // OBSOLETE
-	ObjectsPath       Path = []string{"objects"}
-	RelationsSubPath  Path = []string{"relations_sub"}
-	RelationsObjPath  Path = []string{"relations_obj"}
-	MetadataKey            = []byte("metadata")
-	BodyKey                = []byte("body")
-	ModelKey               = []byte("model")
+	SystemPath             Path = []string{"_system"}
+	NamesPath              Path = []string{"_system", "names"}                           // interned object type and relation names
+	PropertyIndexesPath    Path = []string{"_system", "property_indexes"}                // declared object property indexes
+	SearchPropertiesPath   Path = []string{"_system", "search_properties"}               // declared object search properties
+	ChangeLogPath          Path = []string{"_changelog"}                                 // store mutations keyed by revision
+	ManifestPath           Path = ManifestPathV2                                         // current path
+	ManifestPathV1         Path = []string{"_manifest", manifestName, manifestVersionV1} // migration path V1, OBSOLETE per migration 0.0.8
+	ManifestPathV2         Path = []string{"_manifest", manifestName}                    // migration path V2
+	ObjectTypesPath        Path = []string{"object_types"}                               // OBSOLETE
+	PermissionsPath        Path = []string{"permissions"}                                // OBSOLETE
+	RelationTypesPath      Path = []string{"relation_types"}                             // OBSOLETE
+	ObjectsPath            Path = []string{"objects"}
+	RelationsSubPath       Path = []string{"relations_sub"}
+	RelationsObjPath       Path = []string{"relations_obj"}
+	RelationsRelPath       Path = []string{"relations_rel"}        // index relations by object type and relation name
+	ObjectsUpdatedAtPath   Path = []string{"objects_updated_at"}   // index objects by updated_at timestamp
+	RelationsUpdatedAtPath Path = []string{"relations_updated_at"} // index relations by updated_at timestamp
+	ObjectsCreatedAtPath   Path = []string{"objects_created_at"}   // index objects by created_at timestamp
+	RelationsCreatedAtPath Path = []string{"relations_created_at"} // index relations by created_at timestamp
+	ObjectPropertiesPath   Path = []string{"objects_properties"}   // index objects by declared property values
+	ObjectsSearchPath      Path = []string{"objects_search"}       // index objects by display name and search property terms
+	ObjectTombstonesPath   Path = []string{"objects_tombstones"}   // deleted objects, keyed by object key
+	RelationTombstonesPath Path = []string{"relations_tombstones"} // deleted relations, keyed by relation obj key
+	ObjectsDeletedAtPath   Path = []string{"objects_deleted_at"}   // index deleted objects by deletion timestamp
+	RelationsDeletedAtPath Path = []string{"relations_deleted_at"} // index deleted relations by deletion timestamp
+	RelationsExpiryPath    Path = []string{"relations_expiry"}     // expiration time of time-bounded relations, keyed by relation obj key
+	RelationsExpiresAtPath Path = []string{"relations_expires_at"} // index time-bounded relations by expiration time
+	StatsPath              Path = []string{"stats"}                // object and relation counters, maintained by the write path
+	MetadataKey                 = []byte("metadata")
+	BodyKey                     = []byte("body")
+	ModelKey                    = []byte("model")
 )
+
+// DataPaths, buckets containing directory data (objects, relations and their indexes),
+// which are cleared when the manifest is deleted. The tombstone buckets are retained, so the deletes
+// are propagated to incremental (watermark) sync clients.
+var DataPaths = []Path{
+	ObjectsPath,
+	RelationsObjPath,
+	RelationsSubPath,
+	RelationsRelPath,
+	ObjectsUpdatedAtPath,
+	RelationsUpdatedAtPath,
+	ObjectsCreatedAtPath,
+	RelationsCreatedAtPath,
+	ObjectPropertiesPath,
+	ObjectsSearchPath,
+	RelationsExpiryPath,
+	RelationsExpiresAtPath,
+	StatsPath,
+}
+
+// StorePaths, all buckets of the current store schema, used to initialize stores which are not created by migrations.
+var StorePaths = append([]Path{
+	SystemPath,
+	NamesPath,
+	PropertyIndexesPath,
+	SearchPropertiesPath,
+	ManifestPath,
+	ChangeLogPath,
+	ObjectTombstonesPath,
+	RelationTombstonesPath,
+	ObjectsDeletedAtPath,
+	RelationsDeletedAtPath,
+}, DataPaths...)
@@ -9,7 +9,6 @@ import (
 	"github.com/aserto-dev/go-edge-ds/pkg/x"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	bolt "go.etcd.io/bbolt"
 )
 
 type Iterator[T any, M Message[T]] interface {
@@ -23,8 +22,9 @@ type Iterator[T any, M Message[T]] interface {
 
 type ScanIterator[T any, M Message[T]] struct {
 	ctx   context.Context
-	tx    *bolt.Tx
-	c     *bolt.Cursor
+	tx    Tx
+	path  Path
+	c     Cursor
 	args  *ScanArgs
 	init  bool
 	key   []byte
@@ -34,9 +34,16 @@ type ScanIterator[T any, M Message[T]] struct {
 type ScanOption func(*ScanArgs)
 
 type ScanArgs struct {
-	startToken []byte
-	keyFilter  []byte
-	pageSize   int32
+	startToken  []byte
+	keyFilter   []byte
+	pageSize    int32
+	pageToken   *PageToken
+	tokenErr    error
+	fingerprint uint64
+	translate   KeyTranslator
+	lowerKey    []byte
+	upperKey    []byte
+	reverse     bool
 }
 
 func WithPageSize(size int32) ScanOption {
@@ -45,9 +52,49 @@ func WithPageSize(size int32) ScanOption {
 	}
 }
 
+// WithPageToken, starts the scan at the key identified by the page token, as returned by Token.
 func WithPageToken(token string) ScanOption {
 	return func(a *ScanArgs) {
-		a.startToken = []byte(token)
+		a.pageToken, a.tokenErr = DecodePageToken(token)
+	}
+}
+
+// WithFingerprint, sets the fingerprint of the query filter fields, which is recorded in the issued page tokens,
+// page tokens issued for a different query filter are rejected.
+func WithFingerprint(fields ...string) ScanOption {
+	return func(a *ScanArgs) {
+		a.fingerprint = Fingerprint(fields...)
+	}
+}
+
+// WithKeyTranslator, translates the keys of page tokens, which were issued using an older key encoding.
+func WithKeyTranslator(fn KeyTranslator) ScanOption {
+	return func(a *ScanArgs) {
+		a.translate = fn
+	}
+}
+
+// WithKeyRange, limits the scan to the keys equal or following the lower key and preceding the upper key,
+// a nil bound leaves the range unbounded at that end.
+func WithKeyRange(lower, upper []byte) ScanOption {
+	return func(a *ScanArgs) {
+		a.lowerKey = lower
+		a.upperKey = upper
+	}
+}
+
+// WithReverse, scans the keys in descending order, starting at the key identified by the page token, or at the last key
+// of the key range. Reverse scans do not support key filters.
+func WithReverse() ScanOption {
+	return func(a *ScanArgs) {
+		a.reverse = true
+	}
+}
+
+// WithStartKey, starts the scan at the first key equal or following the start key.
+func WithStartKey(key []byte) ScanOption {
+	return func(a *ScanArgs) {
+		a.startToken = key
 	}
 }
 
@@ -57,25 +104,46 @@ func WithKeyFilter(filter []byte) ScanOption {
 	}
 }
 
-func NewScanIterator[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, opts ...ScanOption) (*ScanIterator[T, M], error) {
+func NewScanIterator[T any, M Message[T]](ctx context.Context, tx Tx, path Path, opts ...ScanOption) (*ScanIterator[T, M], error) {
 	args := &ScanArgs{startToken: nil, keyFilter: nil, pageSize: x.MaxPageSize}
 	for _, opt := range opts {
 		opt(args)
 	}
 
-	if len(args.startToken) == 0 && len(args.keyFilter) != 0 {
+	if args.tokenErr != nil {
+		return nil, args.tokenErr
+	}
+
+	if args.pageToken != nil {
+		key, err := args.pageToken.startKey(tx, path, args.fingerprint, args.translate)
+		if err != nil {
+			return nil, err
+		}
+
+		args.startToken = key
+	}
+
+	if len(args.startToken) == 0 && !args.reverse {
 		args.startToken = args.keyFilter
 	}
 
+	if !args.reverse && bytes.Compare(args.startToken, args.lowerKey) < 0 {
+		args.startToken = args.lowerKey
+	}
+
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return nil, errors.Wrapf(ErrPathNotFound, "path [%s]", path)
 	}
 
-	return &ScanIterator[T, M]{ctx: ctx, tx: tx, c: b.Cursor(), args: args, init: false}, nil
+	return &ScanIterator[T, M]{ctx: ctx, tx: tx, path: path, c: b.Cursor(), args: args, init: false}, nil
 }
 
 func (s *ScanIterator[T, M]) Next() bool {
+	if s.args.reverse {
+		return s.prev()
+	}
+
 	if s.init {
 		s.key, s.value = s.c.Next()
 	}
@@ -90,9 +158,50 @@ func (s *ScanIterator[T, M]) Next() bool {
 		s.init = true
 	}
 
+	if s.args.upperKey != nil && bytes.Compare(s.key, s.args.upperKey) >= 0 {
+		return false
+	}
+
 	return s.key != nil && bytes.HasPrefix(s.key, s.args.keyFilter)
 }
 
+// prev, moves the cursor of a reverse scan to the preceding key.
+func (s *ScanIterator[T, M]) prev() bool {
+	if s.init {
+		s.key, s.value = s.c.Prev()
+	}
+
+	if !s.init {
+		s.key, s.value = s.seekLast()
+		s.init = true
+	}
+
+	return s.key != nil && bytes.Compare(s.key, s.args.lowerKey) >= 0
+}
+
+// seekLast, positions the cursor of a reverse scan at the start token, or at the last key preceding the upper key.
+func (s *ScanIterator[T, M]) seekLast() (key, value []byte) {
+	seek, inclusive := s.args.startToken, true
+	if seek == nil {
+		seek, inclusive = s.args.upperKey, false
+	}
+
+	if seek == nil {
+		return s.c.Last()
+	}
+
+	key, value = s.c.Seek(seek)
+
+	switch {
+	case key == nil:
+		return s.c.Last()
+	case inclusive && bytes.Equal(key, seek):
+		return key, value
+	default:
+		return s.c.Prev()
+	}
+}
+
 func (s *ScanIterator[T, M]) RawKey() []byte {
 	return s.key
 }
@@ -105,6 +214,12 @@ func (s *ScanIterator[T, M]) Key() string {
 	return string(s.key)
 }
 
+// Token, returns the page token identifying the current key, for the path and query filter of the scan.
+func (s *ScanIterator[T, M]) Token() string {
+	t := PageToken{KeyVersion: KeyVersion, Path: s.path, Fingerprint: s.args.fingerprint, Key: s.key}
+	return t.Encode()
+}
+
 func (s *ScanIterator[T, M]) Value() M {
 	msg, err := unmarshal[T, M](s.value)
 	if err != nil {
@@ -132,11 +247,11 @@ type PagedIterator[T any, M Message[T]] interface {
 
 type PageIterator[T any, M Message[T]] struct {
 	iter      *ScanIterator[T, M]
-	nextToken []byte
+	nextToken string
 	values    []M
 }
 
-func NewPageIterator[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, opts ...ScanOption) (PagedIterator[T, M], error) {
+func NewPageIterator[T any, M Message[T]](ctx context.Context, tx Tx, path Path, opts ...ScanOption) (PagedIterator[T, M], error) {
 	iter, err := NewScanIterator[T, M](ctx, tx, path, opts...)
 	if err != nil {
 		return nil, err
@@ -156,10 +271,10 @@ func (p *PageIterator[T, M]) Next() bool {
 	}
 
 	p.values = results
-	p.nextToken = []byte{}
+	p.nextToken = ""
 
 	if p.iter.Next() {
-		p.nextToken = p.iter.RawKey()
+		p.nextToken = p.iter.Token()
 	}
 
 	return false
@@ -170,10 +285,10 @@ func (p *PageIterator[T, M]) Value() []M {
 }
 
 func (p *PageIterator[T, M]) NextToken() string {
-	return string(p.nextToken)
+	return p.nextToken
 }
 
-func Scan[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, keyFilter []byte) ([]M, error) {
+func Scan[T any, M Message[T]](ctx context.Context, tx Tx, path Path, keyFilter []byte) ([]M, error) {
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return nil, errors.Wrapf(ErrPathNotFound, "path [%s]", path)
@@ -195,11 +310,14 @@ func Scan[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, keyF
 	return results, nil
 }
 
+// ScanWithFilter, scans the keys matching the key filter prefix, the optional key match function rejects keys
+// based on the encoded key, before the value is unmarshalled and evaluated by the value filter.
 func ScanWithFilter(
 	ctx context.Context,
-	tx *bolt.Tx,
+	tx Tx,
 	path Path,
 	keyFilter []byte,
+	keyMatch func([]byte) bool,
 	valueFilter func(*dsc3.RelationIdentifier) bool,
 	pool graph.RelationPool,
 	out *[]*dsc3.RelationIdentifier,
@@ -211,6 +329,10 @@ func ScanWithFilter(
 
 	c := b.Cursor()
 
+	if keyMatch == nil {
+		keyMatch = func(_ []byte) bool { return true }
+	}
+
 	if valueFilter == nil {
 		valueFilter = func(_ *dsc3.RelationIdentifier) bool { return true }
 	}
@@ -218,6 +340,10 @@ func ScanWithFilter(
 	results := *out
 
 	for k, v := c.Seek(keyFilter); k != nil && bytes.HasPrefix(k, keyFilter); k, v = c.Next() {
+		if !keyMatch(k) {
+			continue
+		}
+
 		m := pool.Get()
 		if err := unmarshalTo(v, m); err != nil {
 			return err
@@ -233,7 +359,7 @@ func ScanWithFilter(
 	return nil
 }
 
-func KeyPrefixExists[T any, M Message[T]](ctx context.Context, tx *bolt.Tx, path Path, keyFilter []byte) (bool, error) {
+func KeyPrefixExists[T any, M Message[T]](ctx context.Context, tx Tx, path Path, keyFilter []byte) (bool, error) {
 	b, err := SetBucket(tx, path)
 	if err != nil {
 		return false, errors.Wrapf(ErrPathNotFound, "path [%s]", path)
@@ -0,0 +1,57 @@
+package bdb
+
+import (
+	"io"
+	"net/http"
+
+	cerr "github.com/aserto-dev/errors"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrSnapshotNotSupported = cerr.NewAsertoError("E20073", codes.FailedPrecondition, http.StatusPreconditionFailed,
+		"snapshots are not supported by the store backend")
+	ErrInvalidSnapshot = cerr.NewAsertoError("E20074", codes.InvalidArgument, http.StatusBadRequest, "invalid snapshot")
+)
+
+// snapshotter, backend which can write snapshots of its database file, and be replaced by a database file.
+type snapshotter interface {
+	WriteTo(w io.Writer) (int64, error)
+	Replace(path string, prepare PrepareFunc, swapped func()) error
+}
+
+// PrepareFunc, prepares the database file replacing the store, called with a read transaction of the current store
+// and a write transaction of the replacement database file, while writes to the current store are blocked.
+type PrepareFunc func(cur, next Tx) error
+
+// Snapshot, writes a consistent snapshot of the store, in the bbolt file format, to w,
+// returns ErrSnapshotNotSupported when the store backend does not support snapshots.
+func (s *BoltDB) Snapshot(w io.Writer) (int64, error) {
+	db, ok := s.db.(snapshotter)
+	if !ok {
+		return 0, ErrSnapshotNotSupported.Msg(s.config.Backend)
+	}
+
+	return db.WriteTo(w)
+}
+
+// Replace, atomically replaces the store by the database file at path, which must be at the current schema version,
+// and reloads the model cache from the replaced store,
+// returns ErrSnapshotNotSupported when the store backend does not support snapshots.
+//
+// The prepare function (optional) is applied to the replacement database file before it replaces the store,
+// the swapped function (optional) is called after the replacement, before any transaction observes the replaced store.
+func (s *BoltDB) Replace(path string, prepare PrepareFunc, swapped func()) error {
+	db, ok := s.db.(snapshotter)
+	if !ok {
+		return ErrSnapshotNotSupported.Msg(s.config.Backend)
+	}
+
+	s.logger.Info().Str("db_path", s.config.DBPath).Msg("replace")
+
+	if err := db.Replace(path, prepare, swapped); err != nil {
+		return err
+	}
+
+	return s.LoadModel()
+}
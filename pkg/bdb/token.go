@@ -0,0 +1,190 @@
+package bdb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/fnv"
+	"net/http"
+	"slices"
+
+	cerr "github.com/aserto-dev/errors"
+	"google.golang.org/grpc/codes"
+)
+
+// Page tokens are opaque to the client, a page token identifies the key at which the next page starts,
+// the bucket (path) the key belongs to, the version of the key encoding and the fingerprint of the query
+// filter which issued the token.
+//
+// token layout (base64 url encoded, without padding):
+// 0x00 | token_version | key_version | path_len (path_segment_len path_segment)* | fingerprint | key
+//
+// versions, lengths and the fingerprint are uvarint encoded. No key starts with a 0 byte, which distinguishes
+// page tokens from the legacy tokens, which are the (base64 encoded) raw key at which the next page starts.
+
+var ErrInvalidPageToken = cerr.NewAsertoError("E20065", codes.InvalidArgument, http.StatusBadRequest, "invalid page token")
+
+const (
+	pageTokenMarker  byte   = 0x00
+	pageTokenVersion uint64 = 1
+)
+
+// Key encoding versions, KeyVersion is bumped when a migration changes the key encoding of the data buckets,
+// page tokens issued using an older key encoding are translated to the current encoding by a KeyTranslator.
+const (
+	KeyVersionSeparated uint64 = 1 // separator based keys, schema versions before 0.0.15.
+	KeyVersionInterned  uint64 = 2 // compact, length-prefixed keys using interned names, schema version 0.0.15.
+	KeyVersion                 = KeyVersionInterned
+)
+
+// KeyTranslator, translates a key of the path, encoded using the key version, to the current key encoding.
+type KeyTranslator func(tx Tx, path Path, version uint64, key []byte) ([]byte, error)
+
+// PageToken, decoded page token.
+type PageToken struct {
+	KeyVersion  uint64
+	Path        Path
+	Fingerprint uint64
+	Key         []byte
+}
+
+// Encode, returns the opaque string representation of the page token.
+func (t *PageToken) Encode() string {
+	buf := bytes.NewBuffer(make([]byte, 0, len(t.Key)+64)) //nolint:mnd // header size estimate.
+
+	buf.WriteByte(pageTokenMarker)
+	buf.Write(binary.AppendUvarint(nil, pageTokenVersion))
+	buf.Write(binary.AppendUvarint(nil, t.KeyVersion))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(t.Path))))
+
+	for _, segment := range t.Path {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(segment))))
+		buf.WriteString(segment)
+	}
+
+	buf.Write(binary.AppendUvarint(nil, t.Fingerprint))
+	buf.Write(t.Key)
+
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+// DecodePageToken, decodes the page token string.
+//
+// Legacy tokens, which only contain the key, are decoded as tokens without path and fingerprint,
+// base64 encoded tokens contain a key using the interned key encoding, tokens which are not base64 encoded
+// were issued before keys were binary encoded and contain a key using the separator based key encoding.
+func DecodePageToken(token string) (*PageToken, error) {
+	if token == "" {
+		return &PageToken{KeyVersion: KeyVersion}, nil
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return &PageToken{KeyVersion: KeyVersionSeparated, Key: []byte(token)}, nil
+	}
+
+	if len(buf) == 0 || buf[0] != pageTokenMarker {
+		return &PageToken{KeyVersion: KeyVersionInterned, Key: buf}, nil
+	}
+
+	r := tokenReader{buf: buf[1:], ok: true}
+
+	if r.uvarint() != pageTokenVersion {
+		return nil, ErrInvalidPageToken.Msg("unsupported token version")
+	}
+
+	t := &PageToken{KeyVersion: r.uvarint()}
+
+	segments := r.uvarint()
+	if segments > uint64(len(r.buf)) {
+		return nil, ErrInvalidPageToken.Msg("malformed token")
+	}
+
+	for range segments {
+		t.Path = append(t.Path, string(r.bytes()))
+	}
+
+	t.Fingerprint = r.uvarint()
+
+	if !r.ok {
+		return nil, ErrInvalidPageToken.Msg("malformed token")
+	}
+
+	t.Key = r.buf
+
+	return t, nil
+}
+
+// Fingerprint, returns the fingerprint of the query filter fields, page tokens issued by a query
+// can only be used to continue a query with the same path and fingerprint.
+func Fingerprint(fields ...string) uint64 {
+	h := fnv.New64a()
+
+	for _, field := range fields {
+		_, _ = h.Write(binary.AppendUvarint(nil, uint64(len(field))))
+		_, _ = h.Write([]byte(field))
+	}
+
+	return h.Sum64()
+}
+
+// startKey, returns the current encoding of the key at which the scan of the path continues,
+// rejecting tokens issued for another path or query filter.
+func (t *PageToken) startKey(tx Tx, path Path, fingerprint uint64, translate KeyTranslator) ([]byte, error) {
+	if len(t.Key) == 0 {
+		return nil, nil
+	}
+
+	if t.Path != nil && !slices.Equal(t.Path, path) {
+		return nil, ErrInvalidPageToken.Msg("token path mismatch")
+	}
+
+	if t.Path != nil && t.Fingerprint != fingerprint {
+		return nil, ErrInvalidPageToken.Msg("token filter mismatch")
+	}
+
+	if t.KeyVersion == KeyVersion {
+		return t.Key, nil
+	}
+
+	if t.KeyVersion > KeyVersion || translate == nil {
+		return nil, ErrInvalidPageToken.Msgf("unsupported key version %d", t.KeyVersion)
+	}
+
+	return translate(tx, path, t.KeyVersion, t.Key)
+}
+
+// tokenReader, reads the components of an encoded page token, once a read fails all subsequent reads fail.
+type tokenReader struct {
+	buf []byte
+	ok  bool
+}
+
+func (r *tokenReader) uvarint() uint64 {
+	if !r.ok {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.ok = false
+		return 0
+	}
+
+	r.buf = r.buf[n:]
+
+	return v
+}
+
+func (r *tokenReader) bytes() []byte {
+	n := r.uvarint()
+	if !r.ok || n > uint64(len(r.buf)) {
+		r.ok = false
+		return nil
+	}
+
+	v := r.buf[:n]
+	r.buf = r.buf[n:]
+
+	return v
+}
@@ -3,9 +3,12 @@ package datasync
 import (
 	"context"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
 
 	cuckoo "github.com/panmari/cuckoofilter"
 	"github.com/rs/zerolog"
@@ -18,16 +21,20 @@ type SyncClient interface {
 }
 
 type Client struct {
-	logger *zerolog.Logger
-	store  *bdb.BoltDB
+	logger       *zerolog.Logger
+	store        *bdb.BoltDB
+	refIntegrity ds.ReferentialIntegrity
+	mu           sync.Mutex
+	wm           *watermark // watermark of stores which are not file backed.
 }
 
 var _ SyncClient = &Client{}
 
-func New(logger *zerolog.Logger, store *bdb.BoltDB) *Client {
+func New(logger *zerolog.Logger, store *bdb.BoltDB, refIntegrity ds.ReferentialIntegrity) *Client {
 	return &Client{
-		logger: logger,
-		store:  store,
+		logger:       logger,
+		store:        store,
+		refIntegrity: refIntegrity,
 	}
 }
 
@@ -65,6 +72,14 @@ type Sync struct {
 	errChan    chan error
 	tsChan     chan *timestamppb.Timestamp
 	filter     *cuckoo.Filter
+	upstream   *revision    // revision of the upstream export, set by the producer.
+	failures   atomic.Int32 // number of instances which failed to apply.
+}
+
+// revision, revision of the upstream directory, identified by its store id.
+type revision struct {
+	id    string
+	value uint64
 }
 
 func newSync(c *Client, o *Options) *Sync {
@@ -99,6 +114,9 @@ type Option func(*Options)
 
 type Options struct {
 	Mode Mode
+	// Tombstones, requests the tombstones of deleted instances in watermark syncs, using the edge specific
+	// ds.OptionTombstones export option, which is only supported by upstream edge directories.
+	Tombstones bool
 }
 
 type Mode int32
@@ -163,3 +181,11 @@ func WithMode(mode Mode) Option {
 		o.Mode = Set(o.Mode, mode)
 	}
 }
+
+// WithTombstones, requests the tombstones of the instances deleted since the last watermark from the upstream directory,
+// which must be an edge directory, to propagate deletes in watermark syncs.
+func WithTombstones() Option {
+	return func(o *Options) {
+		o.Tombstones = true
+	}
+}
@@ -5,18 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
-	bolt "go.etcd.io/bbolt"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
 
 	cuckoo "github.com/panmari/cuckoofilter"
 	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -73,6 +77,10 @@ func (s *Sync) syncDirectory(ctx context.Context, conn *grpc.ClientConn) error {
 		return err
 	}
 
+	if err := s.setUpstreamRevision(); err != nil {
+		return err
+	}
+
 	runEndTime := time.Now().UTC()
 
 	s.logger.Info().Str(syncStatus, syncFinished).Str("duration", runEndTime.Sub(runStartTime).String()).Msg(syncRun)
@@ -80,6 +88,23 @@ func (s *Sync) syncDirectory(ctx context.Context, conn *grpc.ClientConn) error {
 	return nil
 }
 
+// setUpstreamRevision, records the revision of the upstream export once applied, so revision tokens of the upstream
+// directory can be enforced, the revision is only recorded when the sync applied all instances, and reconciled
+// the deletes (diff or tombstones).
+func (s *Sync) setUpstreamRevision() error {
+	if s.upstream == nil || s.failures.Load() > 0 {
+		return nil
+	}
+
+	if !Has(s.options.Mode, Diff) && !(Has(s.options.Mode, Watermark) && s.options.Tombstones) {
+		return nil
+	}
+
+	return s.store.DB().Update(func(tx bdb.Tx) error {
+		return ds.SetUpstreamRevision(tx, s.upstream.id, s.upstream.value)
+	})
+}
+
 func (s *Sync) producer(ctx context.Context, conn *grpc.ClientConn) error {
 	s.logger.Info().Str(syncStatus, syncStarted).Msg(syncProducer)
 
@@ -95,25 +120,58 @@ func (s *Sync) producer(ctx context.Context, conn *grpc.ClientConn) error {
 
 	ts := &timestamppb.Timestamp{}
 
+	// request the expiration time of time-bounded relations, upstreams not supporting the option ignore it.
+	opts := uint32(dse3.Option_OPTION_DATA) | ds.OptionRelationExpiry
+
 	wm := s.getWatermark()
 	if Has(s.options.Mode, Watermark) {
 		ts = wm.Timestamp
 	}
 
+	// request tombstones to propagate deletes since the last watermark, when the upstream is known to support them.
+	if Has(s.options.Mode, Watermark) && s.options.Tombstones {
+		opts |= ds.OptionTombstones
+	}
+
 	if Has(s.options.Mode, Diff) {
 		s.filter = cuckoo.NewFilter(wm.getFilterSize())
 	}
 
 	s.logger.Debug().Str("start_from", ts.String()).Msg(syncProducer)
 
-	stream, err := dse3.NewExporterClient(conn).Export(ctx, &dse3.ExportRequest{
-		Options:   uint32(dse3.Option_OPTION_DATA),
-		StartFrom: ts,
-	})
+	stream, cancelStream, err := s.export(ctx, conn, opts, ts)
 	if err != nil {
 		return err
 	}
 
+	defer func() { cancelStream() }()
+
+	md, err := stream.Header()
+	if err != nil {
+		return err
+	}
+
+	// the upstream purged tombstones recorded after the watermark, the deletes are reconciled by a full diff sync.
+	if opts&ds.OptionTombstones != 0 && beforeHorizon(md, ts) {
+		s.logger.Warn().Str("start_from", ts.String()).Msg("watermark older than upstream tombstone horizon, forcing diff sync")
+
+		cancelStream()
+
+		s.options.Mode = Set(Clear(s.options.Mode, Watermark), Full|Diff)
+		s.filter = cuckoo.NewFilter(wm.getFilterSize())
+
+		stream, cancelStream, err = s.export(ctx, conn, uint32(dse3.Option_OPTION_DATA)|ds.OptionRelationExpiry, &timestamppb.Timestamp{})
+		if err != nil {
+			return err
+		}
+
+		if md, err = stream.Header(); err != nil {
+			return err
+		}
+	}
+
+	s.upstream = upstreamRevision(md)
+
 	for {
 		msg, err := stream.Recv()
 		if errors.Is(err, io.EOF) {
@@ -130,15 +188,23 @@ func (s *Sync) producer(ctx context.Context, conn *grpc.ClientConn) error {
 		case *dse3.ExportResponse_Object:
 			objCtr.Add(1)
 
-			if Has(s.options.Mode, Diff) {
+			if Has(s.options.Mode, Diff) && !ds.IsTombstone(m.Object.GetEtag()) {
 				s.filter.Insert(getObjectKey(m.Object))
 			}
 		case *dse3.ExportResponse_Relation:
 			relCtr.Add(1)
 
-			if Has(s.options.Mode, Diff) {
+			if Has(s.options.Mode, Diff) && !ds.IsTombstone(m.Relation.GetEtag()) {
 				s.filter.Insert(getRelationKey(m.Relation))
+
+				// stub objects created for the object and subject of the relation are retained by the diff.
+				if s.refIntegrity == ds.ReferentialIntegrityCreate {
+					s.filter.Insert(getObjectKey(&dsc3.Object{Type: m.Relation.GetObjectType(), Id: m.Relation.GetObjectId()}))
+					s.filter.Insert(getObjectKey(&dsc3.Object{Type: m.Relation.GetSubjectType(), Id: m.Relation.GetSubjectId()}))
+				}
 			}
+		case *dse3.ExportResponse_Stats:
+			// stats messages announce the expiration time of the following relation (ds.RelationExpiryStats).
 		default:
 			s.logger.Debug().Msg("producer unknown message type")
 			continue // do not send msg to exportChan when unknown.
@@ -163,10 +229,13 @@ func (s *Sync) subscriber(ctx context.Context) error {
 
 	ts := &timestamppb.Timestamp{}
 
+	// expiry stats message announcing the expiration time of the next relation.
+	var expiry *structpb.Struct
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	batchErr := s.store.DB().Batch(func(tx *bolt.Tx) error {
+	batchErr := s.store.DB().Batch(func(tx bdb.Tx) error {
 		for {
 			msg, ok := <-s.exportChan
 			if !ok {
@@ -178,31 +247,46 @@ func (s *Sync) subscriber(ctx context.Context) error {
 
 			switch m := msg.GetMsg().(type) {
 			case *dse3.ExportResponse_Object:
-				if err := s.objectSetHandler(ctx, tx, m.Object); err == nil {
-					ts = maxTS(ts, m.Object.GetUpdatedAt())
+				// capture source updated_at timestamp, the set handler overwrites it with the local timestamp.
+				updatedAt := m.Object.GetUpdatedAt()
+
+				if err := s.objectHandler(ctx, tx, m.Object); err == nil {
+					ts = maxTS(ts, updatedAt)
 
 					objCtr.Add(1)
 				} else {
 					s.logger.Error().Err(err).Msgf("failed to set object %v", m.Object)
 
 					errCtr.Add(1)
+					s.failures.Add(1)
 
 					s.errChan <- err
 				}
 
 			case *dse3.ExportResponse_Relation:
-				if err := s.relationSetHandler(ctx, tx, m.Relation); err == nil {
-					ts = maxTS(ts, m.Relation.GetUpdatedAt())
+				// capture source updated_at timestamp, the set handler overwrites it with the local timestamp.
+				updatedAt := m.Relation.GetUpdatedAt()
+
+				// a relation not preceded by its expiry stats message is permanent.
+				expiresAt, _ := ds.ExportedRelationExpiry(expiry, m.Relation)
+				expiry = nil
+
+				if err := s.relationHandler(ctx, tx, m.Relation, expiresAt); err == nil {
+					ts = maxTS(ts, updatedAt)
 
 					relCtr.Add(1)
 				} else {
 					s.logger.Error().Err(err).Msgf("failed to set object %v", m.Relation)
 
 					errCtr.Add(1)
+					s.failures.Add(1)
 
 					s.errChan <- err
 				}
 
+			case *dse3.ExportResponse_Stats:
+				expiry = m.Stats
+
 			default:
 				s.logger.Debug().Msg("unknown message type")
 			}
@@ -238,7 +322,7 @@ func (s *Sync) diff(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	batchErr := s.store.DB().Batch(func(tx *bolt.Tx) error {
+	batchErr := s.store.DB().Batch(func(tx bdb.Tx) error {
 		// objects
 		{
 			iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath)
@@ -258,6 +342,7 @@ func (s *Sync) diff(ctx context.Context) error {
 						s.logger.Error().Err(err).Msgf("failed to delete object %v", obj)
 
 						errCtr.Add(1)
+						s.failures.Add(1)
 
 						s.errChan <- err
 					}
@@ -286,6 +371,7 @@ func (s *Sync) diff(ctx context.Context) error {
 						s.errChan <- err
 
 						errCtr.Add(1)
+						s.failures.Add(1)
 					}
 				}
 			}
@@ -306,6 +392,59 @@ func (s *Sync) diff(ctx context.Context) error {
 	return nil
 }
 
+// export, opens the export stream of the upstream directory, the stream is released by the returned cancel function.
+func (s *Sync) export(ctx context.Context, conn *grpc.ClientConn, opts uint32, ts *timestamppb.Timestamp,
+) (dse3.Exporter_ExportClient, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	stream, err := dse3.NewExporterClient(conn).Export(ctx, &dse3.ExportRequest{
+		Options:   opts,
+		StartFrom: ts,
+	})
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return stream, cancel, nil
+}
+
+// beforeHorizon, returns true when the watermark timestamp precedes the tombstone horizon announced by the upstream,
+// a zero watermark results in a full export, which does not depend on tombstones.
+func beforeHorizon(md metadata.MD, ts *timestamppb.Timestamp) bool {
+	if ts.GetSeconds() == 0 && ts.GetNanos() == 0 {
+		return false
+	}
+
+	v := md.Get(x.TombstoneHorizonHeader)
+	if len(v) == 0 {
+		return false
+	}
+
+	horizon, err := time.Parse(time.RFC3339Nano, v[0])
+	if err != nil {
+		return false
+	}
+
+	return ts.AsTime().Before(horizon)
+}
+
+// upstreamRevision, returns the revision of the export and the id of the upstream directory announced by the upstream,
+// a nil value is returned when the upstream does not announce its revision.
+func upstreamRevision(md metadata.MD) *revision {
+	id, rev := md.Get(x.RevisionOriginHeader), md.Get(x.RevisionHeader)
+	if len(id) == 0 || id[0] == "" || len(rev) == 0 {
+		return nil
+	}
+
+	value, err := strconv.ParseUint(rev[0], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &revision{id: id[0], value: value}
+}
+
 func getObjectKey(obj *dsc3.Object) []byte {
 	return fmt.Appendf([]byte{}, "%s:%s", obj.GetType(), obj.GetId())
 }
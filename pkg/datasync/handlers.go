@@ -2,17 +2,38 @@ package datasync
 
 import (
 	"context"
+	"time"
 
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	"github.com/aserto-dev/go-directory/pkg/derr"
 	"github.com/aserto-dev/go-directory/pkg/validator"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/ds"
-
-	bolt "go.etcd.io/bbolt"
 )
 
-func (s *Sync) objectSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Object) error {
+// objectHandler, applies an exported object instance, tombstones are applied as deletes,
+// the tombstone etag is cleared as it is not a valid etag.
+func (s *Sync) objectHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Object) error {
+	if ds.IsTombstone(req.GetEtag()) {
+		req.Etag = ""
+		return s.objectDeleteHandler(ctx, tx, req)
+	}
+
+	return s.objectSetHandler(ctx, tx, req)
+}
+
+// relationHandler, applies an exported relation instance with the expiration time announced by the upstream
+// (zero when permanent), tombstones are applied as deletes, the tombstone etag is cleared as it is not a valid etag.
+func (s *Sync) relationHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Relation, expiresAt time.Time) error {
+	if ds.IsTombstone(req.GetEtag()) {
+		req.Etag = ""
+		return s.relationDeleteHandler(ctx, tx, req)
+	}
+
+	return s.relationSetHandler(ctx, tx, req, expiresAt)
+}
+
+func (s *Sync) objectSetHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Object) error {
 	s.logger.Debug().Interface("object", req).Msg("ImportObject")
 
 	if req == nil {
@@ -31,26 +52,26 @@ func (s *Sync) objectSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Obje
 
 	etag := obj.Hash()
 
-	updReq, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, obj.Key(), req)
+	updReq, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, obj.Key(tx), req)
 	if err != nil {
 		return err
 	}
 
 	if etag == updReq.GetEtag() {
-		s.logger.Trace().Bytes("key", obj.Key()).Str("etag-equal", etag).Msg("ImportObject")
+		s.logger.Trace().Bytes("key", obj.Key(tx)).Str("etag-equal", etag).Msg("ImportObject")
 		return nil
 	}
 
 	updReq.Etag = etag
 
-	if _, err := bdb.Set[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.Object(updReq).Key(), updReq); err != nil {
+	if _, err := ds.SetObject(ctx, tx, updReq); err != nil {
 		return derr.ErrInvalidObject.Msg("set")
 	}
 
 	return nil
 }
 
-func (s *Sync) objectDeleteHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Object) error {
+func (s *Sync) objectDeleteHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Object) error {
 	s.logger.Debug().Interface("object", req).Msg("ImportObject")
 
 	if req == nil {
@@ -66,14 +87,14 @@ func (s *Sync) objectDeleteHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.O
 		return err
 	}
 
-	if err := bdb.Delete(ctx, tx, bdb.ObjectsPath, obj.Key()); err != nil {
+	if err := ds.DeleteObject(ctx, tx, &dsc3.ObjectIdentifier{ObjectType: req.GetType(), ObjectId: req.GetId()}); err != nil {
 		return derr.ErrInvalidObject.Msg("delete")
 	}
 
 	return nil
 }
 
-func (s *Sync) relationSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Relation) error {
+func (s *Sync) relationSetHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Relation, expiresAt time.Time) error {
 	s.logger.Debug().Interface("relation", req).Msg("ImportRelation")
 
 	if req == nil {
@@ -89,32 +110,37 @@ func (s *Sync) relationSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Re
 		return err
 	}
 
+	if err := ds.EnsureRelationObjects(ctx, tx, req, s.refIntegrity); err != nil {
+		return err
+	}
+
 	etag := rel.Hash()
 
-	updReq, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, rel.ObjKey(), req)
+	updReq, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, rel.ObjKey(tx), req)
 	if err != nil {
 		return err
 	}
 
-	if etag == updReq.GetEtag() {
-		s.logger.Trace().Bytes("key", rel.ObjKey()).Str("etag-equal", etag).Msg("ImportRelation")
+	expiryChanged, err := ds.SetRelationExpiry(tx, req, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	if etag == updReq.GetEtag() && !expiryChanged {
+		s.logger.Trace().Bytes("key", rel.ObjKey(tx)).Str("etag-equal", etag).Msg("ImportRelation")
 		return nil
 	}
 
 	updReq.Etag = etag
 
-	if _, err := bdb.Set[dsc3.Relation](ctx, tx, bdb.RelationsObjPath, rel.ObjKey(), updReq); err != nil {
-		return derr.ErrInvalidRelation.Msg("set")
-	}
-
-	if _, err := bdb.Set[dsc3.Relation](ctx, tx, bdb.RelationsSubPath, rel.SubKey(), updReq); err != nil {
+	if _, err := ds.SetRelation(ctx, tx, updReq); err != nil {
 		return derr.ErrInvalidRelation.Msg("set")
 	}
 
 	return nil
 }
 
-func (s *Sync) relationDeleteHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Relation) error {
+func (s *Sync) relationDeleteHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Relation) error {
 	s.logger.Debug().Interface("relation", req).Msg("ImportRelation")
 
 	if req == nil {
@@ -130,11 +156,7 @@ func (s *Sync) relationDeleteHandler(ctx context.Context, tx *bolt.Tx, req *dsc3
 		return err
 	}
 
-	if err := bdb.Delete(ctx, tx, bdb.RelationsObjPath, rel.ObjKey()); err != nil {
-		return derr.ErrInvalidRelation.Msg("delete")
-	}
-
-	if err := bdb.Delete(ctx, tx, bdb.RelationsSubPath, rel.SubKey()); err != nil {
+	if err := ds.DeleteRelation(ctx, tx, req); err != nil {
 		return derr.ErrInvalidRelation.Msg("delete")
 	}
 
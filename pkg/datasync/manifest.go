@@ -13,10 +13,10 @@ import (
 	dsm3 "github.com/aserto-dev/go-directory/aserto/directory/model/v3"
 	"github.com/aserto-dev/go-directory/pkg/derr"
 	"github.com/aserto-dev/go-directory/pkg/validator"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/ds"
 
 	"github.com/pkg/errors"
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -43,7 +43,7 @@ func (s *Sync) syncManifest(ctx context.Context, conn *grpc.ClientConn) error {
 			localReader io.Reader
 		)
 
-		err := s.store.DB().View(func(tx *bolt.Tx) error {
+		err := s.store.DB().View(func(tx bdb.Tx) error {
 			md := &dsm3.Metadata{UpdatedAt: timestamppb.Now(), Etag: ""}
 			manifest, err := ds.Manifest(md).Get(ctx, tx)
 
@@ -110,7 +110,7 @@ func (s *Sync) setManifest(ctx context.Context, remoteBuf []byte) (*model.Model,
 		return nil, derr.ErrInvalidArgument.Msg(err.Error())
 	}
 
-	if err := s.store.DB().Update(func(tx *bolt.Tx) error {
+	if err := s.store.DB().Update(func(tx bdb.Tx) error {
 		stats, err := ds.CalculateStats(ctx, tx)
 		if err != nil {
 			return derr.ErrUnknown.Msgf("failed to calculate stats: %s", err.Error())
@@ -9,7 +9,6 @@ import (
 
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -73,6 +72,19 @@ func maxTS(lhs, rhs *timestamppb.Timestamp) *timestamppb.Timestamp {
 }
 
 func (s *Sync) getWatermark() *watermark {
+	if !s.fileBacked() {
+		s.Client.mu.Lock()
+		defer s.Client.mu.Unlock()
+
+		if s.Client.wm == nil {
+			return newWatermark()
+		}
+
+		wm := *s.Client.wm
+
+		return &wm
+	}
+
 	r, err := os.Open(s.syncFilename())
 	if err != nil {
 		return newWatermark()
@@ -100,21 +112,30 @@ func (s *Sync) setWatermark(ts *timestamppb.Timestamp) error {
 	wm.Timestamp = newTS
 	wm.LastUpdated = newTS.AsTime().Format(time.RFC3339Nano)
 
-	objStats, err := s.dbStats(bdb.ObjectsPath)
+	objCount, err := s.keyCount(bdb.ObjectsPath)
 	if err != nil {
 		return err
 	}
 
-	relStats, err := s.dbStats(bdb.RelationsObjPath)
+	relCount, err := s.keyCount(bdb.RelationsObjPath)
 	if err != nil {
 		return err
 	}
 
-	wm.ObjectCount = uint(objStats.KeyN)   //nolint:gosec // G115: integer overflow conversion int -> uint
-	wm.RelationCount = uint(relStats.KeyN) //nolint:gosec // G115: integer overflow conversion int -> uint
+	wm.ObjectCount = uint(objCount)   //nolint:gosec // G115: integer overflow conversion int -> uint
+	wm.RelationCount = uint(relCount) //nolint:gosec // G115: integer overflow conversion int -> uint
 
 	wm.TotalCount = wm.ObjectCount + wm.RelationCount
 
+	if !s.fileBacked() {
+		s.Client.mu.Lock()
+		defer s.Client.mu.Unlock()
+
+		s.Client.wm = wm
+
+		return nil
+	}
+
 	w, err := os.Create(s.syncFilename())
 	if err != nil {
 		return err
@@ -131,26 +152,32 @@ func (s *Sync) setWatermark(ts *timestamppb.Timestamp) error {
 }
 
 func (s *Sync) syncFilename() string {
-	dir, file := filepath.Split(s.Client.store.DB().Path())
+	dir, file := filepath.Split(s.Client.store.Config().DBPath)
 	return filepath.Join(dir, fmt.Sprintf("%s.%s", file, "sync"))
 }
 
-func (s *Sync) dbStats(path bdb.Path) (bolt.BucketStats, error) {
-	var stats bolt.BucketStats
+// fileBacked, returns true when the watermark is persisted next to the store file,
+// the watermark of an in-memory store is kept in memory, as the store content does not survive a restart.
+func (s *Sync) fileBacked() bool {
+	return s.Client.store.Config().Backend != bdb.MemoryBackend
+}
+
+func (s *Sync) keyCount(path bdb.Path) (int, error) {
+	var count int
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
-		stats = bucketStats(tx, path)
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		count = bucketKeyCount(tx, path)
 		return nil
 	})
 
-	return stats, err
+	return count, err
 }
 
-func bucketStats(tx *bolt.Tx, path bdb.Path) bolt.BucketStats {
+func bucketKeyCount(tx bdb.Tx, path bdb.Path) int {
 	b, err := bdb.SetBucket(tx, path)
 	if err != nil {
-		return bolt.BucketStats{}
+		return 0
 	}
 
-	return b.Stats()
+	return b.KeyN()
 }
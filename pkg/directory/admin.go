@@ -0,0 +1,158 @@
+package directory
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/admin"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Admin, returns the server of the admin service, which streams backups and restores of the directory store,
+// and verifies and repairs the relation indexes of the directory store.
+func (s *Directory) Admin() admin.AdminServer {
+	return &adminServer{dir: s}
+}
+
+type adminServer struct {
+	dir *Directory
+}
+
+var _ admin.AdminServer = (*adminServer)(nil)
+
+func (s *adminServer) Backup(_ *emptypb.Empty, stream admin.Admin_BackupServer) error {
+	_, err := s.dir.Backup(stream.Context(), &chunkWriter{stream: stream})
+
+	return err
+}
+
+func (s *adminServer) Restore(stream admin.Admin_RestoreServer) error {
+	if err := s.dir.Restore(stream.Context(), &chunkReader{stream: stream}); err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(&emptypb.Empty{})
+}
+
+func (s *adminServer) Verify(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	report, err := s.dir.Verify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]any, 0, len(report.Issues))
+
+	for _, issue := range report.Issues {
+		v, err := issueValue(issue)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, v)
+	}
+
+	return structpb.NewStruct(map[string]any{
+		"relations":   report.Relations,
+		"issues":      issues,
+		"issue_count": report.IssueCount,
+		"truncated":   report.Truncated(),
+	})
+}
+
+func (s *adminServer) Repair(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	report, err := s.dir.Repair(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return structpb.NewStruct(map[string]any{
+		"relations": report.Relations,
+		"skipped":   report.Skipped,
+		"removed":   report.Removed,
+	})
+}
+
+// issueValue, converts the issue to a struct value, the binary key is base64 encoded.
+func issueValue(issue *ds.Issue) (map[string]any, error) {
+	v := map[string]any{
+		"kind": string(issue.Kind),
+		"path": strings.Join(issue.Path, "/"),
+		"key":  base64.StdEncoding.EncodeToString(issue.Key),
+	}
+
+	if issue.Detail != "" {
+		v["detail"] = issue.Detail
+	}
+
+	if issue.Relation != nil {
+		buf, err := protojson.Marshal(issue.Relation)
+		if err != nil {
+			return nil, err
+		}
+
+		var rel structpb.Struct
+		if err := protojson.Unmarshal(buf, &rel); err != nil {
+			return nil, err
+		}
+
+		v["relation"] = rel.AsMap()
+	}
+
+	return v, nil
+}
+
+// chunkWriter, writes the snapshot to the backup stream, in chunks of at most admin.ChunkSize bytes.
+type chunkWriter struct {
+	stream admin.Admin_BackupServer
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+
+	for len(p) > 0 {
+		chunk := p[:min(len(p), admin.ChunkSize)]
+
+		if err := w.stream.Send(&wrapperspb.BytesValue{Value: chunk}); err != nil {
+			return n, err
+		}
+
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+
+	return n, nil
+}
+
+// chunkReader, reads the snapshot from the chunks received from the restore stream.
+type chunkReader struct {
+	stream admin.Admin_RestoreServer
+	buf    []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return 0, io.EOF
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		r.buf = chunk.GetValue()
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
@@ -0,0 +1,211 @@
+package directory
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/fs"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Backup, writes a consistent snapshot of the directory store, in the bbolt file format, to w,
+// while the directory continues to serve reads and writes.
+//
+// The snapshot is staged in a temporary file next to the store, which is copied to w once the read transaction
+// of the snapshot is released, so a slow writer does not hold a read transaction of the store.
+// Returns bdb.ErrSnapshotNotSupported when the directory uses the memory backend.
+func (s *Directory) Backup(_ context.Context, w io.Writer) (int64, error) {
+	if s.config.Backend == bdb.MemoryBackend {
+		return 0, bdb.ErrSnapshotNotSupported.Msg(s.config.Backend)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.config.DBPath), ".backup-*")
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	if _, err := s.snapshot(f); err != nil {
+		return 0, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return io.Copy(w, f)
+}
+
+// snapshot, writes a consistent snapshot of the directory store to w, within a read transaction.
+func (s *Directory) snapshot(w io.Writer) (int64, error) {
+	n, err := s.store.Snapshot(w)
+	if err != nil {
+		return n, err
+	}
+
+	s.logger.Info().Int64("size", n).Msg("backup")
+
+	return n, nil
+}
+
+// BackupFile, writes a consistent snapshot of the directory store to the file at path,
+// the snapshot is written to a temporary file, which replaces the file at path once the snapshot is complete.
+func (s *Directory) BackupFile(_ context.Context, path string) (int64, error) {
+	if s.config.Backend == bdb.MemoryBackend {
+		return 0, bdb.ErrSnapshotNotSupported.Msg(s.config.Backend)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	n, err := s.snapshot(f)
+	if err != nil {
+		_ = f.Close()
+		return n, err
+	}
+
+	if err := f.Close(); err != nil {
+		return n, err
+	}
+
+	return n, os.Rename(f.Name(), path)
+}
+
+// Restore, replaces the directory store by the snapshot read from r.
+//
+// The snapshot is staged next to the store, and validated by reading its schema version, snapshots of an older
+// schema version are migrated to the current schema version, snapshots of a newer schema version are rejected.
+// The staged snapshot atomically replaces the store, waiting for running transactions to finish, the store revision
+// continues from the highest of the replaced and the snapshot revision, a reset change is recorded for watchers,
+// and the decision cache is reset, after which the model cache is reloaded and the declared indexes are synchronized.
+// Returns bdb.ErrSnapshotNotSupported when the directory uses the memory backend.
+func (s *Directory) Restore(ctx context.Context, r io.Reader) error {
+	if s.config.Backend == bdb.MemoryBackend {
+		return bdb.ErrSnapshotNotSupported.Msg(s.config.Backend)
+	}
+
+	s.restoreMu.Lock()
+	defer s.restoreMu.Unlock()
+
+	// the staging directory is created next to the store, the staged snapshot is moved in place of the store file,
+	// which requires both to reside on the same file system.
+	dir, err := os.MkdirTemp(filepath.Dir(s.config.DBPath), ".restore-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, filepath.Base(s.config.DBPath))
+
+	if err := stageSnapshot(path, r); err != nil {
+		return err
+	}
+
+	if err := s.prepareSnapshot(path); err != nil {
+		return err
+	}
+
+	// the restored store continues the revision sequence of the replaced store, watchers observe a reset change,
+	// the decision cache is reset before transactions observe the restored store.
+	prepare := func(cur, next bdb.Tx) error {
+		_, err := ds.ResetChangeLog(cur, next)
+		return err
+	}
+
+	if err := s.store.Replace(path, prepare, s.decisions.Reset); err != nil {
+		return err
+	}
+
+	if err := s.store.DB().Update(func(tx bdb.Tx) error {
+		if err := ds.SyncPropertyIndexes(ctx, tx, s.config.PropertyIndexes); err != nil {
+			return err
+		}
+
+		return ds.SyncSearchProperties(ctx, tx, s.config.SearchProperties)
+	}); err != nil {
+		return err
+	}
+
+	s.logger.Info().Str("db_path", s.config.DBPath).Msg("restore")
+
+	return nil
+}
+
+// RestoreFile, replaces the directory store by the snapshot read from the file at path.
+func (s *Directory) RestoreFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	return s.Restore(ctx, f)
+}
+
+func stageSnapshot(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fs.FileModeOwnerRW)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// prepareSnapshot, validates the staged snapshot is a directory store, and migrates it to the current schema version.
+func (s *Directory) prepareSnapshot(path string) error {
+	db, err := bolt.Open(path, fs.FileModeOwnerRW, &bolt.Options{ReadOnly: true, Timeout: s.config.RequestTimeout})
+	if err != nil {
+		return bdb.ErrInvalidSnapshot.Err(err)
+	}
+
+	version, err := common.GetVersion(db)
+	if err == nil {
+		err = db.View(func(tx *bolt.Tx) error {
+			if tx.Bucket([]byte(bdb.SystemPath[0])) == nil {
+				return bdb.ErrInvalidSnapshot.Msg("snapshot does not contain a directory store")
+			}
+
+			return nil
+		})
+	}
+
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info().Str("version", version.String()).Msg("restore_snapshot_version")
+
+	cfg := bdb.Config{DBPath: path, RequestTimeout: s.config.RequestTimeout}
+
+	return migrateSchema(&cfg, s.logger)
+}
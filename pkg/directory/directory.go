@@ -13,10 +13,13 @@ import (
 	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
 	dsa1 "github.com/authzen/access.go/api/access/v1"
 
+	"github.com/aserto-dev/azm/stats"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/migrate"
 	"github.com/aserto-dev/go-edge-ds/pkg/datasync"
 	v3 "github.com/aserto-dev/go-edge-ds/pkg/directory/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/streamer"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/rs/zerolog"
@@ -27,16 +30,39 @@ import (
 // required minimum schema version, when the current version is lower,
 // migration will be invoked to update to the minimum schema version required.
 const (
-	schemaVersion   string = "0.0.9"
+	schemaVersion   string = "0.0.21"
 	manifestVersion int    = 2
 	manifestName    string = "edge"
 )
 
 type Config struct {
+	// Backend, storage backend, "bolt" (default) or "memory", the memory backend does not persist the directory.
+	Backend        string        `json:"backend"`
 	DBPath         string        `json:"db_path"`
 	RequestTimeout time.Duration `json:"request_timeout"`
 	Seed           bool          `json:"seed_metadata"`
 	EnableV2       bool          `json:"enable_v2"`
+	// TombstoneRetention, duration tombstones of deleted objects and relations are retained,
+	// defaults to ds.DefaultTombstoneRetention when not set.
+	TombstoneRetention time.Duration `json:"tombstone_retention"`
+	// ChangeLogRetention, duration change log entries are retained for watchers to resume from,
+	// defaults to ds.DefaultChangeLogRetention when not set.
+	ChangeLogRetention time.Duration `json:"changelog_retention"`
+	// ExpiryInterval, interval between runs of the reaper deleting expired time-bounded relations,
+	// defaults to ds.DefaultExpiryInterval when not set.
+	ExpiryInterval time.Duration `json:"expiry_interval"`
+	// DecisionCacheSize, maximum number of check decisions cached by the reader, zero disables the decision cache.
+	DecisionCacheSize int `json:"decision_cache_size"`
+	// PropertyIndexes, object properties indexed by object type, used to look up objects by property value,
+	// indexes added to or removed from the config are built or dropped when the directory is opened.
+	PropertyIndexes map[string][]string `json:"property_indexes"`
+	// SearchProperties, object properties added to the full-text search index by object type, next to the display name,
+	// objects of which the search properties changed in the config are reindexed when the directory is opened.
+	SearchProperties map[string][]string `json:"search_properties"`
+	// ReferentialIntegrity, enforcement of the existence of the object and subject of relation writes by the writer,
+	// importer and sync, "reject" rejects relations referencing non-existing objects, "create" creates stub objects
+	// for them, unset stores relations without checking their object and subject exist.
+	ReferentialIntegrity ds.ReferentialIntegrity `json:"referential_integrity"`
 }
 
 type Directory struct {
@@ -46,17 +72,27 @@ type Directory struct {
 	exporter3 dse3.ExporterServer
 	importer3 dsi3.ImporterServer
 	model3    dsm3.ModelServer
-	reader3   dsr3.ReaderServer
+	reader3   *v3.Reader
 	writer3   dsw3.WriterServer
 	access1   dsa1.AccessServer
+	watcher3  *v3.Watcher
+	sync      *datasync.Client
+	decisions *ds.DecisionCache
+	gcCancel  context.CancelFunc
+	gcDone    chan struct{}
+	restoreMu sync.Mutex
 }
 
 var (
 	directory *Directory
-	once      sync.Once
+	mu        sync.Mutex
 )
 
+// Get, returns the process wide directory instance created by New.
 func Get() (*Directory, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
 	if directory != nil {
 		return directory, nil
 	}
@@ -64,42 +100,55 @@ func Get() (*Directory, error) {
 	return nil, status.Error(codes.Internal, "directory not initialized")
 }
 
+// New, returns the process wide directory instance, which is created on first use.
+// Subsequent calls return the same instance, regardless of the config, until the instance is closed.
 func New(ctx context.Context, config *Config, logger *zerolog.Logger) (*Directory, error) {
-	var err error
+	mu.Lock()
+	defer mu.Unlock()
 
-	once.Do(func() {
-		directory, err = newDirectory(ctx, config, logger)
-	})
+	if directory != nil {
+		return directory, nil
+	}
 
-	return directory, err
+	dir, err := newDirectory(ctx, config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	directory = dir
+
+	return directory, nil
 }
 
-func newDirectory(_ context.Context, config *Config, logger *zerolog.Logger) (*Directory, error) {
+// Open, creates a directory instance owned by the caller, independent of the process wide instance returned by New,
+// allowing multiple directories, each with its own store, to be hosted side-by-side.
+// The caller is responsible for releasing the instance using Close.
+func Open(ctx context.Context, config *Config, logger *zerolog.Logger) (*Directory, error) {
+	return newDirectory(ctx, config, logger)
+}
+
+func newDirectory(ctx context.Context, config *Config, logger *zerolog.Logger) (_ *Directory, err error) {
 	newLogger := logger.With().Str("component", "directory").Logger()
 
+	if err := config.ReferentialIntegrity.Validate(); err != nil {
+		return nil, err
+	}
+
 	cfg := bdb.Config{
+		Backend:        config.Backend,
 		DBPath:         config.DBPath,
 		RequestTimeout: config.RequestTimeout,
 	}
 
-	if ok, err := migrate.CheckSchemaVersion(&cfg, logger, semver.MustParse(schemaVersion)); !ok {
-		switch {
-		case errors.Is(err, migrate.ErrDirectorySchemaUpdateRequired):
-			if err := migrate.Migrate(&cfg, logger, semver.MustParse(schemaVersion)); err != nil {
-				return nil, err
-			}
-		case errors.Is(err, migrate.ErrDirectorySchemaVersionHigher):
-			return nil, err
-		default:
-			return nil, err
-		}
-
-		if ok, err := migrate.CheckSchemaVersion(&cfg, logger, semver.MustParse(schemaVersion)); !ok {
+	// in-memory stores are created at the current schema version, migrations only apply to bolt stores.
+	if cfg.Backend != bdb.MemoryBackend {
+		if err := migrateSchema(&cfg, logger); err != nil {
 			return nil, err
 		}
 	}
 
 	store, err := bdb.New(&bdb.Config{
+		Backend:        config.Backend,
 		DBPath:         config.DBPath,
 		RequestTimeout: config.RequestTimeout,
 	},
@@ -113,10 +162,33 @@ func newDirectory(_ context.Context, config *Config, logger *zerolog.Logger) (*D
 		return nil, err
 	}
 
-	reader3 := v3.NewReader(logger, store)
-	writer3 := v3.NewWriter(logger, store)
+	// release the store, and its file lock, when the directory is not created.
+	defer func() {
+		if err != nil {
+			store.Close()
+		}
+	}()
+
+	if err := store.DB().Update(func(tx bdb.Tx) error {
+		if _, err := ds.EnsureStoreID(tx); err != nil {
+			return err
+		}
+
+		if err := ds.SyncPropertyIndexes(ctx, tx, config.PropertyIndexes); err != nil {
+			return err
+		}
+
+		return ds.SyncSearchProperties(ctx, tx, config.SearchProperties)
+	}); err != nil {
+		return nil, err
+	}
+
+	decisions := ds.NewDecisionCache(config.DecisionCacheSize)
+
+	reader3 := v3.NewReader(logger, store, decisions)
+	writer3 := v3.NewWriter(logger, store, config.ReferentialIntegrity)
 	exporter3 := v3.NewExporter(logger, store)
-	importer3 := v3.NewImporter(logger, store)
+	importer3 := v3.NewImporter(logger, store, config.ReferentialIntegrity)
 
 	access1 := v3.NewAccess(logger, reader3)
 
@@ -130,20 +202,65 @@ func newDirectory(_ context.Context, config *Config, logger *zerolog.Logger) (*D
 		exporter3: exporter3,
 		importer3: importer3,
 		access1:   access1,
+		watcher3:  v3.NewWatcher(logger, store),
+		sync:      datasync.New(&newLogger, store, config.ReferentialIntegrity),
+		decisions: decisions,
 	}
 
 	if err := store.LoadModel(); err != nil {
 		return nil, err
 	}
 
+	gcCtx, gcCancel := context.WithCancel(context.Background())
+	dir.gcCancel = gcCancel
+	dir.gcDone = make(chan struct{})
+
+	go dir.gc(gcCtx)
+
 	return dir, nil
 }
 
+func migrateSchema(cfg *bdb.Config, logger *zerolog.Logger) error {
+	if ok, err := migrate.CheckSchemaVersion(cfg, logger, semver.MustParse(schemaVersion)); !ok {
+		switch {
+		case errors.Is(err, migrate.ErrDirectorySchemaUpdateRequired):
+			if err := migrate.Migrate(cfg, logger, semver.MustParse(schemaVersion)); err != nil {
+				return err
+			}
+		case errors.Is(err, migrate.ErrDirectorySchemaVersionHigher):
+			return err
+		default:
+			return err
+		}
+
+		if ok, err := migrate.CheckSchemaVersion(cfg, logger, semver.MustParse(schemaVersion)); !ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close, stops the background routines and closes the store of the directory instance,
+// when the instance is the process wide instance, a subsequent New call creates a new instance.
 func (s *Directory) Close() {
+	if s.gcCancel != nil {
+		s.gcCancel()
+		<-s.gcDone
+		s.gcCancel = nil
+	}
+
 	if s.store != nil {
 		s.store.Close()
 		s.store = nil
 	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if directory == s {
+		directory = nil
+	}
 }
 
 func (s *Directory) Exporter3() dse3.ExporterServer {
@@ -170,6 +287,117 @@ func (s *Directory) Access1() dsa1.AccessServer {
 	return s.access1
 }
 
+// Streamer3, returns the streamer.Streamer service, the server-side streaming variants of GetObjects and GetRelations.
+func (s *Directory) Streamer3() streamer.StreamerServer {
+	return s.reader3
+}
+
+func (s *Directory) Watcher3() *v3.Watcher {
+	return s.watcher3
+}
+
+// DecisionCacheStats, returns the decision cache hit and miss counters.
+func (s *Directory) DecisionCacheStats() ds.DecisionCacheStats {
+	return s.decisions.Stats()
+}
+
+// Stats, returns the object and relation statistics, read from the stats counters.
+func (s *Directory) Stats(ctx context.Context) (*stats.Stats, error) {
+	var result *stats.Stats
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		var err error
+
+		result, err = ds.CalculateStats(ctx, tx)
+
+		return err
+	})
+
+	return result, err
+}
+
+// RepairStats, recomputes the stats counters from the stored objects and relations,
+// returns the recomputed statistics.
+func (s *Directory) RepairStats(ctx context.Context) (*stats.Stats, error) {
+	var result *stats.Stats
+
+	err := s.store.DB().Update(func(tx bdb.Tx) error {
+		var err error
+
+		result, err = ds.RepairStats(ctx, tx)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Int("object_types", len(result.ObjectTypes)).Msg("stats_repair")
+
+	return result, nil
+}
+
+// Verify, cross-checks the relation buckets of the directory store, and validates the relations against the
+// current model, returns the detected integrity issues. Verify does not modify the store.
+func (s *Directory) Verify(ctx context.Context) (*ds.VerifyReport, error) {
+	var result *ds.VerifyReport
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		var err error
+
+		result, err = ds.VerifyRelations(ctx, tx, s.store.MC())
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Int("relations", result.Relations).Int("issues", result.IssueCount).Msg("verify")
+
+	return result, nil
+}
+
+// Repair, rebuilds the relations_sub and relations_rel buckets of the directory store from the relations_obj bucket,
+// and resets the decision cache. The buckets are repaired in batches of ds.RepairBatchSize entries,
+// each in its own write transaction, so writes are not blocked for the duration of the repair.
+func (s *Directory) Repair(ctx context.Context) (*ds.RepairReport, error) {
+	result := &ds.RepairReport{}
+
+	for pos := (ds.RepairPosition{}); !pos.Done(); {
+		// the batch report is merged once the batch is committed.
+		batch := &ds.RepairReport{}
+
+		err := s.store.DB().Update(func(tx bdb.Tx) error {
+			var err error
+
+			pos, err = ds.RepairRelations(ctx, tx, pos, batch)
+
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Relations += batch.Relations
+		result.Skipped += batch.Skipped
+		result.Removed += batch.Removed
+	}
+
+	s.decisions.Reset()
+
+	s.logger.Info().Int("relations", result.Relations).Int("skipped", result.Skipped).Int("removed", result.Removed).Msg("repair")
+
+	return result, nil
+}
+
+// Compact, compacts the directory store file, reclaiming the space of deleted instances,
+// writes are only paused while the writes made during the copy are applied and the compacted file is swapped in.
+// Returns bdb.ErrCompactionNotSupported when the directory uses the memory backend.
+func (s *Directory) Compact(_ context.Context) (*bdb.CompactStats, error) {
+	return s.store.Compact()
+}
+
 func (s *Directory) Logger() *zerolog.Logger {
 	return s.logger
 }
@@ -179,5 +407,5 @@ func (s *Directory) Config() Config {
 }
 
 func (s *Directory) DataSyncClient() datasync.SyncClient {
-	return datasync.New(s.logger, s.store)
+	return s.sync
 }
@@ -0,0 +1,137 @@
+package directory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+)
+
+// maximum interval between garbage collection runs.
+const gcInterval = time.Hour
+
+func (c *Config) tombstoneRetention() time.Duration {
+	if c.TombstoneRetention <= 0 {
+		return ds.DefaultTombstoneRetention
+	}
+
+	return c.TombstoneRetention
+}
+
+func (c *Config) changeLogRetention() time.Duration {
+	if c.ChangeLogRetention <= 0 {
+		return ds.DefaultChangeLogRetention
+	}
+
+	return c.ChangeLogRetention
+}
+
+func (c *Config) expiryInterval() time.Duration {
+	if c.ExpiryInterval <= 0 {
+		return ds.DefaultExpiryInterval
+	}
+
+	return c.ExpiryInterval
+}
+
+// gc, periodically purges tombstones and change log entries older than their configured retention window,
+// and deletes expired relations, runs until the context is canceled.
+func (s *Directory) gc(ctx context.Context) {
+	defer close(s.gcDone)
+
+	tombstoneRetention := s.config.tombstoneRetention()
+	changeLogRetention := s.config.changeLogRetention()
+
+	ticker := time.NewTicker(min(tombstoneRetention, changeLogRetention, gcInterval))
+	defer ticker.Stop()
+
+	expiryTicker := time.NewTicker(s.config.expiryInterval())
+	defer expiryTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expiryTicker.C:
+			if _, err := s.PurgeExpiredRelations(ctx, time.Now().UTC()); err != nil {
+				s.logger.Error().Err(err).Msg("expiry_gc")
+			}
+		case <-ticker.C:
+			now := time.Now().UTC()
+
+			if _, err := s.PurgeTombstones(ctx, now.Add(-tombstoneRetention)); err != nil {
+				s.logger.Error().Err(err).Msg("tombstone_gc")
+			}
+
+			if _, err := s.PurgeChanges(ctx, now.Add(-changeLogRetention)); err != nil {
+				s.logger.Error().Err(err).Msg("changelog_gc")
+			}
+		}
+	}
+}
+
+// PurgeTombstones, garbage collects the tombstones recorded before the given time,
+// returns the number of purged tombstones.
+func (s *Directory) PurgeTombstones(ctx context.Context, before time.Time) (int, error) {
+	count := 0
+
+	err := s.store.DB().Update(func(tx bdb.Tx) error {
+		var err error
+
+		count, err = ds.PurgeTombstones(ctx, tx, before)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Debug().Int("purged", count).Time("before", before).Msg("tombstone_gc")
+
+	return count, nil
+}
+
+// PurgeChanges, garbage collects the change log entries recorded before the given time,
+// returns the number of purged change log entries.
+func (s *Directory) PurgeChanges(ctx context.Context, before time.Time) (int, error) {
+	count := 0
+
+	err := s.store.DB().Update(func(tx bdb.Tx) error {
+		var err error
+
+		count, err = ds.PurgeChanges(ctx, tx, before)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Debug().Int("purged", count).Time("before", before).Msg("changelog_gc")
+
+	return count, nil
+}
+
+// PurgeExpiredRelations, deletes the relations expired at the given time, the deletes are recorded
+// as tombstones and change log entries, returns the number of deleted relations.
+func (s *Directory) PurgeExpiredRelations(ctx context.Context, now time.Time) (int, error) {
+	count := 0
+
+	err := s.store.DB().Update(func(tx bdb.Tx) error {
+		var err error
+
+		count, err = ds.PurgeExpiredRelations(ctx, tx, now)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count > 0 {
+		s.logger.Debug().Int("purged", count).Time("now", now).Msg("expiry_gc")
+	}
+
+	return count, nil
+}
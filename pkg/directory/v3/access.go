@@ -28,6 +28,13 @@ func NewAccess(logger *zerolog.Logger, reader *Reader) *Access {
 // The Access Evaluation API defines the message exchange pattern between a client (PEP)
 // and an authorization service (PDP) for executing a single access evaluation.
 func (s *Access) Evaluation(ctx context.Context, req *acc1.EvaluationRequest) (*acc1.EvaluationResponse, error) {
+	ctx, err := evaluationContextRelations(ctx, req.GetContext())
+	if err != nil {
+		return &acc1.EvaluationResponse{}, err
+	}
+
+	ctx = evaluationSubjectAlias(ctx, req.GetSubject().GetProperties())
+
 	resp, err := s.reader.Check(ctx, extractCheck(req))
 	if err != nil {
 		return &acc1.EvaluationResponse{}, err
@@ -64,6 +71,11 @@ func extractCheck(req *acc1.EvaluationRequest) *dsr3.CheckRequest {
 // and an authorization service (PDP) for evaluating multiple access evaluations within
 // the scope of a single message exchange (also known as "boxcarring" requests).
 func (s *Access) Evaluations(ctx context.Context, req *acc1.EvaluationsRequest) (*acc1.EvaluationsResponse, error) {
+	ctx, err := evaluationContextRelations(ctx, req.GetContext())
+	if err != nil {
+		return &acc1.EvaluationsResponse{}, err
+	}
+
 	defCheck, checks := extractChecks(req)
 
 	checksResp, err := s.reader.Checks(ctx, &dsr3.ChecksRequest{Default: defCheck, Checks: checks})
@@ -0,0 +1,65 @@
+package v3
+
+import (
+	"context"
+	"strconv"
+
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// subjectAliasProp, AuthZEN subject property flagging the subject id as an identity alias.
+const subjectAliasProp string = "alias"
+
+// withSubjectAlias, flags the subject id as alias, when requested by the incoming request metadata.
+func withSubjectAlias(ctx context.Context) (context.Context, error) {
+	v := metautils.ExtractIncoming(ctx).Get(x.SubjectAliasHeader)
+	if v == "" {
+		return ctx, nil
+	}
+
+	alias, err := strconv.ParseBool(v)
+	if err != nil {
+		return ctx, ds.ErrInvalidAlias.Msgf("%s: %q", x.SubjectAliasHeader, v)
+	}
+
+	if alias {
+		ctx = ds.WithSubjectAlias(ctx)
+	}
+
+	return ctx, nil
+}
+
+// evaluationSubjectAlias, flags the subject id as alias, when requested by the AuthZEN subject properties.
+func evaluationSubjectAlias(ctx context.Context, props *structpb.Struct) context.Context {
+	if props.GetFields()[subjectAliasProp].GetBoolValue() {
+		return ds.WithSubjectAlias(ctx)
+	}
+
+	return ctx
+}
+
+// resolveSubjectAlias, returns a clone of the check request of which the subject id is replaced by the id of the subject
+// identified by the alias, when the context flags the subject id as alias, otherwise returns the request unchanged.
+// The alias is resolved in the transaction the check is evaluated in.
+func resolveSubjectAlias(ctx context.Context, tx bdb.Tx, req *dsr3.CheckRequest) (*dsr3.CheckRequest, error) {
+	if !ds.IsSubjectAlias(ctx) {
+		return req, nil
+	}
+
+	id, err := ds.ResolveAlias(ctx, tx, req.GetSubjectType(), req.GetSubjectId())
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := proto.Clone(req).(*dsr3.CheckRequest)
+	resolved.SubjectId = id
+
+	return resolved, nil
+}
@@ -0,0 +1,68 @@
+package v3
+
+import (
+	"context"
+
+	"github.com/aserto-dev/azm/cache"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// contextualRelationsProp, AuthZEN evaluation context property containing the list of contextual relations.
+const contextualRelationsProp string = "contextual_relations"
+
+// withContextualRelations, attaches the contextual relations passed in the incoming request metadata to the context,
+// and validates all contextual relations carried by the context against the model.
+func withContextualRelations(ctx context.Context, mc *cache.Cache) (context.Context, error) {
+	values := grpcmd.ValueFromIncomingContext(ctx, x.ContextualRelationHeader)
+
+	rels := make([]*dsc3.RelationIdentifier, 0, len(values))
+
+	for _, v := range values {
+		rel, err := ds.ParseContextualRelation(v)
+		if err != nil {
+			return ctx, err
+		}
+
+		rels = append(rels, rel)
+	}
+
+	ctx = ds.WithContextualRelations(ctx, rels...)
+
+	if err := ds.ValidateContextualRelations(mc, ds.ContextualRelations(ctx)); err != nil {
+		return ctx, err
+	}
+
+	return ctx, nil
+}
+
+// evaluationContextRelations, attaches the contextual relations passed in the AuthZEN evaluation context to the context.
+func evaluationContextRelations(ctx context.Context, evalCtx *structpb.Struct) (context.Context, error) {
+	list := evalCtx.GetFields()[contextualRelationsProp].GetListValue()
+	if list == nil {
+		return ctx, nil
+	}
+
+	rels := make([]*dsc3.RelationIdentifier, 0, len(list.GetValues()))
+
+	for _, v := range list.GetValues() {
+		buf, err := protojson.Marshal(v)
+		if err != nil {
+			return ctx, ds.ErrInvalidContextualRelation.Err(err)
+		}
+
+		rel, err := ds.ParseContextualRelation(string(buf))
+		if err != nil {
+			return ctx, err
+		}
+
+		rels = append(rels, rel)
+	}
+
+	return ds.WithContextualRelations(ctx, rels...), nil
+}
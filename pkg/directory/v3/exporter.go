@@ -1,16 +1,20 @@
 package v3
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"time"
 
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
 	"github.com/aserto-dev/go-directory/pkg/pb"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
 
 	"github.com/rs/zerolog"
-	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc/metadata"
 )
 
 type Exporter struct {
@@ -27,87 +31,317 @@ func NewExporter(logger *zerolog.Logger, store *bdb.BoltDB) *Exporter {
 	}
 }
 
+// Export, streams the requested instances of the store.
+//
+// The instances are read in batches of x.MaxPageSize, each batch is read in its own read transaction, which is released
+// before the batch is sent, so a slow receiver does not hold a read transaction of the store. The exported revision
+// announced in the header is the revision at the start of the export, instances changed during the export are exported
+// in either state, sync clients apply the changes following the announced revision.
 func (s *Exporter) Export(req *dse3.ExportRequest, stream dse3.Exporter_ExportServer) error {
 	logger := s.logger.With().Str("method", "Export").Interface("req", req).Logger()
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
-		// stats mode, short circuits when enabled
-		if req.GetOptions()&uint32(dse3.Option_OPTION_STATS) != 0 {
-			if err := exportStats(tx, stream, req.GetOptions()); err != nil {
-				logger.Error().Err(err).Msg("export_stats")
-				return err
-			}
+	// stats mode, short circuits when enabled
+	if req.GetOptions()&uint32(dse3.Option_OPTION_STATS) != 0 {
+		if err := s.exportStats(stream, req.GetOptions()); err != nil {
+			logger.Error().Err(err).Msg("export_stats")
+			return err
+		}
 
-			return nil
+		return nil
+	}
+
+	// incremental mode, only export instances updated after the start_from timestamp.
+	startFrom := startFromTime(req)
+
+	if err := s.exportHeader(stream, req.GetOptions()); err != nil {
+		return err
+	}
+
+	if req.GetOptions()&uint32(dse3.Option_OPTION_DATA_OBJECTS) != 0 {
+		if err := s.exportBatches(stream, exportObjects(startFrom)); err != nil {
+			logger.Error().Err(err).Msg("export_objects")
+			return err
 		}
+	}
 
-		if req.GetOptions()&uint32(dse3.Option_OPTION_DATA_OBJECTS) != 0 {
-			if err := exportObjects(tx, stream); err != nil {
-				logger.Error().Err(err).Msg("export_objects")
-				return err
-			}
+	if req.GetOptions()&uint32(dse3.Option_OPTION_DATA_RELATIONS) != 0 {
+		batch := exportRelations(startFrom)
+		if req.GetOptions()&ds.OptionRelationExpiry != 0 {
+			batch = withRelationExpiry(batch)
 		}
 
-		if req.GetOptions()&uint32(dse3.Option_OPTION_DATA_RELATIONS) != 0 {
-			if err := exportRelations(tx, stream); err != nil {
-				logger.Error().Err(err).Msg("export_relations")
-				return err
-			}
+		if err := s.exportBatches(stream, batch); err != nil {
+			logger.Error().Err(err).Msg("export_relations")
+			return err
 		}
+	}
 
-		return nil
-	})
+	if req.GetOptions()&ds.OptionTombstones != 0 {
+		if err := s.exportTombstones(stream, req.GetOptions(), startFrom); err != nil {
+			logger.Error().Err(err).Msg("export_tombstones")
+			return err
+		}
+	}
 
-	return err
+	return nil
 }
 
-func exportObjects(tx *bolt.Tx, stream dse3.Exporter_ExportServer) error {
-	iter, err := bdb.NewScanIterator[dsc3.Object](stream.Context(), tx, bdb.ObjectsPath)
-	if err != nil {
+// exportHeader, announces the exported revision and its origin, and the tombstone horizon before the data,
+// so sync clients can record the applied upstream revision, and fall back to a full sync.
+func (s *Exporter) exportHeader(stream dse3.Exporter_ExportServer, opts uint32) error {
+	var md metadata.MD
+
+	if err := s.store.DB().View(func(tx bdb.Tx) error {
+		md = revisionMD(revision{value: ds.Revision(tx), origin: ds.StoreID(tx)})
+
+		if opts&ds.OptionTombstones != 0 {
+			if horizon := ds.TombstoneHorizon(tx); !horizon.IsZero() {
+				md.Append(x.TombstoneHorizonHeader, horizon.Format(time.RFC3339Nano))
+			}
+		}
+
+		return nil
+	}); err != nil {
 		return err
 	}
 
-	for iter.Next() {
-		if err := stream.Send(&dse3.ExportResponse{Msg: &dse3.ExportResponse_Object{Object: iter.Value()}}); err != nil {
+	return stream.SendHeader(md)
+}
+
+// exportBatch, reads the batch of export responses starting at the start key (nil starts at the beginning),
+// returns the key following the batch, which is nil when the batch is the last batch.
+type exportBatch func(ctx context.Context, tx bdb.Tx, start []byte) ([]*dse3.ExportResponse, []byte, error)
+
+// exportBatches, reads the batches of the export in their own read transactions, and sends each batch once
+// the transaction is released.
+func (s *Exporter) exportBatches(stream dse3.Exporter_ExportServer, batch exportBatch) error {
+	ctx := stream.Context()
+
+	var start []byte
+
+	for {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-	}
 
-	return nil
+		var resps []*dse3.ExportResponse
+
+		if err := s.store.DB().View(func(tx bdb.Tx) error {
+			var err error
+
+			resps, start, err = batch(ctx, tx, start)
+
+			return err
+		}); err != nil {
+			return err
+		}
+
+		for _, resp := range resps {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+
+		if start == nil {
+			return nil
+		}
+	}
 }
 
-func exportRelations(tx *bolt.Tx, stream dse3.Exporter_ExportServer) error {
-	iter, err := bdb.NewScanIterator[dsc3.Relation](stream.Context(), tx, bdb.RelationsObjPath)
+// scanBatch, returns the export responses of the instances at path, starting at the start key,
+// msg returns nil for instances which are not exported.
+func scanBatch[T any, M bdb.Message[T]](
+	ctx context.Context,
+	tx bdb.Tx,
+	path bdb.Path,
+	start []byte,
+	opts []bdb.ScanOption,
+	msg func(iter *bdb.ScanIterator[T, M]) (*dse3.ExportResponse, error),
+) ([]*dse3.ExportResponse, []byte, error) {
+	if start != nil {
+		opts = append(opts, bdb.WithStartKey(start))
+	}
+
+	iter, err := bdb.NewScanIterator[T, M](ctx, tx, path, opts...)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	for iter.Next() {
-		if err := stream.Send(&dse3.ExportResponse{Msg: &dse3.ExportResponse_Relation{Relation: iter.Value()}}); err != nil {
-			return err
+	resps := make([]*dse3.ExportResponse, 0, x.MaxPageSize)
+
+	for len(resps) < int(x.MaxPageSize) && iter.Next() {
+		resp, err := msg(iter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if resp != nil {
+			resps = append(resps, resp)
 		}
 	}
 
-	return nil
+	if len(resps) == int(x.MaxPageSize) && iter.Next() {
+		// the key is only valid for the lifetime of the transaction.
+		return resps, bytes.Clone(iter.RawKey()), nil
+	}
+
+	return resps, nil, nil
 }
 
-func exportStats(tx *bolt.Tx, stream dse3.Exporter_ExportServer, opts uint32) error {
-	stats := ds.NewStats()
+// startFromTime returns the start_from timestamp of the export request,
+// a zero time value is returned when not set, which results in a full export.
+func startFromTime(req *dse3.ExportRequest) time.Time {
+	if req.GetStartFrom() == nil || !req.GetStartFrom().IsValid() {
+		return time.Time{}
+	}
+
+	ts := req.GetStartFrom().AsTime()
+	if ts.Unix() == 0 && ts.Nanosecond() == 0 {
+		return time.Time{}
+	}
+
+	return ts
+}
+
+func exportObjects(startFrom time.Time) exportBatch {
+	objectMsg := func(obj *dsc3.Object) *dse3.ExportResponse {
+		return &dse3.ExportResponse{Msg: &dse3.ExportResponse_Object{Object: obj}}
+	}
+
+	if !startFrom.IsZero() {
+		return exportUpdatedAfter(bdb.ObjectsUpdatedAtPath, bdb.ObjectsPath, startFrom, objectMsg)
+	}
+
+	return func(ctx context.Context, tx bdb.Tx, start []byte) ([]*dse3.ExportResponse, []byte, error) {
+		return scanBatch(ctx, tx, bdb.ObjectsPath, start, nil,
+			func(iter *bdb.ScanIterator[dsc3.Object, *dsc3.Object]) (*dse3.ExportResponse, error) {
+				return objectMsg(iter.Value()), nil
+			})
+	}
+}
+
+func exportRelations(startFrom time.Time) exportBatch {
+	relationMsg := func(rel *dsc3.Relation) *dse3.ExportResponse {
+		return &dse3.ExportResponse{Msg: &dse3.ExportResponse_Relation{Relation: rel}}
+	}
+
+	if !startFrom.IsZero() {
+		return exportUpdatedAfter(bdb.RelationsUpdatedAtPath, bdb.RelationsObjPath, startFrom, relationMsg)
+	}
+
+	return func(ctx context.Context, tx bdb.Tx, start []byte) ([]*dse3.ExportResponse, []byte, error) {
+		return scanBatch(ctx, tx, bdb.RelationsObjPath, start, nil,
+			func(iter *bdb.ScanIterator[dsc3.Relation, *dsc3.Relation]) (*dse3.ExportResponse, error) {
+				return relationMsg(iter.Value()), nil
+			})
+	}
+}
+
+// withRelationExpiry, precedes each time-bounded relation of the batch with the stats message announcing
+// its expiration time (ds.OptionRelationExpiry), read in the transaction of the batch.
+func withRelationExpiry(batch exportBatch) exportBatch {
+	return func(ctx context.Context, tx bdb.Tx, start []byte) ([]*dse3.ExportResponse, []byte, error) {
+		resps, next, err := batch(ctx, tx, start)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		expiry := ds.RelationExpiryExporter(tx)
+		out := make([]*dse3.ExportResponse, 0, len(resps))
+
+		for _, resp := range resps {
+			if stats := expiry(resp.GetRelation()); stats != nil {
+				out = append(out, &dse3.ExportResponse{Msg: &dse3.ExportResponse_Stats{Stats: stats}})
+			}
+
+			out = append(out, resp)
+		}
 
-	// object stats.
+		return out, next, nil
+	}
+}
+
+// exportUpdatedAfter, walks the updated_at index starting after the start_from timestamp,
+// resolving each index entry to the instance stored in the primary bucket.
+func exportUpdatedAfter[T any, M bdb.Message[T]](
+	idxPath, path bdb.Path,
+	startFrom time.Time,
+	msg func(M) *dse3.ExportResponse,
+) exportBatch {
+	return func(ctx context.Context, tx bdb.Tx, start []byte) ([]*dse3.ExportResponse, []byte, error) {
+		return scanBatch(ctx, tx, idxPath, start, []bdb.ScanOption{bdb.WithStartKey(ds.TimestampSeekKey(startFrom))},
+			func(iter *bdb.ScanIterator[T, M]) (*dse3.ExportResponse, error) {
+				v, err := bdb.Get[T, M](ctx, tx, path, iter.RawValue())
+				if err != nil {
+					return nil, err
+				}
+
+				return msg(v), nil
+			})
+	}
+}
+
+// exportTombstones, streams the tombstones recorded after the start_from timestamp,
+// tombstone instances are identified by their etag value (ds.TombstoneEtag).
+func (s *Exporter) exportTombstones(stream dse3.Exporter_ExportServer, opts uint32, startFrom time.Time) error {
 	if opts&uint32(dse3.Option_OPTION_DATA_OBJECTS) != 0 {
-		if err := stats.CountObjects(stream.Context(), tx); err != nil {
+		if err := s.exportBatches(stream, tombstonesAfter(bdb.ObjectsDeletedAtPath, bdb.ObjectTombstonesPath, startFrom,
+			func(obj *dsc3.Object) *dse3.ExportResponse {
+				return &dse3.ExportResponse{Msg: &dse3.ExportResponse_Object{Object: obj}}
+			})); err != nil {
 			return err
 		}
 	}
 
-	// relation stats.
 	if opts&uint32(dse3.Option_OPTION_DATA_RELATIONS) != 0 {
-		if err := stats.CountRelations(stream.Context(), tx); err != nil {
+		if err := s.exportBatches(stream, tombstonesAfter(bdb.RelationsDeletedAtPath, bdb.RelationTombstonesPath, startFrom,
+			func(rel *dsc3.Relation) *dse3.ExportResponse {
+				return &dse3.ExportResponse{Msg: &dse3.ExportResponse_Relation{Relation: rel}}
+			})); err != nil {
 			return err
 		}
 	}
 
+	return nil
+}
+
+// tombstonesAfter, walks the deletion time index of the tombstones at path starting after the start_from timestamp,
+// all tombstones are exported when the start_from timestamp is not set.
+func tombstonesAfter[T any, M bdb.Message[T]](idxPath, path bdb.Path, startFrom time.Time, msg func(M) *dse3.ExportResponse) exportBatch {
+	if !startFrom.IsZero() {
+		return exportUpdatedAfter(idxPath, path, startFrom, msg)
+	}
+
+	return func(ctx context.Context, tx bdb.Tx, start []byte) ([]*dse3.ExportResponse, []byte, error) {
+		return scanBatch(ctx, tx, path, start, nil, func(iter *bdb.ScanIterator[T, M]) (*dse3.ExportResponse, error) {
+			return msg(iter.Value()), nil
+		})
+	}
+}
+
+func (s *Exporter) exportStats(stream dse3.Exporter_ExportServer, opts uint32) error {
+	stats := ds.NewStats()
+
+	if err := s.store.DB().View(func(tx bdb.Tx) error {
+		// object stats.
+		if opts&uint32(dse3.Option_OPTION_DATA_OBJECTS) != 0 {
+			if err := stats.LoadObjects(stream.Context(), tx); err != nil {
+				return err
+			}
+		}
+
+		// relation stats.
+		if opts&uint32(dse3.Option_OPTION_DATA_RELATIONS) != 0 {
+			if err := stats.LoadRelations(stream.Context(), tx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	buf, err := json.Marshal(stats.Stats)
 	if err != nil {
 		return err
@@ -15,15 +15,15 @@ import (
 	"github.com/aserto-dev/go-edge-ds/pkg/ds"
 
 	"github.com/rs/zerolog"
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc/status"
 )
 
 type Importer struct {
 	dsi3.UnimplementedImporterServer
 
-	logger *zerolog.Logger
-	store  *bdb.BoltDB
+	logger       *zerolog.Logger
+	store        *bdb.BoltDB
+	refIntegrity ds.ReferentialIntegrity
 }
 
 const (
@@ -33,10 +33,11 @@ const (
 
 type counters map[string]*dsi3.ImportCounter
 
-func NewImporter(logger *zerolog.Logger, store *bdb.BoltDB) *Importer {
+func NewImporter(logger *zerolog.Logger, store *bdb.BoltDB, refIntegrity ds.ReferentialIntegrity) *Importer {
 	return &Importer{
-		logger: logger,
-		store:  store,
+		logger:       logger,
+		store:        store,
+		refIntegrity: refIntegrity,
 	}
 }
 
@@ -48,7 +49,9 @@ func (s *Importer) Import(stream dsi3.Importer_ImportServer) error {
 		relation: {Type: relation},
 	}
 
-	importErr := s.store.DB().Batch(func(tx *bolt.Tx) error {
+	var rev revision
+
+	importErr := s.store.DB().Batch(withRevision(&rev, func(tx bdb.Tx) error {
 		for {
 			select {
 			case <-ctx.Done(): // exit if context is done
@@ -90,12 +93,15 @@ func (s *Importer) Import(stream dsi3.Importer_ImportServer) error {
 				}
 			}
 		}
-	})
+	}))
+	if importErr == nil {
+		stream.SetTrailer(revisionMD(rev))
+	}
 
 	return importErr
 }
 
-func (s *Importer) handleImportRequest(ctx context.Context, tx *bolt.Tx, req *dsi3.ImportRequest, ctr counters) error {
+func (s *Importer) handleImportRequest(ctx context.Context, tx bdb.Tx, req *dsi3.ImportRequest, ctr counters) error {
 	switch m := req.GetMsg().(type) {
 	case *dsi3.ImportRequest_Object:
 		if req.GetOpCode() == dsi3.Opcode_OPCODE_SET {
@@ -147,7 +153,7 @@ func (s *Importer) handleImportRequest(ctx context.Context, tx *bolt.Tx, req *ds
 	}
 }
 
-func (s *Importer) objectSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Object) error {
+func (s *Importer) objectSetHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Object) error {
 	s.logger.Debug().Interface("object", req).Msg("ImportObject")
 
 	if req == nil {
@@ -165,26 +171,26 @@ func (s *Importer) objectSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.
 
 	etag := obj.Hash()
 
-	updReq, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, obj.Key(), req)
+	updReq, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, obj.Key(tx), req)
 	if err != nil {
 		return err
 	}
 
 	if etag == updReq.GetEtag() {
-		s.logger.Trace().Bytes("key", obj.Key()).Str("etag-equal", etag).Msg("ImportObject")
+		s.logger.Trace().Bytes("key", obj.Key(tx)).Str("etag-equal", etag).Msg("ImportObject")
 		return nil
 	}
 
 	updReq.Etag = etag
 
-	if _, err := bdb.Set[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.Object(updReq).Key(), updReq); err != nil {
+	if _, err := ds.SetObject(ctx, tx, updReq); err != nil {
 		return derr.ErrInvalidObject.Msg("set")
 	}
 
 	return nil
 }
 
-func (s *Importer) objectDeleteHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Object) error {
+func (s *Importer) objectDeleteHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Object) error {
 	s.logger.Debug().Interface("object", req).Msg("ImportObject")
 
 	if req == nil {
@@ -200,14 +206,14 @@ func (s *Importer) objectDeleteHandler(ctx context.Context, tx *bolt.Tx, req *ds
 		return modelValidateError(err)
 	}
 
-	if err := bdb.Delete(ctx, tx, bdb.ObjectsPath, obj.Key()); err != nil {
+	if err := ds.DeleteObject(ctx, tx, &dsc3.ObjectIdentifier{ObjectType: req.GetType(), ObjectId: req.GetId()}); err != nil {
 		return derr.ErrInvalidObject.Msg("delete")
 	}
 
 	return nil
 }
 
-func (s *Importer) objectDeleteWithRelationsHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Object) error {
+func (s *Importer) objectDeleteWithRelationsHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Object) error {
 	s.logger.Debug().Interface("object", req).Msg("ImportObject")
 
 	if req == nil {
@@ -223,7 +229,7 @@ func (s *Importer) objectDeleteWithRelationsHandler(ctx context.Context, tx *bol
 		return modelValidateError(err)
 	}
 
-	if err := bdb.Delete(ctx, tx, bdb.ObjectsPath, obj.Key()); err != nil {
+	if err := ds.DeleteObject(ctx, tx, &dsc3.ObjectIdentifier{ObjectType: req.GetType(), ObjectId: req.GetId()}); err != nil {
 		return derr.ErrInvalidObject.Msg("delete")
 	}
 
@@ -240,22 +246,17 @@ func (s *Importer) objectDeleteWithRelationsHandler(ctx context.Context, tx *bol
 	return nil
 }
 
-func (*Importer) deleteObjectRelations(ctx context.Context, tx *bolt.Tx, path bdb.Path, obj *dsc3.Object) error {
+func (*Importer) deleteObjectRelations(ctx context.Context, tx bdb.Tx, path bdb.Path, obj *dsc3.Object) error {
 	iter, err := bdb.NewScanIterator[dsc3.Relation](
 		ctx, tx, path,
-		bdb.WithKeyFilter(append(ds.Object(obj).Key(), ds.InstanceSeparator)),
+		bdb.WithKeyFilter(ds.Object(obj).Key(tx)),
 	)
 	if err != nil {
 		return err
 	}
 
 	for iter.Next() {
-		rel := ds.Relation(iter.Value())
-		if err := bdb.Delete(ctx, tx, bdb.RelationsObjPath, rel.ObjKey()); err != nil {
-			return err
-		}
-
-		if err := bdb.Delete(ctx, tx, bdb.RelationsSubPath, rel.SubKey()); err != nil {
+		if err := ds.DeleteRelation(ctx, tx, iter.Value()); err != nil {
 			return err
 		}
 	}
@@ -263,7 +264,7 @@ func (*Importer) deleteObjectRelations(ctx context.Context, tx *bolt.Tx, path bd
 	return nil
 }
 
-func (s *Importer) relationSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Relation) error {
+func (s *Importer) relationSetHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Relation) error {
 	s.logger.Debug().Interface("relation", req).Msg("ImportRelation")
 
 	if req == nil {
@@ -279,32 +280,43 @@ func (s *Importer) relationSetHandler(ctx context.Context, tx *bolt.Tx, req *dsc
 		return modelValidateError(err)
 	}
 
+	if err := ds.EnsureRelationObjects(ctx, tx, req, s.refIntegrity); err != nil {
+		return err
+	}
+
 	etag := rel.Hash()
 
-	updReq, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, rel.ObjKey(), req)
+	// the expiration time of the import stream applies to the relations set by the stream.
+	expiresAt, err := relationExpiry(ctx)
 	if err != nil {
 		return err
 	}
 
-	if etag == updReq.GetEtag() {
-		s.logger.Trace().Bytes("key", rel.ObjKey()).Str("etag-equal", etag).Msg("ImportRelation")
-		return nil
+	updReq, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, rel.ObjKey(tx), req)
+	if err != nil {
+		return err
 	}
 
-	updReq.Etag = etag
+	expiryChanged, err := ds.SetRelationExpiry(tx, req, expiresAt)
+	if err != nil {
+		return err
+	}
 
-	if _, err := bdb.Set[dsc3.Relation](ctx, tx, bdb.RelationsObjPath, ds.Relation(updReq).ObjKey(), updReq); err != nil {
-		return derr.ErrInvalidRelation.Msg("set")
+	if etag == updReq.GetEtag() && !expiryChanged {
+		s.logger.Trace().Bytes("key", rel.ObjKey(tx)).Str("etag-equal", etag).Msg("ImportRelation")
+		return nil
 	}
 
-	if _, err := bdb.Set[dsc3.Relation](ctx, tx, bdb.RelationsSubPath, ds.Relation(updReq).SubKey(), updReq); err != nil {
+	updReq.Etag = etag
+
+	if _, err := ds.SetRelation(ctx, tx, updReq); err != nil {
 		return derr.ErrInvalidRelation.Msg("set")
 	}
 
 	return nil
 }
 
-func (s *Importer) relationDeleteHandler(ctx context.Context, tx *bolt.Tx, req *dsc3.Relation) error {
+func (s *Importer) relationDeleteHandler(ctx context.Context, tx bdb.Tx, req *dsc3.Relation) error {
 	s.logger.Debug().Interface("relation", req).Msg("ImportRelation")
 
 	if req == nil {
@@ -320,11 +332,7 @@ func (s *Importer) relationDeleteHandler(ctx context.Context, tx *bolt.Tx, req *
 		return modelValidateError(err)
 	}
 
-	if err := bdb.Delete(ctx, tx, bdb.RelationsObjPath, rel.ObjKey()); err != nil {
-		return derr.ErrInvalidRelation.Msg("delete")
-	}
-
-	if err := bdb.Delete(ctx, tx, bdb.RelationsSubPath, rel.SubKey()); err != nil {
+	if err := ds.DeleteRelation(ctx, tx, req); err != nil {
 		return derr.ErrInvalidRelation.Msg("delete")
 	}
 
@@ -0,0 +1,146 @@
+package v3
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+)
+
+// listOptions, returns the sort order and time windows passed in the incoming request metadata.
+func listOptions(ctx context.Context) (*ds.ListOptions, error) {
+	md := metautils.ExtractIncoming(ctx)
+
+	sortBy := md.Get(x.SortByHeader)
+	descending := strings.HasPrefix(sortBy, "-")
+
+	var created, updated ds.TimeRange
+
+	for header, t := range map[string]*time.Time{
+		x.CreatedAfterHeader:  &created.After,
+		x.CreatedBeforeHeader: &created.Before,
+		x.UpdatedAfterHeader:  &updated.After,
+		x.UpdatedBeforeHeader: &updated.Before,
+	} {
+		v := md.Get(header)
+		if v == "" {
+			continue
+		}
+
+		ts, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, ds.ErrInvalidListOptions.Msgf("%s: %q", header, v)
+		}
+
+		*t = ts
+	}
+
+	return ds.NewListOptions(strings.TrimPrefix(sortBy, "-"), descending, created, updated)
+}
+
+// getObjectsOrdered, gets a page of object instances in timestamp order, by scanning the timestamp index of the sort field.
+func (*Reader) getObjectsOrdered(
+	ctx context.Context,
+	tx bdb.Tx,
+	req *dsr3.GetObjectsRequest,
+	opts *ds.ListOptions,
+	resp *dsr3.GetObjectsResponse,
+) error {
+	var typeFilter []byte
+	if req.GetObjectType() != "" {
+		typeFilter = ds.ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: req.GetObjectType()}).Key(tx)
+	}
+
+	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, opts.ObjectsIndex(), append(
+		append(objectsQuery(req, opts), bdb.WithPageToken(req.GetPage().GetToken())),
+		opts.ScanOptions()...,
+	)...)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		if !bytes.HasPrefix(iter.RawValue(), typeFilter) {
+			continue
+		}
+
+		if len(resp.GetResults()) == int(req.GetPage().GetSize()) {
+			resp.Page.NextToken = iter.Token()
+			break
+		}
+
+		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, iter.RawValue())
+		if err != nil {
+			return err
+		}
+
+		if opts.Match(obj) {
+			resp.Results = append(resp.GetResults(), obj)
+		}
+	}
+
+	return nil
+}
+
+// getRelationsOrdered, gets a page of relation instances in timestamp order, by scanning the timestamp index of the sort field.
+func (s *Reader) getRelationsOrdered(
+	ctx context.Context,
+	tx bdb.Tx,
+	req *dsr3.GetRelationsRequest,
+	opts *ds.ListOptions,
+	resp *dsr3.GetRelationsResponse,
+) error {
+	getRelations := ds.GetRelations(req)
+
+	keyFilter := ds.RelationIdentifierBuffer()
+	defer ds.ReturnRelationIdentifierBuffer(keyFilter)
+
+	_, valueFilter := getRelations.RelationValueFilter(tx, keyFilter)
+
+	keyMatch := getRelations.KeyMatch(tx, bdb.RelationsObjPath)
+	if keyMatch == nil {
+		keyMatch = func(_ []byte) bool { return true }
+	}
+
+	iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, opts.RelationsIndex(), append(
+		append(relationsQuery(req, opts), bdb.WithPageToken(req.GetPage().GetToken())),
+		opts.ScanOptions()...,
+	)...)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		if !keyMatch(iter.RawValue()) {
+			continue
+		}
+
+		if len(resp.GetResults()) == int(req.GetPage().GetSize()) {
+			resp.Page.NextToken = iter.Token()
+			break
+		}
+
+		rel, err := bdb.Get[dsc3.Relation](ctx, tx, bdb.RelationsObjPath, iter.RawValue())
+		if err != nil {
+			return err
+		}
+
+		if valueFilter(rel) && opts.Match(rel) {
+			resp.Results = append(resp.GetResults(), rel)
+		}
+	}
+
+	if req.GetWithObjects() {
+		resp.Objects = s.getWithObjects(ctx, tx, resp.GetResults())
+	}
+
+	return nil
+}
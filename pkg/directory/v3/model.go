@@ -23,7 +23,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -64,7 +63,7 @@ func (s *Model) GetManifest(req *dsm3.GetManifestRequest, stream dsm3.Model_GetM
 
 	md := &dsm3.Metadata{UpdatedAt: timestamppb.Now(), Etag: ""}
 
-	modelErr := s.store.DB().View(func(tx *bolt.Tx) error {
+	modelErr := s.store.DB().View(func(tx bdb.Tx) error {
 		manifest, err := ds.Manifest(md).Get(stream.Context(), tx)
 
 		switch {
@@ -177,12 +176,16 @@ func (s *Model) SetManifest(stream dsm3.Model_SetManifestServer) error {
 		return derr.ErrInvalidArgument.Msg(err.Error())
 	}
 
-	if err := s.store.DB().Update(func(tx *bolt.Tx) error {
+	var rev revision
+
+	if err := s.store.DB().Update(withRevision(&rev, func(tx bdb.Tx) error {
 		return s.setManifest(stream, tx, m, md, data)
-	}); err != nil {
+	})); err != nil {
 		return err
 	}
 
+	stream.SetTrailer(revisionMD(rev))
+
 	logger.Info().Msg("manifest updated")
 
 	return s.store.MC().UpdateModel(m)
@@ -210,7 +213,7 @@ func (s *Model) DeleteManifest(ctx context.Context, req *dsm3.DeleteManifestRequ
 		return resp, derr.ErrInvalidArgument.Msg(err.Error())
 	}
 
-	if err := s.store.DB().Update(func(tx *bolt.Tx) error {
+	if err := update(ctx, s.store, func(tx bdb.Tx) error {
 		// optimistic concurrency check
 		ifMatchHeader := metautils.ExtractIncoming(ctx).Get(headers.IfMatch)
 		if ifMatchHeader != "" {
@@ -245,7 +248,7 @@ func (s *Model) DeleteManifest(ctx context.Context, req *dsm3.DeleteManifestRequ
 	return &dsm3.DeleteManifestResponse{Result: &emptypb.Empty{}}, nil
 }
 
-func (*Model) getModel(stream dsm3.Model_GetManifestServer, tx *bolt.Tx, md *dsm3.Metadata) error {
+func (*Model) getModel(stream dsm3.Model_GetManifestServer, tx bdb.Tx, md *dsm3.Metadata) error {
 	model, err := ds.Manifest(md).GetModel(stream.Context(), tx)
 
 	switch {
@@ -277,7 +280,7 @@ func (*Model) getModel(stream dsm3.Model_GetManifestServer, tx *bolt.Tx, md *dsm
 	return nil
 }
 
-func (s *Model) setManifest(stream dsm3.Model_SetManifestServer, tx *bolt.Tx, m *azmModel.Model, md *dsm3.Metadata, data *bytes.Buffer) error {
+func (s *Model) setManifest(stream dsm3.Model_SetManifestServer, tx bdb.Tx, m *azmModel.Model, md *dsm3.Metadata, data *bytes.Buffer) error {
 	stats, err := ds.CalculateStats(stream.Context(), tx)
 	if err != nil {
 		return derr.ErrUnknown.Msgf("failed to calculate stats: %s", err.Error())
@@ -0,0 +1,83 @@
+package v3
+
+import (
+	"context"
+	"strings"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+)
+
+// propertyFilter, property name and value of the property filter passed in the incoming request metadata.
+type propertyFilter struct {
+	name  string
+	value string
+}
+
+// getPropertyFilter, returns the property filter passed in the incoming request metadata, or nil when absent.
+func getPropertyFilter(ctx context.Context, req *dsr3.GetObjectsRequest, opts *ds.ListOptions) (*propertyFilter, error) {
+	v := metautils.ExtractIncoming(ctx).Get(x.PropertyHeader)
+	if v == "" {
+		return nil, nil
+	}
+
+	name, value, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return nil, ds.ErrInvalidPropertyFilter.Msgf("%s: %q", x.PropertyHeader, v)
+	}
+
+	if req.GetObjectType() == "" {
+		return nil, ds.ErrInvalidPropertyFilter.Msg("object type not set")
+	}
+
+	if opts.IsOrdered() {
+		return nil, ds.ErrInvalidPropertyFilter.Msg("property filter can not be combined with sort order or time range")
+	}
+
+	return &propertyFilter{name: name, value: value}, nil
+}
+
+// getObjectsByProperty, gets a page of object instances of the object type with the property value,
+// by scanning the property index.
+func (*Reader) getObjectsByProperty(
+	ctx context.Context,
+	tx bdb.Tx,
+	req *dsr3.GetObjectsRequest,
+	filter *propertyFilter,
+	resp *dsr3.GetObjectsResponse,
+) error {
+	keyFilter, err := ds.PropertyKeyFilter(tx, req.GetObjectType(), filter.name, filter.value)
+	if err != nil {
+		return err
+	}
+
+	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectPropertiesPath,
+		bdb.WithFingerprint(req.GetObjectType(), filter.name, filter.value),
+		bdb.WithPageToken(req.GetPage().GetToken()),
+		bdb.WithKeyFilter(keyFilter),
+	)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		if len(resp.GetResults()) == int(req.GetPage().GetSize()) {
+			resp.Page.NextToken = iter.Token()
+			break
+		}
+
+		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, iter.RawValue())
+		if err != nil {
+			return err
+		}
+
+		resp.Results = append(resp.GetResults(), obj)
+	}
+
+	return nil
+}
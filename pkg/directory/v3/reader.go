@@ -2,6 +2,8 @@ package v3
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
@@ -14,7 +16,6 @@ import (
 	"github.com/go-http-utils/headers"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc"
 	grpcmd "google.golang.org/grpc/metadata"
 )
@@ -22,17 +23,25 @@ import (
 type Reader struct {
 	dsr3.UnimplementedReaderServer
 
-	logger *zerolog.Logger
-	store  *bdb.BoltDB
+	logger    *zerolog.Logger
+	store     *bdb.BoltDB
+	decisions *ds.DecisionCache
 }
 
-func NewReader(logger *zerolog.Logger, store *bdb.BoltDB) *Reader {
+// NewReader, returns a reader, check decisions are cached in the decision cache when not nil.
+func NewReader(logger *zerolog.Logger, store *bdb.BoltDB, decisions *ds.DecisionCache) *Reader {
 	return &Reader{
-		logger: logger,
-		store:  store,
+		logger:    logger,
+		store:     store,
+		decisions: decisions,
 	}
 }
 
+// DecisionCacheStats, returns the decision cache counters.
+func (s *Reader) DecisionCacheStats() ds.DecisionCacheStats {
+	return s.decisions.Stats()
+}
+
 // GetObject, get single object instance.
 func (s *Reader) GetObject(ctx context.Context, req *dsr3.GetObjectRequest) (*dsr3.GetObjectResponse, error) {
 	resp := &dsr3.GetObjectResponse{}
@@ -46,8 +55,12 @@ func (s *Reader) GetObject(ctx context.Context, req *dsr3.GetObjectRequest) (*ds
 		return resp, err
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
-		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, objIdent.Key())
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, objIdent.Key(tx))
 		if err != nil {
 			return err
 		}
@@ -63,7 +76,7 @@ func (s *Reader) GetObject(ctx context.Context, req *dsr3.GetObjectRequest) (*ds
 		if req.GetWithRelations() {
 			// incoming object relations of object instance
 			// (result.type == incoming.subject.type && result.key == incoming.subject.key)
-			incoming, err := bdb.Scan[dsc3.Relation](ctx, tx, bdb.RelationsSubPath, ds.Object(obj).Key())
+			incoming, err := bdb.Scan[dsc3.Relation](ctx, tx, bdb.RelationsSubPath, ds.Object(obj).Key(tx))
 			if err != nil {
 				return err
 			}
@@ -72,7 +85,7 @@ func (s *Reader) GetObject(ctx context.Context, req *dsr3.GetObjectRequest) (*ds
 
 			// outgoing object relations of object instance
 			// (result.type == outgoing.object.type && result.key == outgoing.object.key)
-			outgoing, err := bdb.Scan[dsc3.Relation](ctx, tx, bdb.RelationsObjPath, ds.Object(obj).Key())
+			outgoing, err := bdb.Scan[dsc3.Relation](ctx, tx, bdb.RelationsObjPath, ds.Object(obj).Key(tx))
 			if err != nil {
 				return err
 			}
@@ -107,9 +120,13 @@ func (s *Reader) GetObjectMany(ctx context.Context, req *dsr3.GetObjectManyReque
 		}
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
 		for _, i := range req.GetParam() {
-			obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.ObjectIdentifier(i).Key())
+			obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.ObjectIdentifier(i).Key(tx))
 			if err != nil {
 				return err
 			}
@@ -124,6 +141,7 @@ func (s *Reader) GetObjectMany(ctx context.Context, req *dsr3.GetObjectManyReque
 }
 
 // GetObjects, gets (all) object instances, optionally filtered by object type, as a paginated array of objects.
+// Without ordering options the objects are listed in key order, object ids by length first, then lexically (see ds key encoding).
 func (s *Reader) GetObjects(ctx context.Context, req *dsr3.GetObjectsRequest) (*dsr3.GetObjectsResponse, error) {
 	resp := &dsr3.GetObjectsResponse{Results: []*dsc3.Object{}, Page: &dsc3.PaginationResponse{}}
 
@@ -135,21 +153,55 @@ func (s *Reader) GetObjects(ctx context.Context, req *dsr3.GetObjectsRequest) (*
 		req.Page = &dsc3.PaginationRequest{Size: x.MaxPageSize}
 	}
 
-	opts := []bdb.ScanOption{
+	opts := append(objectsQuery(req, nil),
 		bdb.WithPageSize(req.GetPage().GetSize()),
 		bdb.WithPageToken(req.GetPage().GetToken()),
-	}
+	)
+
+	oid := ds.ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: req.GetObjectType()})
 
 	if req.GetObjectType() != "" {
-		oid := ds.ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: req.GetObjectType()})
 		if err := ds.ObjectSelector(oid.ObjectIdentifier).Validate(s.store.MC()); err != nil {
 			return resp, err
 		}
+	}
 
-		opts = append(opts, bdb.WithKeyFilter(oid.Key()))
+	listOpts, err := listOptions(ctx)
+	if err != nil {
+		return resp, err
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
+	propFilter, err := getPropertyFilter(ctx, req, listOpts)
+	if err != nil {
+		return resp, err
+	}
+
+	search, err := getSearch(ctx, req, listOpts, propFilter)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
+
+	err = s.store.DB().View(func(tx bdb.Tx) error {
+		if search != nil {
+			return s.getObjectsBySearch(ctx, tx, req, search, resp)
+		}
+
+		if propFilter != nil {
+			return s.getObjectsByProperty(ctx, tx, req, propFilter, resp)
+		}
+
+		if listOpts.IsOrdered() {
+			return s.getObjectsOrdered(ctx, tx, req, listOpts, resp)
+		}
+
+		if req.GetObjectType() != "" {
+			opts = append(opts, bdb.WithKeyFilter(oid.Key(tx)))
+		}
+
 		iter, err := bdb.NewPageIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath, opts...)
 		if err != nil {
 			return err
@@ -185,12 +237,16 @@ func (s *Reader) GetRelation(ctx context.Context, req *dsr3.GetRelationRequest)
 	filter := ds.RelationIdentifierBuffer()
 	defer ds.ReturnRelationIdentifierBuffer(filter)
 
-	path, err := getRelation.PathAndFilter(filter)
-	if err != nil {
+	if err := atLeastRevision(ctx, s.store); err != nil {
 		return resp, err
 	}
 
-	err = s.store.DB().View(func(tx *bolt.Tx) error {
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		path, err := getRelation.PathAndFilter(tx, filter)
+		if err != nil {
+			return err
+		}
+
 		relations, err := bdb.Scan[dsc3.Relation](ctx, tx, path, filter.Bytes())
 		if err != nil {
 			return err
@@ -205,6 +261,18 @@ func (s *Reader) GetRelation(ctx context.Context, req *dsr3.GetRelationRequest)
 		}
 
 		dbRel := relations[0]
+
+		// an expired relation, which is not deleted by the reaper yet, is not found, like it is ignored by checks.
+		if expiresAt, ok := ds.RelationExpiry(tx, dbRel); ok {
+			if !expiresAt.After(time.Now()) {
+				return bdb.ErrKeyNotFound
+			}
+
+			if err := grpc.SetHeader(ctx, grpcmd.Pairs(x.RelationExpiresAtHeader, expiresAt.Format(time.RFC3339Nano))); err != nil {
+				return err
+			}
+		}
+
 		resp.Result = dbRel
 
 		inMD, _ := grpcmd.FromIncomingContext(ctx)
@@ -226,6 +294,7 @@ func (s *Reader) GetRelation(ctx context.Context, req *dsr3.GetRelationRequest)
 }
 
 // GetRelations, gets paginated set of relation instances based on subject, relation, object filter.
+// Without ordering options the relations are listed in key order, object ids by length first, then lexically (see ds key encoding).
 func (s *Reader) GetRelations(ctx context.Context, req *dsr3.GetRelationsRequest) (*dsr3.GetRelationsResponse, error) {
 	resp := &dsr3.GetRelationsResponse{
 		Results: []*dsc3.Relation{},
@@ -246,18 +315,29 @@ func (s *Reader) GetRelations(ctx context.Context, req *dsr3.GetRelationsRequest
 		return resp, err
 	}
 
+	listOpts, err := listOptions(ctx)
+	if err != nil {
+		return resp, err
+	}
+
 	keyFilter := ds.RelationIdentifierBuffer()
 	defer ds.ReturnRelationIdentifierBuffer(keyFilter)
 
-	path, valueFilter := getRelations.RelationValueFilter(keyFilter)
-
-	opts := []bdb.ScanOption{
-		bdb.WithPageToken(req.GetPage().GetToken()),
-		bdb.WithKeyFilter(keyFilter.Bytes()),
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
-		iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, path, opts...)
+	err = s.store.DB().View(func(tx bdb.Tx) error {
+		if listOpts.IsOrdered() {
+			return s.getRelationsOrdered(ctx, tx, req, listOpts, resp)
+		}
+
+		path, valueFilter := getRelations.RelationValueFilter(tx, keyFilter)
+
+		iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, path, append(relationsQuery(req, nil),
+			bdb.WithPageToken(req.GetPage().GetToken()),
+			bdb.WithKeyFilter(keyFilter.Bytes()),
+		)...)
 		if err != nil {
 			return err
 		}
@@ -271,7 +351,7 @@ func (s *Reader) GetRelations(ctx context.Context, req *dsr3.GetRelationsRequest
 
 			if int64(req.GetPage().GetSize()) == int64(len(resp.GetResults())) {
 				if iter.Next() {
-					resp.Page.NextToken = iter.Key()
+					resp.Page.NextToken = iter.Token()
 				}
 
 				break
@@ -313,14 +393,38 @@ func (s *Reader) Check(ctx context.Context, req *dsr3.CheckRequest) (*dsr3.Check
 		return resp, nil
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
-		var err error
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
 
-		resp, err = check.Exec(ctx, tx, s.store.MC())
+	ctx, err := withContextualRelations(ctx, s.store.MC())
+	if err != nil {
+		return resp, err
+	}
+
+	ctx, err = withSubjectAlias(ctx)
+	if err != nil {
+		return resp, err
+	}
+
+	var aliasErr error
+
+	err = s.store.DB().View(func(tx bdb.Tx) error {
+		resolved, err := resolveSubjectAlias(ctx, tx, req)
+		if err != nil {
+			aliasErr = err
+			return err
+		}
+
+		resp, err = ds.Check(resolved).Exec(ctx, tx, s.store.MC(), s.decisions)
 
 		return err
 	})
-	if err != nil {
+
+	switch {
+	case aliasErr != nil:
+		return resp, aliasErr
+	case err != nil:
 		resp.Context = ds.SetContextWithReason(err)
 	}
 
@@ -336,10 +440,19 @@ func (s *Reader) Checks(ctx context.Context, req *dsr3.ChecksRequest) (*dsr3.Che
 		return resp, err
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
+
+	ctx, err := withContextualRelations(ctx, s.store.MC())
+	if err != nil {
+		return resp, err
+	}
+
+	err = s.store.DB().View(func(tx bdb.Tx) error {
 		var err error
 
-		resp, err = checks.Exec(ctx, tx, s.store.MC())
+		resp, err = checks.Exec(ctx, tx, s.store.MC(), s.decisions)
 
 		return err
 	})
@@ -364,19 +477,36 @@ func (s *Reader) CheckPermission(ctx context.Context, req *dsr3.CheckPermissionR
 		return resp, err
 	}
 
-	check := ds.Check(&dsr3.CheckRequest{
+	checkReq := &dsr3.CheckRequest{
 		ObjectType:  req.GetObjectType(),
 		ObjectId:    req.GetObjectId(),
 		Relation:    req.GetPermission(),
 		SubjectType: req.GetSubjectType(),
 		SubjectId:   req.GetSubjectId(),
 		Trace:       req.GetTrace(),
-	})
+	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
-		var err error
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
 
-		r, err := check.Exec(ctx, tx, s.store.MC())
+	ctx, err := withContextualRelations(ctx, s.store.MC())
+	if err != nil {
+		return resp, err
+	}
+
+	ctx, err = withSubjectAlias(ctx)
+	if err != nil {
+		return resp, err
+	}
+
+	err = s.store.DB().View(func(tx bdb.Tx) error {
+		resolved, err := resolveSubjectAlias(ctx, tx, checkReq)
+		if err != nil {
+			return err
+		}
+
+		r, err := ds.Check(resolved).Exec(ctx, tx, s.store.MC(), s.decisions)
 		if err == nil {
 			resp.Check = r.GetCheck()
 			resp.Trace = r.GetTrace()
@@ -411,10 +541,19 @@ func (s *Reader) CheckRelation(ctx context.Context, req *dsr3.CheckRelationReque
 		Trace:       req.GetTrace(),
 	})
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
+
+	ctx, err := withContextualRelations(ctx, s.store.MC())
+	if err != nil {
+		return resp, err
+	}
+
+	err = s.store.DB().View(func(tx bdb.Tx) error {
 		var err error
 
-		r, err := check.Exec(ctx, tx, s.store.MC())
+		r, err := check.Exec(ctx, tx, s.store.MC(), s.decisions)
 		if err == nil {
 			resp.Check = r.GetCheck()
 			resp.Trace = r.GetTrace()
@@ -439,7 +578,11 @@ func (s *Reader) GetGraph(ctx context.Context, req *dsr3.GetGraphRequest) (*dsr3
 		return resp, err
 	}
 
-	err := s.store.DB().View(func(tx *bolt.Tx) error {
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return resp, err
+	}
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
 		var err error
 
 		results, err := getGraph.Exec(ctx, tx, s.store.MC())
@@ -455,20 +598,20 @@ func (s *Reader) GetGraph(ctx context.Context, req *dsr3.GetGraphRequest) (*dsr3
 	return resp, err
 }
 
-func (*Reader) getWithObjects(ctx context.Context, tx *bolt.Tx, relations []*dsc3.Relation) map[string]*dsc3.Object {
+func (*Reader) getWithObjects(ctx context.Context, tx bdb.Tx, relations []*dsc3.Relation) map[string]*dsc3.Object {
 	objects := map[string]*dsc3.Object{}
 
 	for _, r := range relations {
 		rel := ds.Relation(r)
 
-		sub, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.ObjectIdentifier(rel.Subject()).Key())
+		sub, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.ObjectIdentifier(rel.Subject()).Key(tx))
 		if err != nil {
 			sub = &dsc3.Object{Type: rel.SubjectType, Id: rel.SubjectId}
 		}
 
 		objects[ds.Object(sub).StrKey()] = sub
 
-		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.ObjectIdentifier(rel.Object()).Key())
+		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, ds.ObjectIdentifier(rel.Object()).Key(tx))
 		if err != nil {
 			obj = &dsc3.Object{Type: rel.ObjectType, Id: rel.ObjectId}
 		}
@@ -478,3 +621,27 @@ func (*Reader) getWithObjects(ctx context.Context, tx *bolt.Tx, relations []*dsc
 
 	return objects
 }
+
+// objectsQuery, page token scan options of the get objects query filter and list options.
+func objectsQuery(req *dsr3.GetObjectsRequest, opts *ds.ListOptions) []bdb.ScanOption {
+	return []bdb.ScanOption{
+		bdb.WithFingerprint(append([]string{req.GetObjectType()}, opts.Fingerprint()...)...),
+		bdb.WithKeyTranslator(ds.TranslateKey),
+	}
+}
+
+// relationsQuery, page token scan options of the get relations query filter and list options.
+func relationsQuery(req *dsr3.GetRelationsRequest, opts *ds.ListOptions) []bdb.ScanOption {
+	return []bdb.ScanOption{
+		bdb.WithFingerprint(append([]string{
+			req.GetObjectType(),
+			req.GetObjectId(),
+			req.GetRelation(),
+			req.GetSubjectType(),
+			req.GetSubjectId(),
+			req.GetSubjectRelation(),
+			strconv.FormatBool(req.GetWithEmptySubjectRelation()),
+		}, opts.Fingerprint()...)...),
+		bdb.WithKeyTranslator(ds.TranslateKey),
+	}
+}
@@ -0,0 +1,121 @@
+package v3
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
+)
+
+// revision, store revision observed by a transaction, and the id of the store the revision originates from.
+type revision struct {
+	value  uint64
+	origin string
+}
+
+// revisionMD, returns the revision and its origin as outgoing metadata.
+func revisionMD(rev revision) grpcmd.MD {
+	return grpcmd.Pairs(x.RevisionHeader, strconv.FormatUint(rev.value, 10), x.RevisionOriginHeader, rev.origin)
+}
+
+// withRevision, wraps the transaction function, on success rev contains the store revision observed by the transaction.
+func withRevision(rev *revision, fn func(bdb.Tx) error) func(bdb.Tx) error {
+	return func(tx bdb.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		*rev = revision{value: ds.Revision(tx), origin: ds.StoreID(tx)}
+
+		return nil
+	}
+}
+
+// update, executes the unary write in a read-write transaction,
+// and returns the store revision observed by the write and its origin as response headers.
+func update(ctx context.Context, store *bdb.BoltDB, fn func(bdb.Tx) error) error {
+	var rev revision
+
+	if err := store.DB().Update(withRevision(&rev, fn)); err != nil {
+		return err
+	}
+
+	_ = grpc.SetHeader(ctx, revisionMD(rev))
+
+	return nil
+}
+
+// atLeastRevision, enforces the "at least as fresh as" consistency token passed in the incoming request metadata.
+//
+// Revisions are local to the store they originate from, the token is compared against the store revision when
+// the origin header is absent or identifies the store, and against the last upstream revision applied by sync when
+// the origin header identifies the upstream directory, tokens of any other origin are rejected with
+// ds.ErrUnknownRevisionOrigin.
+// When the store has not reached the requested revision, the call either fails fast with ds.ErrRevisionNotReached,
+// or, when a revision timeout is passed, waits until the revision is reached, the timeout expires or the context is done.
+func atLeastRevision(ctx context.Context, store *bdb.BoltDB) error {
+	md := metautils.ExtractIncoming(ctx)
+
+	token := md.Get(x.AtLeastRevisionHeader)
+	if token == "" {
+		return nil
+	}
+
+	origin := md.Get(x.RevisionOriginHeader)
+
+	want, err := strconv.ParseUint(token, 10, 64)
+	if err != nil {
+		return ds.ErrInvalidRevision.Msgf("%s: %q", x.AtLeastRevisionHeader, token)
+	}
+
+	var timeout time.Duration
+
+	if v := md.Get(x.RevisionTimeoutHeader); v != "" {
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return ds.ErrInvalidRevision.Msgf("%s: %q", x.RevisionTimeoutHeader, v)
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		// obtain the change signal before reading, so commits during the read are not missed.
+		changed := store.Changed()
+
+		var rev uint64
+
+		if err := store.DB().View(func(tx bdb.Tx) error {
+			var err error
+
+			rev, err = ds.OriginRevision(tx, origin)
+
+			return err
+		}); err != nil {
+			return err
+		}
+
+		if rev >= want {
+			return nil
+		}
+
+		if timeout <= 0 {
+			return ds.ErrRevisionNotReached.Msgf("requested %d, current %d", want, rev)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return ds.ErrRevisionNotReached.Msgf("requested %d, current %d, timeout %s", want, rev, timeout)
+		case <-changed:
+		}
+	}
+}
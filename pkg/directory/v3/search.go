@@ -0,0 +1,65 @@
+package v3
+
+import (
+	"context"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+)
+
+// getSearch, returns the full-text search passed in the incoming request metadata, or nil when absent.
+func getSearch(ctx context.Context, req *dsr3.GetObjectsRequest, opts *ds.ListOptions, filter *propertyFilter) (*ds.Search, error) {
+	md := metautils.ExtractIncoming(ctx)
+
+	query := md.Get(x.SearchHeader)
+	if query == "" {
+		return nil, nil
+	}
+
+	if opts.IsOrdered() || filter != nil {
+		return nil, ds.ErrInvalidSearch.Msg("search can not be combined with sort order, time range or property filter")
+	}
+
+	return ds.NewSearch(req.GetObjectType(), query, md.Get(x.SearchModeHeader))
+}
+
+// getObjectsBySearch, gets a page of object instances matching the full-text search, by scanning the search index.
+func (*Reader) getObjectsBySearch(
+	ctx context.Context,
+	tx bdb.Tx,
+	req *dsr3.GetObjectsRequest,
+	search *ds.Search,
+	resp *dsr3.GetObjectsResponse,
+) error {
+	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsSearchPath,
+		bdb.WithFingerprint(search.Fingerprint()...),
+		bdb.WithPageToken(req.GetPage().GetToken()),
+		bdb.WithKeyFilter(search.KeyFilter()),
+	)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		if len(resp.GetResults()) == int(req.GetPage().GetSize()) {
+			resp.Page.NextToken = iter.Token()
+			break
+		}
+
+		obj, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, iter.RawValue())
+		if err != nil {
+			return err
+		}
+
+		if search.Match(tx, iter.RawKey(), obj) {
+			resp.Results = append(resp.GetResults(), obj)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package v3
+
+import (
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-directory/pkg/validator"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/streamer"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+)
+
+var _ streamer.StreamerServer = (*Reader)(nil)
+
+// StreamObjects, streams all object instances, optionally filtered by object type, in batches of the requested page size,
+// until the objects are exhausted or the stream context is done.
+//
+// Each batch is read in its own read transaction, which is released before the batch is sent, the next batch is read
+// starting at the page token of the sent batch. The page token of the request is used as start position.
+func (s *Reader) StreamObjects(req *dsr3.GetObjectsRequest, stream streamer.Streamer_StreamObjectsServer) error {
+	ctx := stream.Context()
+
+	if err := validator.GetObjectsRequest(req); err != nil {
+		return err
+	}
+
+	oid := ds.ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: req.GetObjectType()})
+
+	if req.GetObjectType() != "" {
+		if err := ds.ObjectSelector(oid.ObjectIdentifier).Validate(s.store.MC()); err != nil {
+			return err
+		}
+	}
+
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return err
+	}
+
+	batchSize := streamBatchSize(req.GetPage())
+	token := req.GetPage().GetToken()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		resp := &dsr3.GetObjectsResponse{Results: make([]*dsc3.Object, 0, batchSize), Page: &dsc3.PaginationResponse{}}
+
+		if err := s.store.DB().View(func(tx bdb.Tx) error {
+			opts := append(objectsQuery(req, nil), bdb.WithPageToken(token))
+
+			if req.GetObjectType() != "" {
+				opts = append(opts, bdb.WithKeyFilter(oid.Key(tx)))
+			}
+
+			iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath, opts...)
+			if err != nil {
+				return err
+			}
+
+			for len(resp.GetResults()) < batchSize && iter.Next() {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				resp.Results = append(resp.GetResults(), iter.Value())
+			}
+
+			if iter.Next() {
+				resp.Page.NextToken = iter.Token()
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if len(resp.GetResults()) == 0 {
+			return nil
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+
+		if resp.GetPage().GetNextToken() == "" {
+			return nil
+		}
+
+		token = resp.GetPage().GetNextToken()
+	}
+}
+
+// StreamRelations, streams all relation instances matching the subject, relation, object filter, in batches of the
+// requested page size, until the relations are exhausted or the stream context is done. When with_objects is set,
+// each batch contains the objects of the relations of the batch.
+//
+// Each batch is read in its own read transaction, which is released before the batch is sent, the next batch is read
+// starting at the page token of the sent batch. The page token of the request is used as start position.
+func (s *Reader) StreamRelations(req *dsr3.GetRelationsRequest, stream streamer.Streamer_StreamRelationsServer) error {
+	ctx := stream.Context()
+
+	if err := validator.GetRelationsRequest(req); err != nil {
+		return err
+	}
+
+	getRelations := ds.GetRelations(req)
+	if err := getRelations.Validate(s.store.MC()); err != nil {
+		return err
+	}
+
+	if err := atLeastRevision(ctx, s.store); err != nil {
+		return err
+	}
+
+	batchSize := streamBatchSize(req.GetPage())
+	token := req.GetPage().GetToken()
+
+	keyFilter := ds.RelationIdentifierBuffer()
+	defer ds.ReturnRelationIdentifierBuffer(keyFilter)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		resp := &dsr3.GetRelationsResponse{
+			Results: make([]*dsc3.Relation, 0, batchSize),
+			Objects: map[string]*dsc3.Object{},
+			Page:    &dsc3.PaginationResponse{},
+		}
+
+		if err := s.store.DB().View(func(tx bdb.Tx) error {
+			keyFilter.Reset()
+
+			path, valueFilter := getRelations.RelationValueFilter(tx, keyFilter)
+
+			iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, path, append(relationsQuery(req, nil),
+				bdb.WithPageToken(token),
+				bdb.WithKeyFilter(keyFilter.Bytes()),
+			)...)
+			if err != nil {
+				return err
+			}
+
+			// the value filter can reject most relations of the scan, the stream context is checked for each relation.
+			for len(resp.GetResults()) < batchSize && iter.Next() {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				if rel := iter.Value(); valueFilter(rel) {
+					resp.Results = append(resp.GetResults(), rel)
+				}
+			}
+
+			if iter.Next() {
+				resp.Page.NextToken = iter.Token()
+			}
+
+			if req.GetWithObjects() {
+				resp.Objects = s.getWithObjects(ctx, tx, resp.GetResults())
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if len(resp.GetResults()) != 0 {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+
+		if resp.GetPage().GetNextToken() == "" {
+			return nil
+		}
+
+		token = resp.GetPage().GetNextToken()
+	}
+}
+
+// PreviewDeleteObject, streams the relations which are deleted with the object instance by DeleteObject
+// (with_relations), in batches of x.MaxPageSize relations, after checking the preconditions of the delete.
+//
+// Each batch is read in its own read transaction, which is released before the batch is sent, relations
+// changed while streaming are streamed in either state.
+func (s *Reader) PreviewDeleteObject(req *dsw3.DeleteObjectRequest, stream streamer.Streamer_PreviewDeleteObjectServer) error {
+	ctx := stream.Context()
+
+	if err := validator.DeleteObjectRequest(req); err != nil {
+		return err
+	}
+
+	oid := &dsc3.ObjectIdentifier{ObjectType: req.GetObjectType(), ObjectId: req.GetObjectId()}
+
+	if err := ds.ObjectIdentifier(oid).Validate(s.store.MC()); err != nil {
+		return err
+	}
+
+	if err := s.store.DB().View(func(tx bdb.Tx) error {
+		return deleteObjectPreconditions(ctx, tx, req)
+	}); err != nil {
+		return err
+	}
+
+	if !req.GetWithRelations() {
+		return nil
+	}
+
+	for pos := (ds.ObjectRelationsPosition{}); !pos.Done(); {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		resp := &dsr3.GetRelationsResponse{Objects: map[string]*dsc3.Object{}, Page: &dsc3.PaginationResponse{}}
+
+		if err := s.store.DB().View(func(tx bdb.Tx) error {
+			var err error
+
+			resp.Results, pos, err = ds.ObjectRelationsPage(ctx, tx, oid, pos, int(x.MaxPageSize))
+
+			return err
+		}); err != nil {
+			return err
+		}
+
+		if len(resp.GetResults()) == 0 {
+			continue
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// streamBatchSize, returns the number of instances per stream message, the requested page size or x.MaxPageSize.
+func streamBatchSize(page *dsc3.PaginationRequest) int {
+	if page.GetSize() <= 0 || page.GetSize() > x.MaxPageSize {
+		return int(x.MaxPageSize)
+	}
+
+	return int(page.GetSize())
+}
@@ -0,0 +1,174 @@
+package v3
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/watcher"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Watcher, streams the store change feed to subscribers, implements the watcher.Watcher service.
+type Watcher struct {
+	logger *zerolog.Logger
+	store  *bdb.BoltDB
+}
+
+var _ watcher.WatcherServer = (*Watcher)(nil)
+
+func NewWatcher(logger *zerolog.Logger, store *bdb.BoltDB) *Watcher {
+	return &Watcher{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// Revision, returns the current store revision, which can be used as the resume token of a subsequent Watch call.
+func (s *Watcher) Revision(_ context.Context, _ *emptypb.Empty) (*wrapperspb.UInt64Value, error) {
+	rev, err := s.revision()
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapperspb.UInt64(rev), nil
+}
+
+func (s *Watcher) revision() (uint64, error) {
+	var rev uint64
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		rev = ds.Revision(tx)
+		return nil
+	})
+
+	return rev, err
+}
+
+// Watch, streams all changes recorded after the resume token revision, in revision order,
+// and continues streaming new changes as they are committed, until the stream context is done.
+//
+// A resume token of 0 starts streaming from the current store revision.
+// When the changes following the resume token have been garbage collected, ds.ErrRevisionCompacted is returned.
+func (s *Watcher) Watch(req *wrapperspb.UInt64Value, stream watcher.Watcher_WatchServer) error {
+	ctx := stream.Context()
+	logger := s.logger.With().Str("method", "Watch").Uint64("from_revision", req.GetValue()).Logger()
+
+	rev := req.GetValue()
+	if rev == 0 {
+		cur, err := s.revision()
+		if err != nil {
+			return err
+		}
+
+		rev = cur
+	}
+
+	for {
+		// obtain the change signal before reading, so commits during the read are not missed.
+		changed := s.store.Changed()
+
+		// the changes are read in batches, each in its own read transaction, which is released before the batch is sent.
+		for {
+			batch, err := s.changes(ctx, rev)
+			if err != nil {
+				logger.Debug().Err(err).Uint64("revision", rev).Msg("watch")
+				return err
+			}
+
+			for _, change := range batch {
+				msg, err := changeStruct(change)
+				if err != nil {
+					return err
+				}
+
+				if err := stream.Send(msg); err != nil {
+					return err
+				}
+
+				rev = change.Revision
+			}
+
+			if len(batch) < watchBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// watchBatchSize, maximum number of changes read in a single read transaction.
+const watchBatchSize int = int(x.MaxPageSize)
+
+// errBatchFull, stops the change scan once the batch is full.
+var errBatchFull = errors.New("batch full")
+
+// changes, returns the batch of changes recorded after the given revision, up to watchBatchSize changes.
+func (s *Watcher) changes(ctx context.Context, after uint64) ([]*ds.Change, error) {
+	batch := make([]*ds.Change, 0, watchBatchSize)
+
+	err := s.store.DB().View(func(tx bdb.Tx) error {
+		return ds.ScanChanges(ctx, tx, after, func(change *ds.Change) error {
+			if len(batch) == watchBatchSize {
+				return errBatchFull
+			}
+
+			batch = append(batch, change)
+
+			return nil
+		})
+	})
+	if err != nil && !errors.Is(err, errBatchFull) {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// changeStruct, converts the change to the struct sent by the watcher.Watcher service.
+func changeStruct(change *ds.Change) (*structpb.Struct, error) {
+	v := map[string]any{
+		"revision":  strconv.FormatUint(change.Revision, 10),
+		"op":        change.Op.String(),
+		"timestamp": change.Timestamp.Format(time.RFC3339Nano),
+	}
+
+	for name, msg := range map[string]proto.Message{
+		"object":   change.Object,
+		"relation": change.Relation,
+		"manifest": change.Manifest,
+	} {
+		if msg == nil || !msg.ProtoReflect().IsValid() {
+			continue
+		}
+
+		buf, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		var instance structpb.Struct
+		if err := protojson.Unmarshal(buf, &instance); err != nil {
+			return nil, err
+		}
+
+		v[name] = instance.AsMap()
+	}
+
+	return structpb.NewStruct(v)
+}
@@ -2,6 +2,8 @@ package v3
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
@@ -9,25 +11,29 @@ import (
 	"github.com/aserto-dev/go-directory/pkg/validator"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
 
 	"github.com/go-http-utils/headers"
 	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
 	"github.com/rs/zerolog"
-	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type Writer struct {
 	dsw3.UnimplementedWriterServer
 
-	logger *zerolog.Logger
-	store  *bdb.BoltDB
+	logger       *zerolog.Logger
+	store        *bdb.BoltDB
+	refIntegrity ds.ReferentialIntegrity
 }
 
-func NewWriter(logger *zerolog.Logger, store *bdb.BoltDB) *Writer {
+func NewWriter(logger *zerolog.Logger, store *bdb.BoltDB, refIntegrity ds.ReferentialIntegrity) *Writer {
 	return &Writer{
-		logger: logger,
-		store:  store,
+		logger:       logger,
+		store:        store,
+		refIntegrity: refIntegrity,
 	}
 }
 
@@ -47,8 +53,8 @@ func (s *Writer) SetObject(ctx context.Context, req *dsw3.SetObjectRequest) (*ds
 
 	etag := obj.Hash()
 
-	err := s.store.DB().Update(func(tx *bolt.Tx) error {
-		updObj, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, obj.Key(), req.GetObject())
+	err := update(ctx, s.store, func(tx bdb.Tx) error {
+		updObj, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, obj.Key(tx), req.GetObject())
 		if err != nil {
 			return err
 		}
@@ -61,7 +67,7 @@ func (s *Writer) SetObject(ctx context.Context, req *dsw3.SetObjectRequest) (*ds
 		}
 
 		if etag == updObj.GetEtag() {
-			s.logger.Trace().Bytes("key", ds.Object(req.GetObject()).Key()).Str("etag-equal", etag).Msg("set_object")
+			s.logger.Trace().Bytes("key", ds.Object(req.GetObject()).Key(tx)).Str("etag-equal", etag).Msg("set_object")
 
 			resp.Result = updObj
 
@@ -70,7 +76,7 @@ func (s *Writer) SetObject(ctx context.Context, req *dsw3.SetObjectRequest) (*ds
 
 		updObj.Etag = etag
 
-		objType, err := bdb.Set(ctx, tx, bdb.ObjectsPath, obj.Key(), updObj)
+		objType, err := ds.SetObject(ctx, tx, updObj)
 		if err != nil {
 			return err
 		}
@@ -96,37 +102,41 @@ func (s *Writer) DeleteObject(ctx context.Context, req *dsw3.DeleteObjectRequest
 		return resp, err
 	}
 
-	err := s.store.DB().Update(func(tx *bolt.Tx) error {
-		objIdent := ds.ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: req.GetObjectType(), ObjectId: req.GetObjectId()})
+	dryRun, err := deleteDryRun(ctx)
+	if err != nil {
+		return resp, err
+	}
 
-		// optimistic concurrency check
-		ifMatchHeader := metautils.ExtractIncoming(ctx).Get(headers.IfMatch)
-		if ifMatchHeader != "" {
-			obj := &dsc3.Object{Type: req.GetObjectType(), Id: req.GetObjectId()}
+	if dryRun {
+		if err := s.previewDeleteObject(ctx, req, objIdent.ObjectIdentifier); err != nil {
+			return resp, err
+		}
 
-			updObj, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, ds.Object(obj).Key(), obj)
-			if err != nil {
-				return err
-			}
+		resp.Result = &emptypb.Empty{}
 
-			if ifMatchHeader != updObj.GetEtag() {
-				return derr.ErrHashMismatch.Msgf("for object with type [%s] and id [%s]", updObj.GetType(), updObj.GetId())
-			}
+		return resp, nil
+	}
+
+	err = update(ctx, s.store, func(tx bdb.Tx) error {
+		objIdent := ds.ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: req.GetObjectType(), ObjectId: req.GetObjectId()})
+
+		if err := deleteObjectPreconditions(ctx, tx, req); err != nil {
+			return err
 		}
 
-		if err := bdb.Delete(ctx, tx, bdb.ObjectsPath, objIdent.Key()); err != nil {
+		if err := ds.DeleteObject(ctx, tx, objIdent.ObjectIdentifier); err != nil {
 			return err
 		}
 
 		if req.GetWithRelations() {
-			// incoming object relations of object instance (result.type == incoming.subject.type && result.key == incoming.subject.key)
-			if err := s.deleteRelations(ctx, bdb.RelationsSubPath, tx, objIdent.ObjectIdentifier); err != nil {
-				return err
-			}
-			// outgoing object relations of object instance (result.type == outgoing.object.type && result.key == outgoing.object.key)
-			if err := s.deleteRelations(ctx, bdb.RelationsObjPath, tx, objIdent.ObjectIdentifier); err != nil {
+			// outgoing relations of the object instance, and incoming relations with the object instance as subject,
+			// including usersets (subject relation) of the object instance.
+			rels, err := ds.DeleteObjectRelations(ctx, tx, objIdent.ObjectIdentifier)
+			if err != nil {
 				return err
 			}
+
+			s.logger.Trace().Str("type", req.GetObjectType()).Str("id", req.GetObjectId()).Int("relations", len(rels)).Msg("delete_object")
 		}
 
 		resp.Result = &emptypb.Empty{}
@@ -152,8 +162,17 @@ func (s *Writer) SetRelation(ctx context.Context, req *dsw3.SetRelationRequest)
 
 	etag := relation.Hash()
 
-	err := s.store.DB().Update(func(tx *bolt.Tx) error {
-		updRel, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, relation.ObjKey(), req.GetRelation())
+	expiresAt, err := relationExpiry(ctx)
+	if err != nil {
+		return resp, err
+	}
+
+	err = update(ctx, s.store, func(tx bdb.Tx) error {
+		if err := ds.EnsureRelationObjects(ctx, tx, req.GetRelation(), s.refIntegrity); err != nil {
+			return err
+		}
+
+		updRel, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, relation.ObjKey(tx), req.GetRelation())
 		if err != nil {
 			return err
 		}
@@ -167,8 +186,14 @@ func (s *Writer) SetRelation(ctx context.Context, req *dsw3.SetRelationRequest)
 			)
 		}
 
-		if etag == updRel.GetEtag() {
-			s.logger.Trace().Bytes("key", ds.Relation(req.GetRelation()).ObjKey()).Str("etag-equal", etag).Msg("set_relation")
+		// a set without expiration time makes a time-bounded relation permanent.
+		expiryChanged, err := ds.SetRelationExpiry(tx, req.GetRelation(), expiresAt)
+		if err != nil {
+			return err
+		}
+
+		if etag == updRel.GetEtag() && !expiryChanged {
+			s.logger.Trace().Bytes("key", ds.Relation(req.GetRelation()).ObjKey(tx)).Str("etag-equal", etag).Msg("set_relation")
 
 			resp.Result = updRel
 
@@ -177,15 +202,11 @@ func (s *Writer) SetRelation(ctx context.Context, req *dsw3.SetRelationRequest)
 
 		updRel.Etag = etag
 
-		objRel, err := bdb.Set(ctx, tx, bdb.RelationsObjPath, relation.ObjKey(), updRel)
+		objRel, err := ds.SetRelation(ctx, tx, updRel)
 		if err != nil {
 			return err
 		}
 
-		if _, err := bdb.Set(ctx, tx, bdb.RelationsSubPath, relation.SubKey(), updRel); err != nil {
-			return err
-		}
-
 		resp.Result = objRel
 
 		return nil
@@ -215,11 +236,11 @@ func (s *Writer) DeleteRelation(ctx context.Context, req *dsw3.DeleteRelationReq
 		return resp, err
 	}
 
-	err := s.store.DB().Update(func(tx *bolt.Tx) error {
+	err := update(ctx, s.store, func(tx bdb.Tx) error {
 		// optimistic concurrency check
 		ifMatchHeader := metautils.ExtractIncoming(ctx).Get(headers.IfMatch)
 		if ifMatchHeader != "" {
-			updRel, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, rid.ObjKey(), rel)
+			updRel, err := ds.UpdateMetadataRelation(ctx, tx, bdb.RelationsObjPath, rid.ObjKey(tx), rel)
 			if err != nil {
 				return err
 			}
@@ -231,11 +252,7 @@ func (s *Writer) DeleteRelation(ctx context.Context, req *dsw3.DeleteRelationReq
 			}
 		}
 
-		if err := bdb.Delete(ctx, tx, bdb.RelationsObjPath, rid.ObjKey()); err != nil {
-			return err
-		}
-
-		if err := bdb.Delete(ctx, tx, bdb.RelationsSubPath, rid.SubKey()); err != nil {
+		if err := ds.DeleteRelation(ctx, tx, rel); err != nil {
 			return err
 		}
 
@@ -247,27 +264,88 @@ func (s *Writer) DeleteRelation(ctx context.Context, req *dsw3.DeleteRelationReq
 	return resp, err
 }
 
-func (*Writer) deleteRelations(ctx context.Context, path bdb.Path, tx *bolt.Tx, oid *dsc3.ObjectIdentifier) error {
-	objIdent := ds.ObjectIdentifier(oid)
+// deleteObjectPreconditions, checks the preconditions of the deletion of the object instance, the If-Match request
+// header must match the etag of the object instance, which does not match when the object instance does not exist.
+func deleteObjectPreconditions(ctx context.Context, tx bdb.Tx, req *dsw3.DeleteObjectRequest) error {
+	// optimistic concurrency check
+	ifMatchHeader := metautils.ExtractIncoming(ctx).Get(headers.IfMatch)
+	if ifMatchHeader == "" {
+		return nil
+	}
 
-	iter, err := bdb.NewScanIterator[dsc3.Relation](
-		ctx, tx, path,
-		bdb.WithKeyFilter(append(objIdent.Key(), ds.InstanceSeparator)),
-	)
+	obj := &dsc3.Object{Type: req.GetObjectType(), Id: req.GetObjectId()}
+
+	updObj, err := ds.UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, ds.Object(obj).Key(tx), obj)
 	if err != nil {
 		return err
 	}
 
-	for iter.Next() {
-		rel := ds.Relation(iter.Value())
-		if err := bdb.Delete(ctx, tx, bdb.RelationsObjPath, rel.ObjKey()); err != nil {
+	if ifMatchHeader != updObj.GetEtag() {
+		return derr.ErrHashMismatch.Msgf("for object with type [%s] and id [%s]", updObj.GetType(), updObj.GetId())
+	}
+
+	return nil
+}
+
+// previewDeleteObject, checks the preconditions of the deletion of the object instance, and returns the number of
+// relations which are deleted with the object instance in the response header, without modifying the store.
+// The dry-run only returns the count, the relations themselves are only listed by the PreviewDeleteObject
+// method of the streamer service (Streamer.PreviewDeleteObject).
+func (s *Writer) previewDeleteObject(ctx context.Context, req *dsw3.DeleteObjectRequest, oid *dsc3.ObjectIdentifier) error {
+	count := 0
+
+	if err := s.store.DB().View(func(tx bdb.Tx) error {
+		if err := deleteObjectPreconditions(ctx, tx, req); err != nil {
 			return err
 		}
 
-		if err := bdb.Delete(ctx, tx, bdb.RelationsSubPath, rel.SubKey()); err != nil {
-			return err
+		if !req.GetWithRelations() {
+			return nil
 		}
+
+		var err error
+
+		count, err = ds.CountObjectRelations(ctx, tx, oid)
+
+		return err
+	}); err != nil {
+		return err
 	}
 
-	return nil
+	return grpc.SetHeader(ctx, grpcmd.Pairs(x.DeletedRelationCountHeader, strconv.Itoa(count)))
+}
+
+// deleteDryRun, returns true when the incoming request metadata requests a dry-run of the delete.
+func deleteDryRun(ctx context.Context) (bool, error) {
+	v := metautils.ExtractIncoming(ctx).Get(x.DryRunHeader)
+	if v == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, derr.ErrInvalidArgument.Msgf("%s: %q", x.DryRunHeader, v)
+	}
+
+	return dryRun, nil
+}
+
+// relationExpiry, returns the relation expiration time passed in the incoming request metadata,
+// returns the zero time when not set, relations set without expiration time are permanent.
+func relationExpiry(ctx context.Context) (time.Time, error) {
+	v := metautils.ExtractIncoming(ctx).Get(x.RelationExpiresAtHeader)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, ds.ErrInvalidExpiry.Msgf("%s: %q", x.RelationExpiresAtHeader, v)
+	}
+
+	if !expiresAt.After(time.Now()) {
+		return time.Time{}, ds.ErrInvalidExpiry.Msgf("%s: %q is in the past", x.RelationExpiresAtHeader, v)
+	}
+
+	return expiresAt.UTC(), nil
 }
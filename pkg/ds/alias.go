@@ -0,0 +1,86 @@
+package ds
+
+import (
+	"context"
+	"net/http"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Identity aliases are alternative identifiers of a subject, like an email address or the id assigned by an
+// identity provider, stored as identity objects, related to the canonical subject by the identifier relation:
+//
+// identity:{alias}#identifier@{subject_type}:{subject_id}
+//
+// A subject id flagged as an alias is resolved to the id of the canonical subject of the subject type,
+// before the check is evaluated.
+
+const (
+	IdentityObjectType string = "identity"
+	IdentityRelation   string = "identifier"
+)
+
+var (
+	ErrAliasNotFound  = cerr.NewAsertoError("E20069", codes.NotFound, http.StatusNotFound, "subject alias not found")
+	ErrAliasAmbiguous = cerr.NewAsertoError("E20070", codes.FailedPrecondition, http.StatusPreconditionFailed, "subject alias ambiguous")
+	ErrInvalidAlias   = cerr.NewAsertoError("E20071", codes.InvalidArgument, http.StatusBadRequest, "invalid subject alias flag")
+)
+
+type subjectAliasKey struct{}
+
+// WithSubjectAlias, returns a copy of the context flagging the subject id of the checks executed with the context as alias.
+func WithSubjectAlias(ctx context.Context) context.Context {
+	return context.WithValue(ctx, subjectAliasKey{}, true)
+}
+
+// IsSubjectAlias, returns true when the context flags the subject id as alias.
+func IsSubjectAlias(ctx context.Context) bool {
+	alias, _ := ctx.Value(subjectAliasKey{}).(bool)
+	return alias
+}
+
+// ResolveAlias, returns the id of the subject of the subject type identified by the alias,
+// returns ErrAliasNotFound when no subject is identified by the alias,
+// and ErrAliasAmbiguous when the alias identifies more than one subject of the subject type.
+// Expired identifier relations are ignored.
+func ResolveAlias(ctx context.Context, tx bdb.Tx, subjectType, alias string) (string, error) {
+	keyFilter := RelationIdentifierBuffer()
+	defer ReturnRelationIdentifierBuffer(keyFilter)
+
+	path, valueFilter := GetRelations(&dsr3.GetRelationsRequest{
+		ObjectType:  IdentityObjectType,
+		ObjectId:    alias,
+		Relation:    IdentityRelation,
+		SubjectType: subjectType,
+	}).RelationValueFilter(tx, keyFilter)
+
+	relations, err := bdb.Scan[dsc3.Relation](ctx, tx, path, keyFilter.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	ids := []string{}
+
+	// the value filter ignores the expired identifier relations, which no longer identify the subject.
+	for _, rel := range relations {
+		if !valueFilter(rel) || rel.GetSubjectRelation() != "" {
+			continue
+		}
+
+		ids = append(ids, rel.GetSubjectId())
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", ErrAliasNotFound.Msgf("%s:%s", subjectType, alias)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", ErrAliasAmbiguous.Msgf("%s:%s identifies %d subjects", subjectType, alias, len(ids))
+	}
+}
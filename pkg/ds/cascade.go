@@ -0,0 +1,233 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+)
+
+var ErrCascadeIncomplete = cerr.NewAsertoError("E20078", codes.Aborted, http.StatusConflict,
+	"relations referencing the deleted object remain")
+
+// ObjectRelations, returns the relations referencing the object instance, which are removed when the object is
+// deleted with its relations: the outgoing relations of the object, and the incoming relations with the object as
+// subject, either directly (document:x#reader@group:eng) or as userset (document:x#reader@group:eng#member).
+// Each relation is returned once, relations of the object with itself are both outgoing and incoming.
+func ObjectRelations(ctx context.Context, tx bdb.Tx, oid *dsc3.ObjectIdentifier) ([]*dsc3.Relation, error) {
+	rels, _, err := ObjectRelationsPage(ctx, tx, oid, ObjectRelationsPosition{}, -1)
+	return rels, err
+}
+
+// ObjectRelationsPosition, position of a paged scan of the relations referencing an object instance,
+// the zero value is the start of the scan.
+type ObjectRelationsPosition struct {
+	phase int    // index of objectRelationPaths.
+	key   []byte // key of the next relation of the phase, nil is the first relation.
+}
+
+// Done, reports whether the scan is complete.
+func (p ObjectRelationsPosition) Done() bool {
+	return p.phase >= len(objectRelationPaths)
+}
+
+// objectRelationPaths, the outgoing relations are keyed by object in relations_obj,
+// the incoming relations by subject in relations_sub.
+var objectRelationPaths = []bdb.Path{bdb.RelationsObjPath, bdb.RelationsSubPath}
+
+// ObjectRelationsPage, returns up to size (unlimited when negative) relations referencing the object instance,
+// as returned by ObjectRelations, starting at pos, and the position following the returned relations.
+func ObjectRelationsPage(
+	ctx context.Context,
+	tx bdb.Tx,
+	oid *dsc3.ObjectIdentifier,
+	pos ObjectRelationsPosition,
+	size int,
+) ([]*dsc3.Relation, ObjectRelationsPosition, error) {
+	result := []*dsc3.Relation{}
+
+	for !pos.Done() && (size < 0 || len(result) < size) {
+		rels, next, err := objectRelations(ctx, tx, objectRelationPaths[pos.phase], oid, pos.key, size-len(result))
+		if err != nil {
+			return nil, pos, err
+		}
+
+		result = append(result, rels...)
+
+		if next == nil {
+			pos = ObjectRelationsPosition{phase: pos.phase + 1}
+		} else {
+			pos = ObjectRelationsPosition{phase: pos.phase, key: next}
+		}
+	}
+
+	return result, pos, nil
+}
+
+// DeleteObjectRelations, deletes the relations referencing the object instance, as returned by ObjectRelations,
+// and verifies no relation with the object as subject remains in the relations_rel bucket, returns the deleted relations.
+// ObjectRelations finds the relations with the object as subject through the relations_sub bucket, the relations_rel
+// bucket indexes the same relations independently, a remaining entry reveals a relation missing from relations_sub.
+// Returns ErrCascadeIncomplete when relations referencing the object remain, the caller is expected to abort
+// the transaction, leaving the store unchanged.
+func DeleteObjectRelations(ctx context.Context, tx bdb.Tx, oid *dsc3.ObjectIdentifier) ([]*dsc3.Relation, error) {
+	rels, err := ObjectRelations(ctx, tx, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rel := range rels {
+		if err := DeleteRelation(ctx, tx, rel); err != nil {
+			return nil, err
+		}
+	}
+
+	remaining, err := subjectRelationCount(ctx, tx, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	if remaining > 0 {
+		return nil, ErrCascadeIncomplete.Msgf("object [%s:%s], %d relations remain",
+			oid.GetObjectType(), oid.GetObjectId(), remaining)
+	}
+
+	return rels, nil
+}
+
+// subjectRelationCount, returns the number of relations_rel entries with the object instance as subject, either
+// directly or as userset. The relations_rel keys start with the object type and relation of the relation, followed
+// by the subject, the entries of each object type and relation are skipped to the entries of the subject.
+func subjectRelationCount(ctx context.Context, tx bdb.Tx, oid *dsc3.ObjectIdentifier) (int, error) {
+	b, err := bdb.SetBucket(tx, bdb.RelationsRelPath)
+	if err != nil {
+		return 0, nil //nolint:nilerr // a missing bucket contains no relations.
+	}
+
+	typeID := newNameResolver(tx).id(oid.GetObjectType())
+	if typeID == 0 {
+		// no relation refers to an object type which has not been interned.
+		return 0, nil
+	}
+
+	subject := &bytes.Buffer{}
+	writeObjectKey(subject, typeID, oid.GetObjectId())
+
+	count := 0
+	c := b.Cursor()
+
+	for k, _ := c.First(); k != nil; {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		r := keyReader{key: k, ok: true}
+		r.uvarint()
+		r.uvarint()
+
+		if !r.ok {
+			// undecodable keys are reported by VerifyRelations.
+			k, _ = c.Next()
+			continue
+		}
+
+		prefix := bytes.Clone(k[:len(k)-len(r.key)])
+		seek := append(bytes.Clone(prefix), subject.Bytes()...)
+
+		for k, _ = c.Seek(seek); k != nil && bytes.HasPrefix(k, seek); k, _ = c.Next() {
+			count++
+		}
+
+		// the last byte of a uvarint is below 0x80, incrementing it yields the first key following the prefix.
+		prefix[len(prefix)-1]++
+		k, _ = c.Seek(prefix)
+	}
+
+	return count, nil
+}
+
+// objectRelations, returns up to n (unlimited when negative) relations of the relation bucket keyed by the object
+// instance, starting at the start key, and the key following the returned relations, nil when the bucket is exhausted.
+// The relations_obj bucket is keyed by the object of the relation, the relations_sub bucket by the subject of the
+// relation, relations of the object with itself are skipped in relations_sub, as they are returned from relations_obj.
+// The key prefix selects the candidate relations, which are matched against the decoded relation.
+func objectRelations(
+	ctx context.Context,
+	tx bdb.Tx,
+	path bdb.Path,
+	oid *dsc3.ObjectIdentifier,
+	start []byte,
+	n int,
+) ([]*dsc3.Relation, []byte, error) {
+	opts := []bdb.ScanOption{bdb.WithKeyFilter(ObjectIdentifier(oid).Key(tx))}
+	if start != nil {
+		opts = append(opts, bdb.WithStartKey(start))
+	}
+
+	iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, path, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rels := []*dsc3.Relation{}
+	match := objectRelationMatch(path, oid)
+
+	for iter.Next() {
+		if n >= 0 && len(rels) == n {
+			// the key is only valid for the lifetime of the transaction.
+			return rels, bytes.Clone(iter.RawKey()), nil
+		}
+
+		if rel := iter.Value(); match(rel) {
+			rels = append(rels, rel)
+		}
+	}
+
+	return rels, nil, nil
+}
+
+// CountObjectRelations, returns the number of relations referencing the object instance, as returned by
+// ObjectRelations, without loading the relations in memory.
+func CountObjectRelations(ctx context.Context, tx bdb.Tx, oid *dsc3.ObjectIdentifier) (int, error) {
+	count := 0
+
+	for _, path := range objectRelationPaths {
+		iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, path, bdb.WithKeyFilter(ObjectIdentifier(oid).Key(tx)))
+		if err != nil {
+			return 0, err
+		}
+
+		match := objectRelationMatch(path, oid)
+
+		for iter.Next() {
+			if match(iter.Value()) {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// objectRelationMatch, returns a filter matching the relations of the relation bucket referencing the object instance,
+// as described by objectRelations.
+func objectRelationMatch(path bdb.Path, oid *dsc3.ObjectIdentifier) func(*dsc3.Relation) bool {
+	isObject := func(objType, objID string) bool {
+		return objType == oid.GetObjectType() && objID == oid.GetObjectId()
+	}
+
+	if path[0] == bdb.RelationsObjPath[0] {
+		return func(rel *dsc3.Relation) bool {
+			return isObject(rel.GetObjectType(), rel.GetObjectId())
+		}
+	}
+
+	return func(rel *dsc3.Relation) bool {
+		return isObject(rel.GetSubjectType(), rel.GetSubjectId()) && !isObject(rel.GetObjectType(), rel.GetObjectId())
+	}
+}
@@ -0,0 +1,274 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsm3 "github.com/aserto-dev/go-directory/aserto/directory/model/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// The change log records every mutation of the store, in commit order, keyed by a store wide,
+// monotonically increasing revision number.
+//
+// store layout:
+// _system/revision			-- contains the current (last assigned) revision
+// _changelog/{revision}		-- contains the change: op | unix nano timestamp | proto encoded instance
+
+//nolint:lll // single line readability more important.
+var (
+	ErrRevisionCompacted  = cerr.NewAsertoError("E20056", codes.OutOfRange, http.StatusGone, "revision has been compacted")
+	ErrInvalidChange      = cerr.NewAsertoError("E20057", codes.DataLoss, http.StatusInternalServerError, "invalid change log entry")
+	ErrRevisionNotReached = cerr.NewAsertoError("E20058", codes.Unavailable, http.StatusServiceUnavailable, "store has not reached requested revision")
+	ErrInvalidRevision    = cerr.NewAsertoError("E20059", codes.InvalidArgument, http.StatusBadRequest, "invalid revision")
+)
+
+var revisionKey = []byte("revision")
+
+// DefaultChangeLogRetention, default duration change log entries are retained before being garbage collected.
+const DefaultChangeLogRetention time.Duration = 24 * time.Hour
+
+// ChangeOp, type of change recorded in the change log.
+type ChangeOp uint8
+
+const (
+	ChangeUnknown ChangeOp = iota
+	ChangeObjectSet
+	ChangeObjectDelete
+	ChangeRelationSet
+	ChangeRelationDelete
+	ChangeManifestSet
+	ChangeManifestDelete
+	// ChangeReset, the store has been replaced by a restored snapshot, the change log does not contain the changes
+	// preceding the reset, watchers discard their state and re-read the store.
+	ChangeReset
+)
+
+var changeOps = map[ChangeOp]string{
+	ChangeUnknown:        "UNKNOWN",
+	ChangeObjectSet:      "OBJECT_SET",
+	ChangeObjectDelete:   "OBJECT_DELETE",
+	ChangeRelationSet:    "RELATION_SET",
+	ChangeRelationDelete: "RELATION_DELETE",
+	ChangeManifestSet:    "MANIFEST_SET",
+	ChangeManifestDelete: "MANIFEST_DELETE",
+	ChangeReset:          "RESET",
+}
+
+func (op ChangeOp) String() string {
+	if s, ok := changeOps[op]; ok {
+		return s
+	}
+
+	return changeOps[ChangeUnknown]
+}
+
+// Change, typed change event, depending on the op either Object, Relation or Manifest is set.
+type Change struct {
+	Revision  uint64
+	Op        ChangeOp
+	Timestamp time.Time
+	Object    *dsc3.Object
+	Relation  *dsc3.Relation
+	Manifest  *dsm3.Metadata
+}
+
+const (
+	revisionSize     int = 8
+	changeHeaderSize int = 1 + timestampSize
+)
+
+// Revision, returns the current store revision.
+func Revision(tx bdb.Tx) uint64 {
+	v, err := bdb.GetKey(tx, bdb.SystemPath, revisionKey)
+	if err != nil || len(v) != revisionSize {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(v)
+}
+
+// nextRevision, increments and returns the store revision.
+func nextRevision(tx bdb.Tx) (uint64, error) {
+	rev := Revision(tx) + 1
+
+	if err := bdb.SetKey(tx, bdb.SystemPath, revisionKey, revisionBytes(rev)); err != nil {
+		return 0, err
+	}
+
+	return rev, nil
+}
+
+func revisionBytes(rev uint64) []byte {
+	buf := make([]byte, revisionSize)
+	binary.BigEndian.PutUint64(buf, rev)
+
+	return buf
+}
+
+// AppendChange, records the change in the change log, under the next store revision.
+// The watchers of the store are notified when the transaction is committed.
+func AppendChange(tx bdb.Tx, op ChangeOp, msg proto.Message) (uint64, error) {
+	rev, err := nextRevision(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	val := make([]byte, changeHeaderSize, changeHeaderSize+len(buf))
+	val[0] = byte(op)
+	binary.BigEndian.PutUint64(val[1:], uint64(time.Now().UTC().UnixNano())) //nolint:gosec // G115: timestamps are always after the epoch.
+
+	if err := bdb.SetKey(tx, bdb.ChangeLogPath, revisionBytes(rev), append(val, buf...)); err != nil {
+		return 0, err
+	}
+
+	return rev, nil
+}
+
+// ScanChanges, calls fn for each change recorded after the given revision, in revision order.
+// Returns ErrRevisionCompacted when changes following the revision have been purged,
+// unless the oldest available change is a reset, which supersedes the preceding changes.
+func ScanChanges(ctx context.Context, tx bdb.Tx, after uint64, fn func(*Change) error) error {
+	b, err := bdb.SetBucket(tx, bdb.ChangeLogPath)
+	if err != nil {
+		return err
+	}
+
+	c := b.Cursor()
+
+	k, v := c.Seek(revisionBytes(after + 1))
+	if k == nil {
+		return nil
+	}
+
+	if first := binary.BigEndian.Uint64(k); first > after+1 && after < Revision(tx) && !isReset(v) {
+		return ErrRevisionCompacted.Msgf("revision %d, oldest available %d", after, first-1)
+	}
+
+	for ; k != nil; k, v = c.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		change, err := decodeChange(k, v)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeChanges, removes the changes recorded before the given time from the change log,
+// returns the number of purged changes.
+func PurgeChanges(_ context.Context, tx bdb.Tx, before time.Time) (int, error) {
+	b, err := bdb.SetBucket(tx, bdb.ChangeLogPath)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := [][]byte{}
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(v) < changeHeaderSize || changeTime(v).After(before) {
+			break
+		}
+
+		keys = append(keys, bytes.Clone(k))
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+func changeTime(v []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v[1:changeHeaderSize]))).UTC() //nolint:gosec // G115: timestamps are always after the epoch.
+}
+
+func decodeChange(k, v []byte) (*Change, error) {
+	if len(k) != revisionSize || len(v) < changeHeaderSize {
+		return nil, ErrInvalidChange.Msgf("key %x", k)
+	}
+
+	change := &Change{
+		Revision:  binary.BigEndian.Uint64(k),
+		Op:        ChangeOp(v[0]),
+		Timestamp: changeTime(v),
+	}
+
+	payload := v[changeHeaderSize:]
+
+	var msg proto.Message
+
+	switch change.Op {
+	case ChangeObjectSet, ChangeObjectDelete:
+		change.Object = &dsc3.Object{}
+		msg = change.Object
+	case ChangeRelationSet, ChangeRelationDelete:
+		change.Relation = &dsc3.Relation{}
+		msg = change.Relation
+	case ChangeManifestSet, ChangeManifestDelete:
+		change.Manifest = &dsm3.Metadata{}
+		msg = change.Manifest
+	case ChangeReset:
+		return change, nil
+	default:
+		return nil, ErrInvalidChange.Msgf("revision %d op %d", change.Revision, v[0])
+	}
+
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return nil, ErrInvalidChange.Err(err).Msgf("revision %d", change.Revision)
+	}
+
+	return change, nil
+}
+
+func isReset(v []byte) bool {
+	return len(v) >= changeHeaderSize && ChangeOp(v[0]) == ChangeReset
+}
+
+// ResetChangeLog, prepares the store restored from a snapshot (next) to continue the revision sequence of the store
+// it replaces (cur), so the store revision never moves backwards. The change log of the snapshot is removed,
+// and a reset change is recorded under the revision following the highest of both store revisions,
+// returns the revision of the reset change.
+func ResetChangeLog(cur, next bdb.Tx) (uint64, error) {
+	rev := max(Revision(cur), Revision(next))
+
+	if err := bdb.DeleteBucket(next, bdb.ChangeLogPath); err != nil {
+		return 0, err
+	}
+
+	if _, err := bdb.CreateBucket(next, bdb.ChangeLogPath); err != nil {
+		return 0, err
+	}
+
+	if err := bdb.SetKey(next, bdb.SystemPath, revisionKey, revisionBytes(rev)); err != nil {
+		return 0, err
+	}
+
+	return AppendChange(next, ChangeReset, &emptypb.Empty{})
+}
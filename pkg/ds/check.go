@@ -2,6 +2,7 @@ package ds
 
 import (
 	"context"
+	"time"
 
 	"github.com/aserto-dev/azm/cache"
 	"github.com/aserto-dev/azm/graph"
@@ -9,10 +10,10 @@ import (
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
 	"github.com/aserto-dev/go-directory/pkg/derr"
+	"github.com/aserto-dev/go-directory/pkg/pb"
 	"github.com/aserto-dev/go-directory/pkg/prop"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -24,7 +25,8 @@ func Check(i *dsr3.CheckRequest) *check {
 	return &check{safe.Check(i)}
 }
 
-func (i *check) Exec(ctx context.Context, tx *bolt.Tx, mc *cache.Cache) (*dsr3.CheckResponse, error) {
+// Exec, evaluates the check, consulting the decision cache when not nil.
+func (i *check) Exec(ctx context.Context, tx bdb.Tx, mc *cache.Cache, dc *DecisionCache) (*dsr3.CheckResponse, error) {
 	if err := i.RelationIdentifiersExist(ctx, tx); err != nil {
 		return &dsr3.CheckResponse{
 			Check:   false,
@@ -32,31 +34,67 @@ func (i *check) Exec(ctx context.Context, tx *bolt.Tx, mc *cache.Cache) (*dsr3.C
 		}, err
 	}
 
-	return mc.Check(i.CheckRequest, getRelations(ctx, tx))
+	key, cacheable := dc.cacheable(ctx, mc, i.CheckRequest)
+	if !cacheable {
+		return mc.Check(i.CheckRequest, getRelations(ctx, tx))
+	}
+
+	rev := Revision(tx)
+	now := time.Now().UTC()
+
+	if decision, ok := dc.get(rev, key, now); ok {
+		return &dsr3.CheckResponse{Check: decision, Context: pb.NewStruct()}, nil
+	}
+
+	resp, err := mc.Check(i.CheckRequest, getRelations(ctx, tx))
+	if err == nil && len(resp.GetContext().GetFields()) == 0 {
+		expiresAt, _ := NextRelationExpiry(tx, now)
+		dc.set(rev, key, resp.GetCheck(), expiresAt)
+	}
+
+	return resp, err
 }
 
-func getRelations(ctx context.Context, tx *bolt.Tx) graph.RelationReader {
+func getRelations(ctx context.Context, tx bdb.Tx) graph.RelationReader {
 	return func(r *dsc3.RelationIdentifier, pool graph.RelationPool, out *[]*dsc3.RelationIdentifier) error {
 		keyFilter := RelationIdentifierBuffer()
 		defer ReturnRelationIdentifierBuffer(keyFilter)
 
-		path, valueFilter := RelationIdentifier(r).Filter(keyFilter)
+		rel := RelationIdentifier(r)
+		path, valueFilter := rel.Filter(tx, keyFilter)
+
+		storeFilter := valueFilter
+		if expired := expiredFilter(tx, time.Now().UTC()); expired != nil {
+			storeFilter = func(rel *dsc3.RelationIdentifier) bool {
+				return valueFilter(rel) && !expired(rel)
+			}
+		}
+
+		keyMatch := rel.KeyMatch(tx, path)
 
-		return bdb.ScanWithFilter(ctx, tx, path, keyFilter.Bytes(), valueFilter, pool, out)
+		if err := bdb.ScanWithFilter(ctx, tx, path, keyFilter.Bytes(), keyMatch, storeFilter, pool, out); err != nil {
+			return err
+		}
+
+		scanContextual(ContextualRelations(ctx), valueFilter, pool, out)
+
+		return nil
 	}
 }
 
-func (i *check) RelationIdentifiersExist(ctx context.Context, tx *bolt.Tx) error {
-	if !i.relationIdentifierExist(
+func (i *check) RelationIdentifiersExist(ctx context.Context, tx bdb.Tx) error {
+	contextual := ContextualRelations(ctx)
+
+	if !contextualSubjectExists(contextual, i.SubjectType, i.SubjectId) && !i.relationIdentifierExist(
 		ctx, tx, bdb.RelationsSubPath,
-		ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: i.SubjectType, ObjectId: i.SubjectId}).Key(),
+		ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: i.SubjectType, ObjectId: i.SubjectId}).Key(tx),
 	) {
 		return derr.ErrObjectNotFound.Msgf("subject %s:%s", i.SubjectType, i.SubjectId)
 	}
 
-	if !i.relationIdentifierExist(
+	if !contextualObjectExists(contextual, i.ObjectType, i.ObjectId) && !i.relationIdentifierExist(
 		ctx, tx, bdb.RelationsObjPath,
-		ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: i.ObjectType, ObjectId: i.ObjectId}).Key(),
+		ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: i.ObjectType, ObjectId: i.ObjectId}).Key(tx),
 	) {
 		return derr.ErrObjectNotFound.Msgf("object %s:%s", i.ObjectType, i.ObjectId)
 	}
@@ -64,7 +102,7 @@ func (i *check) RelationIdentifiersExist(ctx context.Context, tx *bolt.Tx) error
 	return nil
 }
 
-func (i *check) relationIdentifierExist(ctx context.Context, tx *bolt.Tx, path bdb.Path, keyFilter []byte) bool {
+func (i *check) relationIdentifierExist(ctx context.Context, tx bdb.Tx, path bdb.Path, keyFilter []byte) bool {
 	exists, err := bdb.KeyPrefixExists[dsc3.Relation](ctx, tx, path, keyFilter)
 	if err != nil {
 		return false
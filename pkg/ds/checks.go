@@ -9,8 +9,8 @@ import (
 	"github.com/aserto-dev/azm/safe"
 	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
 	"github.com/aserto-dev/go-directory/pkg/prop"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -30,14 +30,14 @@ func (i *checks) Validate(mc *cache.Cache) error {
 	return nil
 }
 
-func (i *checks) Exec(ctx context.Context, tx *bolt.Tx, mc *cache.Cache) (*dsr3.ChecksResponse, error) {
+func (i *checks) Exec(ctx context.Context, tx bdb.Tx, mc *cache.Cache, dc *DecisionCache) (*dsr3.ChecksResponse, error) {
 	consumer := func(in *dsr3.CheckRequest) *dsr3.CheckResponse {
 		check := Check(in)
 		if err := check.Validate(mc); err != nil {
 			return checkError(err)
 		}
 
-		resp, err := check.Exec(ctx, tx, mc)
+		resp, err := check.Exec(ctx, tx, mc, dc)
 		if err != nil {
 			return checkError(err)
 		}
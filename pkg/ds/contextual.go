@@ -0,0 +1,110 @@
+package ds
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aserto-dev/azm/cache"
+	"github.com/aserto-dev/azm/graph"
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Contextual relations are request scoped relation instances, which are not persisted,
+// but participate in the evaluation of the checks executed with the request context.
+
+//nolint:lll // single line readability more important.
+var ErrInvalidContextualRelation = cerr.NewAsertoError("E20063", codes.InvalidArgument, http.StatusBadRequest, "invalid contextual relation")
+
+type contextualRelationsKey struct{}
+
+// WithContextualRelations, returns a copy of the context carrying the contextual relations,
+// relations already attached to the context are retained.
+func WithContextualRelations(ctx context.Context, rels ...*dsc3.RelationIdentifier) context.Context {
+	if len(rels) == 0 {
+		return ctx
+	}
+
+	cur := ContextualRelations(ctx)
+
+	all := make([]*dsc3.RelationIdentifier, 0, len(cur)+len(rels))
+	all = append(all, cur...)
+	all = append(all, rels...)
+
+	return context.WithValue(ctx, contextualRelationsKey{}, all)
+}
+
+// ContextualRelations, returns the contextual relations attached to the context.
+func ContextualRelations(ctx context.Context) []*dsc3.RelationIdentifier {
+	rels, _ := ctx.Value(contextualRelationsKey{}).([]*dsc3.RelationIdentifier)
+	return rels
+}
+
+// ParseContextualRelation, parses the JSON encoded relation identifier.
+func ParseContextualRelation(s string) (*dsc3.RelationIdentifier, error) {
+	rel := &dsc3.RelationIdentifier{}
+	if err := protojson.Unmarshal([]byte(s), rel); err != nil {
+		return nil, ErrInvalidContextualRelation.Err(err).Msgf("%q", s)
+	}
+
+	return rel, nil
+}
+
+// ValidateContextualRelations, validates the contextual relations against the model.
+func ValidateContextualRelations(mc *cache.Cache, rels []*dsc3.RelationIdentifier) error {
+	for _, rel := range rels {
+		if err := RelationIdentifier(rel).Validate(mc); err != nil {
+			return ErrInvalidContextualRelation.Err(err).Msgf("%s:%s#%s@%s:%s",
+				rel.GetObjectType(), rel.GetObjectId(), rel.GetRelation(), rel.GetSubjectType(), rel.GetSubjectId(),
+			)
+		}
+	}
+
+	return nil
+}
+
+// scanContextual, appends the contextual relations matching the value filter to out,
+// as copies obtained from the pool, as the pool resets the instances it is handed back.
+func scanContextual(
+	rels []*dsc3.RelationIdentifier,
+	valueFilter func(*dsc3.RelationIdentifier) bool,
+	pool graph.RelationPool,
+	out *[]*dsc3.RelationIdentifier,
+) {
+	for _, rel := range rels {
+		if !valueFilter(rel) {
+			continue
+		}
+
+		m := pool.Get()
+		proto.Merge(m, rel)
+
+		*out = append(*out, m)
+	}
+}
+
+// contextualSubjectExists, returns true when a contextual relation references the subject.
+func contextualSubjectExists(rels []*dsc3.RelationIdentifier, subjectType, subjectID string) bool {
+	for _, rel := range rels {
+		if rel.GetSubjectType() == subjectType && rel.GetSubjectId() == subjectID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contextualObjectExists, returns true when a contextual relation references the object.
+func contextualObjectExists(rels []*dsc3.RelationIdentifier, objectType, objectID string) bool {
+	for _, rel := range rels {
+		if rel.GetObjectType() == objectType && rel.GetObjectId() == objectID {
+			return true
+		}
+	}
+
+	return false
+}
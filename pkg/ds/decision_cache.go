@@ -0,0 +1,168 @@
+package ds
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aserto-dev/azm/cache"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+)
+
+// DecisionCache, bounded cache of check decisions, keyed by the check identifiers and the model etag,
+// for a single store revision. Every store mutation (write, import, sync, manifest change) advances the store
+// revision, which invalidates all cached decisions. Safe for concurrent use.
+//
+// The expiry of time-bounded relations does not advance the revision, each decision is cached with the earliest
+// expiration time of the store following its evaluation, the decision is a miss once that time has passed.
+//
+// Decisions are not cached for traced checks, checks carrying contextual relations, or checks which produced a reason.
+type DecisionCache struct {
+	mu       sync.Mutex
+	size     int
+	revision uint64
+	entries  map[decisionKey]decision
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+}
+
+// DecisionCacheStats, decision cache counters.
+type DecisionCacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+type decisionKey struct {
+	objectType  string
+	objectID    string
+	relation    string
+	subjectType string
+	subjectID   string
+	modelETag   string
+}
+
+type decision struct {
+	allowed   bool
+	expiresAt time.Time // earliest expiration time of the store following the evaluation, zero when none.
+}
+
+// valid, returns true when no relation expired since the evaluation of the decision.
+func (d decision) valid(now time.Time) bool {
+	return d.expiresAt.IsZero() || now.Before(d.expiresAt)
+}
+
+// NewDecisionCache, returns a decision cache holding up to size decisions, returns nil when size is not positive,
+// a nil decision cache is valid and disables caching.
+func NewDecisionCache(size int) *DecisionCache {
+	if size <= 0 {
+		return nil
+	}
+
+	return &DecisionCache{
+		size:    size,
+		entries: make(map[decisionKey]decision, size),
+	}
+}
+
+// Stats, returns the decision cache counters.
+func (c *DecisionCache) Stats() DecisionCacheStats {
+	if c == nil {
+		return DecisionCacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return DecisionCacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: len(c.entries),
+	}
+}
+
+// Reset, drops all cached decisions, used when the store is replaced, as the revision of the replacing store
+// is not related to the cached revision.
+func (c *DecisionCache) Reset() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.revision = 0
+}
+
+func (c *DecisionCache) get(rev uint64, key decisionKey, now time.Time) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(rev)
+
+	d, ok := c.entries[key]
+	if ok && rev == c.revision && d.valid(now) {
+		c.hits.Add(1)
+		return d.allowed, true
+	}
+
+	if ok && !d.valid(now) {
+		delete(c.entries, key)
+	}
+
+	c.misses.Add(1)
+
+	return false, false
+}
+
+func (c *DecisionCache) set(rev uint64, key decisionKey, allowed bool, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(rev)
+
+	// decisions evaluated against an older snapshot are not cached.
+	if rev != c.revision {
+		return
+	}
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+
+	c.entries[key] = decision{allowed: allowed, expiresAt: expiresAt}
+}
+
+// advance, drops all cached decisions when the store has advanced past the cached revision.
+func (c *DecisionCache) advance(rev uint64) {
+	if rev > c.revision {
+		clear(c.entries)
+		c.revision = rev
+	}
+}
+
+// cacheable, returns the decision key and true when the decision of the check can be cached.
+func (c *DecisionCache) cacheable(ctx context.Context, mc *cache.Cache, req *dsr3.CheckRequest) (decisionKey, bool) {
+	if c == nil || req.GetTrace() || len(ContextualRelations(ctx)) != 0 {
+		return decisionKey{}, false
+	}
+
+	var etag string
+	if md := mc.Metadata(); md != nil {
+		etag = md.ETag
+	}
+
+	return decisionKey{
+		objectType:  req.GetObjectType(),
+		objectID:    req.GetObjectId(),
+		relation:    req.GetRelation(),
+		subjectType: req.GetSubjectType(),
+		subjectID:   req.GetSubjectId(),
+		modelETag:   etag,
+	}, true
+}
@@ -0,0 +1,291 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Time-bounded relations grant access until their expiration time, expired relations are ignored by checks
+// and graph searches, and are deleted by the directory expiry reaper, which records the deletes like any other
+// relation delete (tombstone and change log entry), so they are propagated to synced directories.
+//
+// The expiration time is stored in relations_expiry only. dsc3.Relation does not declare an expiration time
+// (nor relation properties), the expiration time is set by the x.RelationExpiresAtHeader request header of SetRelation
+// and Import, and returned in the x.RelationExpiresAtHeader response header of GetRelation.
+// Exports requesting OptionRelationExpiry announce the expiration time of each time-bounded relation in a stats message
+// preceding the relation (RelationExpiryStats), sync clients apply the relation with the announced expiration time.
+// Reads ignore expired relations, which are not deleted by the reaper yet, like checks do.
+//
+// store layout:
+// relations_expiry/{relation obj key}	-- contains the big-endian unix nano expiration timestamp
+// relations_expires_at/{big-endian unix nano expiration timestamp}{relation obj key}	-- contains the relation obj key
+
+//nolint:lll // single line readability more important.
+var ErrInvalidExpiry = cerr.NewAsertoError("E20064", codes.InvalidArgument, http.StatusBadRequest, "invalid relation expiration time")
+
+// DefaultExpiryInterval, default interval between runs of the expired relations reaper.
+const DefaultExpiryInterval time.Duration = time.Minute
+
+// OptionRelationExpiry, edge specific export option, when set the exporter precedes each exported time-bounded relation
+// with a stats message announcing the expiration time of the relation (RelationExpiryStats).
+// The option is not part of the directory export API, exporters not supporting the option export the relations only.
+const OptionRelationExpiry uint32 = 1 << 8
+
+const (
+	relationExpiryStatsKey string = "relation_expiry"
+	expiresAtStatsKey      string = "expires_at"
+)
+
+// RelationExpiryStats, returns the export stats message announcing the expiration time of the relation,
+// the message is exported immediately before the relation.
+func RelationExpiryStats(rel *dsc3.Relation, expiresAt time.Time) *structpb.Struct {
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		relationExpiryStatsKey: structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"object_type":      structpb.NewStringValue(rel.GetObjectType()),
+			"object_id":        structpb.NewStringValue(rel.GetObjectId()),
+			"relation":         structpb.NewStringValue(rel.GetRelation()),
+			"subject_type":     structpb.NewStringValue(rel.GetSubjectType()),
+			"subject_id":       structpb.NewStringValue(rel.GetSubjectId()),
+			"subject_relation": structpb.NewStringValue(rel.GetSubjectRelation()),
+			expiresAtStatsKey:  structpb.NewStringValue(expiresAt.UTC().Format(time.RFC3339Nano)),
+		}}),
+	}}
+}
+
+// ExportedRelationExpiry, returns the expiration time announced by the export stats message for the relation,
+// returns false when the message does not announce the expiration time of the relation.
+func ExportedRelationExpiry(stats *structpb.Struct, rel *dsc3.Relation) (time.Time, bool) {
+	fields := stats.GetFields()[relationExpiryStatsKey].GetStructValue().GetFields()
+	if fields == nil {
+		return time.Time{}, false
+	}
+
+	for k, v := range map[string]string{
+		"object_type":      rel.GetObjectType(),
+		"object_id":        rel.GetObjectId(),
+		"relation":         rel.GetRelation(),
+		"subject_type":     rel.GetSubjectType(),
+		"subject_id":       rel.GetSubjectId(),
+		"subject_relation": rel.GetSubjectRelation(),
+	} {
+		if fields[k].GetStringValue() != v {
+			return time.Time{}, false
+		}
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339Nano, fields[expiresAtStatsKey].GetStringValue())
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return expiresAt.UTC(), true
+}
+
+// SetRelationExpiry, sets the expiration time of the relation instance, a zero expiration time removes the expiry,
+// returns true when the expiration time of the relation instance changed.
+func SetRelationExpiry(tx bdb.Tx, rel *dsc3.Relation, expiresAt time.Time) (bool, error) {
+	if err := Intern(tx, rel.GetObjectType(), rel.GetRelation(), rel.GetSubjectType(), rel.GetSubjectRelation()); err != nil {
+		return false, err
+	}
+
+	cur, ok := RelationExpiry(tx, rel)
+	if cur.Equal(expiresAt) && ok != expiresAt.IsZero() {
+		return false, nil
+	}
+
+	objKey := Relation(rel).ObjKey(tx)
+
+	if err := deleteRelationExpiry(tx, objKey); err != nil {
+		return false, err
+	}
+
+	if expiresAt.IsZero() {
+		return true, nil
+	}
+
+	buf := make([]byte, timestampSize)
+	binary.BigEndian.PutUint64(buf, uint64(expiresAt.UnixNano())) //nolint:gosec // G115: timestamps are always after the epoch.
+
+	if err := bdb.SetKey(tx, bdb.RelationsExpiryPath, objKey, buf); err != nil {
+		return false, err
+	}
+
+	return true, bdb.SetKey(tx, bdb.RelationsExpiresAtPath, expiresAtKey(expiresAt, objKey), objKey)
+}
+
+// deleteRelationExpiry, removes the expiration time of the relation instance, and its expiration time index entry.
+func deleteRelationExpiry(tx bdb.Tx, objKey []byte) error {
+	b, err := bdb.SetBucket(tx, bdb.RelationsExpiryPath)
+	if err != nil {
+		return err
+	}
+
+	if v := b.Get(objKey); len(v) == timestampSize {
+		if err := bdb.DeleteKey(tx, bdb.RelationsExpiresAtPath, expiresAtKey(expiryTime(v), objKey)); err != nil {
+			return err
+		}
+	}
+
+	return b.Delete(objKey)
+}
+
+// expiresAtKey, returns the expiration time index key of the relation instance,
+// format: big-endian unix nano expiration timestamp | relation obj key.
+func expiresAtKey(expiresAt time.Time, objKey []byte) []byte {
+	return append(TimestampBoundKey(expiresAt), objKey...)
+}
+
+// NextRelationExpiry, returns the earliest expiration time following the given time, or false when no relation
+// expires after the given time. Decisions evaluated at the given time hold until the returned time.
+func NextRelationExpiry(tx bdb.Tx, now time.Time) (time.Time, bool) {
+	b := bdb.LookupBucket(tx, bdb.RelationsExpiresAtPath)
+	if b == nil {
+		return time.Time{}, false
+	}
+
+	k, _ := b.Cursor().Seek(TimestampSeekKey(now))
+	if len(k) < timestampSize {
+		return time.Time{}, false
+	}
+
+	return expiryTime(k[:timestampSize]), true
+}
+
+// RelationExpiryExporter, returns a function returning the export stats message announcing the expiration time
+// of the relation, or nil when the relation does not expire.
+func RelationExpiryExporter(tx bdb.Tx) func(*dsc3.Relation) *structpb.Struct {
+	if !hasExpiringRelations(tx) {
+		return func(*dsc3.Relation) *structpb.Struct { return nil }
+	}
+
+	return func(rel *dsc3.Relation) *structpb.Struct {
+		if expiresAt, ok := RelationExpiry(tx, rel); ok {
+			return RelationExpiryStats(rel, expiresAt)
+		}
+
+		return nil
+	}
+}
+
+// RelationExpiry, returns the expiration time of the relation instance, or false when the relation does not expire.
+func RelationExpiry(tx bdb.Tx, rel *dsc3.Relation) (time.Time, bool) {
+	b := bdb.LookupBucket(tx, bdb.RelationsExpiryPath)
+	if b == nil {
+		return time.Time{}, false
+	}
+
+	v := b.Get(Relation(rel).ObjKey(tx))
+	if len(v) != timestampSize {
+		return time.Time{}, false
+	}
+
+	return expiryTime(v), true
+}
+
+// expiredFilter, returns a filter matching the relations expired at the given time,
+// returns nil when no relation expires, to keep the check hot path free of lookups.
+func expiredFilter(tx bdb.Tx, now time.Time) func(*dsc3.RelationIdentifier) bool {
+	if !hasExpiringRelations(tx) {
+		return nil
+	}
+
+	b := bdb.LookupBucket(tx, bdb.RelationsExpiryPath)
+	names := newNameResolver(tx)
+
+	return func(rel *dsc3.RelationIdentifier) bool {
+		v := b.Get(RelationIdentifier(rel).objKey(names))
+		if len(v) != timestampSize {
+			return false
+		}
+
+		return !expiryTime(v).After(now)
+	}
+}
+
+// withoutExpired, wraps the value filter of a relation scan to reject the relations expired at the given time,
+// returns the value filter when no relation expires.
+func withoutExpired(tx bdb.Tx, now time.Time, valueFilter func(*dsc3.Relation) bool) func(*dsc3.Relation) bool {
+	expired := expiredFilter(tx, now)
+	if expired == nil {
+		return valueFilter
+	}
+
+	return func(rel *dsc3.Relation) bool {
+		return valueFilter(rel) && !expired(&dsc3.RelationIdentifier{
+			ObjectType:      rel.GetObjectType(),
+			ObjectId:        rel.GetObjectId(),
+			Relation:        rel.GetRelation(),
+			SubjectType:     rel.GetSubjectType(),
+			SubjectId:       rel.GetSubjectId(),
+			SubjectRelation: rel.GetSubjectRelation(),
+		})
+	}
+}
+
+// hasExpiringRelations, returns true when the store contains time-bounded relations.
+func hasExpiringRelations(tx bdb.Tx) bool {
+	b := bdb.LookupBucket(tx, bdb.RelationsExpiryPath)
+	if b == nil {
+		return false
+	}
+
+	k, _ := b.Cursor().First()
+
+	return k != nil
+}
+
+// PurgeExpiredRelations, deletes the relations expired at the given time, returns the number of deleted relations.
+func PurgeExpiredRelations(ctx context.Context, tx bdb.Tx, now time.Time) (int, error) {
+	b, err := bdb.SetBucket(tx, bdb.RelationsExpiresAtPath)
+	if err != nil {
+		return 0, err
+	}
+
+	// the expiration time index is ordered by expiration time, the expired relations precede the given time.
+	keys := [][]byte{}
+	end := TimestampSeekKey(now)
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+		keys = append(keys, bytes.Clone(v))
+	}
+
+	count := 0
+
+	for _, k := range keys {
+		cur, err := getRelation(ctx, tx, k)
+		if err != nil {
+			return 0, err
+		}
+
+		if cur == nil {
+			if err := deleteRelationExpiry(tx, k); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		if err := DeleteRelation(ctx, tx, cur); err != nil {
+			return 0, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+func expiryTime(v []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v))).UTC() //nolint:gosec // G115: timestamps are always after the epoch.
+}
@@ -6,8 +6,7 @@ import (
 	"github.com/aserto-dev/azm/cache"
 	"github.com/aserto-dev/azm/safe"
 	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
-
-	bolt "go.etcd.io/bbolt"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 )
 
 type getGraph struct {
@@ -18,6 +17,6 @@ func GetGraph(i *dsr3.GetGraphRequest) *getGraph {
 	return &getGraph{safe.GetGraph(i)}
 }
 
-func (i *getGraph) Exec(ctx context.Context, tx *bolt.Tx, mc *cache.Cache) (*dsr3.GetGraphResponse, error) {
+func (i *getGraph) Exec(ctx context.Context, tx bdb.Tx, mc *cache.Cache) (*dsr3.GetGraphResponse, error) {
 	return mc.GetGraph(i.GetGraphRequest, getRelations(ctx, tx))
 }
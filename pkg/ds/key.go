@@ -0,0 +1,131 @@
+package ds
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+)
+
+// Object and relation keys use a compact, length-prefixed encoding, object type and relation names are
+// replaced by their interned name id (see names.go), and object ids are prefixed by their length,
+// so a key component is never mistaken for the prefix of another component.
+//
+// object key:	type_id | len(id) id
+// obj key:	object key (object) | relation_id | object key (subject) | sub_relation_id
+// sub key:	object key (subject) | relation_id | object key (object) | sub_relation_id
+// rel key:	obj_type_id | relation_id | object key (subject) | sub_relation_id | len(obj_id) obj_id
+//
+// ids and lengths are uvarint encoded, the subject relation id is 0 when the relation has no subject relation.
+//
+// Keys are not ordered lexically by name: object types and relations are ordered by their interned name id
+// (the order in which the names were first used), and object ids by their length first, then lexically
+// ("b" precedes "aa"). Listings in key order (GetObjects, GetRelations without ordering options) and their pages
+// follow this order, lists ordered by name are sorted by the client, or by timestamp using the ordering options.
+
+// Name ids start at 1, a key component referring to a name which has not been interned is encoded as 0,
+// which does not match any stored key, except for the subject relation id, where 0 identifies the absence
+// of a subject relation, filters on a subject relation which has not been interned use writeNoMatch instead.
+
+// writeNoMatch, replaces the key filter by a filter which does not match any key, as no key starts with a 0 byte.
+func writeNoMatch(buf *bytes.Buffer) {
+	buf.Reset()
+	buf.WriteByte(0)
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeUvarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func writeObjectKey(buf *bytes.Buffer, typeID uint64, id string) {
+	writeUvarint(buf, typeID)
+	writeString(buf, id)
+}
+
+// relationKey, decoded relation key.
+type relationKey struct {
+	objectType      uint64
+	objectID        []byte
+	relation        uint64
+	subjectType     uint64
+	subjectID       []byte
+	subjectRelation uint64
+}
+
+// keyReader, reads the components of an encoded key, once a read fails all subsequent reads fail.
+type keyReader struct {
+	key []byte
+	ok  bool
+}
+
+func (r *keyReader) uvarint() uint64 {
+	if !r.ok {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.key)
+	if n <= 0 {
+		r.ok = false
+		return 0
+	}
+
+	r.key = r.key[n:]
+
+	return v
+}
+
+func (r *keyReader) bytes() []byte {
+	n := r.uvarint()
+	if !r.ok || n > uint64(len(r.key)) {
+		r.ok = false
+		return nil
+	}
+
+	v := r.key[:n]
+	r.key = r.key[n:]
+
+	return v
+}
+
+func (r *keyReader) done() bool {
+	return r.ok && len(r.key) == 0
+}
+
+// decodeRelationKey, decodes the relation key of the relations_obj, relations_sub or relations_rel bucket.
+func decodeRelationKey(path bdb.Path, key []byte) (relationKey, bool) {
+	var (
+		k relationKey
+		r = keyReader{key: key, ok: true}
+	)
+
+	switch path[0] {
+	case bdb.RelationsObjPath[0]:
+		k.objectType, k.objectID = r.uvarint(), r.bytes()
+		k.relation = r.uvarint()
+		k.subjectType, k.subjectID = r.uvarint(), r.bytes()
+		k.subjectRelation = r.uvarint()
+	case bdb.RelationsSubPath[0]:
+		k.subjectType, k.subjectID = r.uvarint(), r.bytes()
+		k.relation = r.uvarint()
+		k.objectType, k.objectID = r.uvarint(), r.bytes()
+		k.subjectRelation = r.uvarint()
+	case bdb.RelationsRelPath[0]:
+		k.objectType = r.uvarint()
+		k.relation = r.uvarint()
+		k.subjectType, k.subjectID = r.uvarint(), r.bytes()
+		k.subjectRelation = r.uvarint()
+		k.objectID = r.bytes()
+	default:
+		return k, false
+	}
+
+	return k, r.done()
+}
@@ -0,0 +1,156 @@
+package ds
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	cerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrInvalidListOptions = cerr.NewAsertoError("E20066", codes.InvalidArgument, http.StatusBadRequest, "invalid sort order or time range")
+
+// Sort fields of listed objects and relations.
+const (
+	SortByCreatedAt string = "created_at"
+	SortByUpdatedAt string = "updated_at"
+)
+
+// TimeRange, time window, an instance is in the time window when its timestamp is after After and before Before,
+// a zero time leaves the window unbounded at that end.
+type TimeRange struct {
+	After  time.Time
+	Before time.Time
+}
+
+func (r TimeRange) IsZero() bool {
+	return r.After.IsZero() && r.Before.IsZero()
+}
+
+// Contains, returns true when the timestamp is in the time window.
+func (r TimeRange) Contains(ts *timestamppb.Timestamp) bool {
+	t := ts.AsTime()
+
+	return (r.After.IsZero() || t.After(r.After)) && (r.Before.IsZero() || t.Before(r.Before))
+}
+
+// keyRange, returns the timestamp index key range of the time window.
+func (r TimeRange) keyRange() (lower, upper []byte) {
+	if !r.After.IsZero() {
+		lower = TimestampSeekKey(r.After)
+	}
+
+	if !r.Before.IsZero() {
+		upper = TimestampBoundKey(r.Before)
+	}
+
+	return lower, upper
+}
+
+// ListOptions, ordering and time windows of listed objects and relations.
+// Ordered lists scan the created_at or updated_at index, instead of the instance keys.
+type ListOptions struct {
+	SortBy     string
+	Descending bool
+	Created    TimeRange
+	Updated    TimeRange
+}
+
+// NewListOptions, validates the sort field and time windows, when time windows are set without a sort field,
+// the instances are sorted by the timestamp of the window, preferring created_at.
+func NewListOptions(sortBy string, descending bool, created, updated TimeRange) (*ListOptions, error) {
+	switch sortBy {
+	case SortByCreatedAt, SortByUpdatedAt:
+	case "":
+		if !created.IsZero() {
+			sortBy = SortByCreatedAt
+		} else if !updated.IsZero() {
+			sortBy = SortByUpdatedAt
+		}
+	default:
+		return nil, ErrInvalidListOptions.Msgf("sort field %q", sortBy)
+	}
+
+	for _, r := range []TimeRange{created, updated} {
+		if !r.After.IsZero() && !r.Before.IsZero() && !r.After.Before(r.Before) {
+			return nil, ErrInvalidListOptions.Msgf("time range after %s is not before %s", r.After.Format(time.RFC3339), r.Before.Format(time.RFC3339))
+		}
+	}
+
+	if sortBy == "" && descending {
+		return nil, ErrInvalidListOptions.Msg("descending order requires a sort field")
+	}
+
+	return &ListOptions{SortBy: sortBy, Descending: descending, Created: created, Updated: updated}, nil
+}
+
+// IsOrdered, returns true when the instances are listed in timestamp order.
+func (o *ListOptions) IsOrdered() bool {
+	return o != nil && o.SortBy != ""
+}
+
+// ObjectsIndex, returns the timestamp index path of the objects sort field.
+func (o *ListOptions) ObjectsIndex() bdb.Path {
+	if o.SortBy == SortByCreatedAt {
+		return bdb.ObjectsCreatedAtPath
+	}
+
+	return bdb.ObjectsUpdatedAtPath
+}
+
+// RelationsIndex, returns the timestamp index path of the relations sort field.
+func (o *ListOptions) RelationsIndex() bdb.Path {
+	if o.SortBy == SortByCreatedAt {
+		return bdb.RelationsCreatedAtPath
+	}
+
+	return bdb.RelationsUpdatedAtPath
+}
+
+// ScanOptions, returns the scan options of the timestamp index scan, limiting the scan to the time window of the sort field.
+func (o *ListOptions) ScanOptions() []bdb.ScanOption {
+	window := o.Updated
+	if o.SortBy == SortByCreatedAt {
+		window = o.Created
+	}
+
+	opts := []bdb.ScanOption{bdb.WithKeyRange(window.keyRange())}
+
+	if o.Descending {
+		opts = append(opts, bdb.WithReverse())
+	}
+
+	return opts
+}
+
+// Match, returns true when the instance timestamps are in the created and updated time windows.
+func (o *ListOptions) Match(msg timestamped) bool {
+	return o.Created.Contains(msg.GetCreatedAt()) && o.Updated.Contains(msg.GetUpdatedAt())
+}
+
+// Fingerprint, returns the list options as page token fingerprint fields, or nil when the list is not ordered.
+func (o *ListOptions) Fingerprint() []string {
+	if !o.IsOrdered() {
+		return nil
+	}
+
+	return []string{
+		o.SortBy,
+		strconv.FormatBool(o.Descending),
+		formatTime(o.Created.After),
+		formatTime(o.Created.Before),
+		formatTime(o.Updated.After),
+		formatTime(o.Updated.Before),
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -9,8 +9,6 @@ import (
 	"github.com/aserto-dev/azm/model"
 	dsm3 "github.com/aserto-dev/go-directory/aserto/directory/model/v3"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
-
-	bolt "go.etcd.io/bbolt"
 )
 
 type manifest struct {
@@ -28,7 +26,7 @@ func Manifest(metadata *dsm3.Metadata) *manifest {
 // Get, hydrates the manifest from the _manifest bucket
 // _metadata/{name}/{version}/metadata
 // _metadata/{name}/{version}/body.
-func (m *manifest) Get(ctx context.Context, tx *bolt.Tx) (*manifest, error) {
+func (m *manifest) Get(ctx context.Context, tx bdb.Tx) (*manifest, error) {
 	if ok, _ := bdb.BucketExists(tx, bdb.ManifestPath); !ok {
 		return nil, bdb.ErrPathNotFound
 	}
@@ -48,7 +46,7 @@ func (m *manifest) Get(ctx context.Context, tx *bolt.Tx) (*manifest, error) {
 
 // GetModel, hydrates the model cache from the _manifest
 // _metadata/{name}/{version}/model.
-func (m *manifest) GetModel(ctx context.Context, tx *bolt.Tx) (*model.Model, error) {
+func (m *manifest) GetModel(ctx context.Context, tx bdb.Tx) (*model.Model, error) {
 	if ok, _ := bdb.BucketExists(tx, bdb.ManifestPath); !ok {
 		return nil, bdb.ErrPathNotFound
 	}
@@ -64,7 +62,7 @@ func (m *manifest) GetModel(ctx context.Context, tx *bolt.Tx) (*model.Model, err
 // Set, persists the manifest body in the _manifest bucket
 // _metadata/{name}/{version}/metadata
 // _metadata/{name}/{version}/body.
-func (m *manifest) Set(ctx context.Context, tx *bolt.Tx, buf *bytes.Buffer) error {
+func (m *manifest) Set(ctx context.Context, tx bdb.Tx, buf *bytes.Buffer) error {
 	if _, err := bdb.CreateBucket(tx, bdb.ManifestPath); err != nil {
 		return err
 	}
@@ -78,12 +76,16 @@ func (m *manifest) Set(ctx context.Context, tx *bolt.Tx, buf *bytes.Buffer) erro
 		return err
 	}
 
+	if _, err := AppendChange(tx, ChangeManifestSet, m.Metadata); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // SetModel, persists the model cache in the _manifest bucket
 // _metadata/{name}/{version}/model.
-func (m *manifest) SetModel(ctx context.Context, tx *bolt.Tx, mod *model.Model) error {
+func (m *manifest) SetModel(ctx context.Context, tx bdb.Tx, mod *model.Model) error {
 	if mod.Metadata == nil {
 		mod.Metadata = &model.Metadata{}
 	}
@@ -104,37 +106,32 @@ func (m *manifest) SetModel(ctx context.Context, tx *bolt.Tx, mod *model.Model)
 //
 // sets the manifest to an empty manifest,
 // updates the model accordingly,
-// deletes and recreates the objects and relations buckets.
-func (m *manifest) Delete(ctx context.Context, tx *bolt.Tx) error {
-	if err := bdb.DeleteBucket(tx, bdb.ManifestPath); err != nil {
-		return err
-	}
-
-	if _, err := bdb.CreateBucket(tx, bdb.ManifestPath); err != nil {
+// records tombstones for all objects and relations,
+// deletes and recreates the objects and relations buckets, including their index buckets.
+func (m *manifest) Delete(ctx context.Context, tx bdb.Tx) error {
+	if err := setTombstones(ctx, tx); err != nil {
 		return err
 	}
 
-	if err := bdb.DeleteBucket(tx, bdb.ObjectsPath); err != nil {
-		return err
-	}
-
-	if _, err := bdb.CreateBucket(tx, bdb.ObjectsPath); err != nil {
+	if err := bdb.DeleteBucket(tx, bdb.ManifestPath); err != nil {
 		return err
 	}
 
-	if err := bdb.DeleteBucket(tx, bdb.RelationsObjPath); err != nil {
+	if _, err := bdb.CreateBucket(tx, bdb.ManifestPath); err != nil {
 		return err
 	}
 
-	if _, err := bdb.CreateBucket(tx, bdb.RelationsObjPath); err != nil {
-		return err
-	}
+	for _, path := range bdb.DataPaths {
+		if err := bdb.DeleteBucket(tx, path); err != nil {
+			return err
+		}
 
-	if err := bdb.DeleteBucket(tx, bdb.RelationsSubPath); err != nil {
-		return err
+		if _, err := bdb.CreateBucket(tx, path); err != nil {
+			return err
+		}
 	}
 
-	if _, err := bdb.CreateBucket(tx, bdb.RelationsSubPath); err != nil {
+	if _, err := AppendChange(tx, ChangeManifestDelete, &dsm3.Metadata{}); err != nil {
 		return err
 	}
 
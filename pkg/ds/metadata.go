@@ -7,13 +7,12 @@ import (
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 
-	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-func UpdateMetadataObject(ctx context.Context, tx *bolt.Tx, path []string, keyFilter []byte, msg *dsc3.Object) (*dsc3.Object, error) {
+func UpdateMetadataObject(ctx context.Context, tx bdb.Tx, path []string, keyFilter []byte, msg *dsc3.Object) (*dsc3.Object, error) {
 	// get timestamp once for transaction.
 	ts := timestamppb.New(time.Now().UTC())
 
@@ -44,7 +43,7 @@ func UpdateMetadataObject(ctx context.Context, tx *bolt.Tx, path []string, keyFi
 	return msg, nil
 }
 
-func UpdateMetadataRelation(ctx context.Context, tx *bolt.Tx, path []string, key []byte, msg *dsc3.Relation) (*dsc3.Relation, error) {
+func UpdateMetadataRelation(ctx context.Context, tx bdb.Tx, path []string, key []byte, msg *dsc3.Relation) (*dsc3.Relation, error) {
 	// get timestamp once for transaction.
 	ts := timestamppb.New(time.Now().UTC())
 
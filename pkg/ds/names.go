@@ -0,0 +1,107 @@
+package ds
+
+import (
+	"encoding/binary"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+)
+
+// Object type and relation names are interned into small integer ids, which replace the names
+// in the object and relation keys (see key.go). Object types, relations and subject relations share
+// a single id space, ids are assigned in sequence starting at 1, id 0 identifies an unknown name.
+//
+// store layout:
+// _system/names/{name}		-- contains the uvarint encoded name id
+// _system/name_sequence	-- contains the big-endian last assigned name id
+
+var nameSequenceKey = []byte("name_sequence")
+
+const nameSequenceSize int = 8
+
+// Intern, assigns a name id to each of the names which have not been interned before.
+// Names are interned by the write path, before the keys of the instance are constructed.
+func Intern(tx bdb.Tx, names ...string) error {
+	b, err := bdb.CreateBucket(tx, bdb.NamesPath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if name == "" || b.Get([]byte(name)) != nil {
+			continue
+		}
+
+		id, err := nextNameID(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put([]byte(name), binary.AppendUvarint(nil, id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NameID, returns the interned id of the name, or 0 when the name has not been interned.
+func NameID(tx bdb.Tx, name string) uint64 {
+	return newNameResolver(tx).id(name)
+}
+
+func nextNameID(tx bdb.Tx) (uint64, error) {
+	var id uint64
+
+	if v, err := bdb.GetKey(tx, bdb.SystemPath, nameSequenceKey); err == nil && len(v) == nameSequenceSize {
+		id = binary.BigEndian.Uint64(v)
+	}
+
+	id++
+
+	buf := make([]byte, nameSequenceSize)
+	binary.BigEndian.PutUint64(buf, id)
+
+	if err := bdb.SetKey(tx, bdb.SystemPath, nameSequenceKey, buf); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// nameResolver, resolves names to their interned ids, using the names bucket of the transaction.
+type nameResolver struct {
+	b bdb.Bucket
+}
+
+func newNameResolver(tx bdb.Tx) nameResolver {
+	b, err := bdb.SetBucket(tx, bdb.NamesPath)
+	if err != nil {
+		return nameResolver{}
+	}
+
+	return nameResolver{b: b}
+}
+
+func (r nameResolver) id(name string) uint64 {
+	if r.b == nil || name == "" {
+		return 0
+	}
+
+	id, n := binary.Uvarint(r.b.Get([]byte(name)))
+	if n <= 0 {
+		return 0
+	}
+
+	return id
+}
+
+// known, returns true when all (non-empty) names have been interned.
+func (r nameResolver) known(names ...string) bool {
+	for _, name := range names {
+		if name != "" && r.id(name) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/aserto-dev/azm/safe"
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/x"
 )
 
@@ -18,14 +19,14 @@ func (i *object) StrKey() string {
 	return i.GetType() + string(TypeIDSeparator) + i.GetId()
 }
 
-func (i *object) Key() []byte {
+// Key, object key
+// format: type_id | len(id) id.
+func (i *object) Key(tx bdb.Tx) []byte {
 	var buf bytes.Buffer
 
 	buf.Grow(x.MaxObjectIdentifierSize)
 
-	buf.WriteString(i.GetType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetId())
+	writeObjectKey(&buf, NameID(tx, i.GetType()), i.GetId())
 
 	return buf.Bytes()
 }
@@ -42,18 +43,22 @@ func (i *objectIdentifier) StrKey() string {
 	return i.GetObjectType() + string(TypeIDSeparator) + i.GetObjectId()
 }
 
-func (i *objectIdentifier) Key() []byte {
+// Key, object key, when the object id is not set, the key prefix of the objects of the object type
+// format: type_id (| len(id) id).
+func (i *objectIdentifier) Key(tx bdb.Tx) []byte {
+	typeID := NameID(tx, i.GetObjectType())
+
 	var buf bytes.Buffer
 
 	buf.Grow(x.MaxObjectIdentifierSize)
 
-	buf.WriteString(i.GetObjectType())
-	buf.WriteByte(TypeIDSeparator)
-
-	if i.GetObjectId() != "" {
-		buf.WriteString(i.GetObjectId())
+	if i.GetObjectId() == "" {
+		writeUvarint(&buf, typeID)
+		return buf.Bytes()
 	}
 
+	writeObjectKey(&buf, typeID, i.GetObjectId())
+
 	return buf.Bytes()
 }
 
@@ -0,0 +1,95 @@
+package ds
+
+import (
+	"encoding/binary"
+	"net/http"
+
+	cerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+)
+
+// Store revisions are local counters, a revision is only meaningful together with the id of the store
+// it originates from. Directories fed by sync record the last applied revision of their upstream directory,
+// so revision tokens of the upstream can be enforced against the synced state.
+//
+// store layout:
+// _system/store_id			-- contains the id of the store, generated when the store is opened first
+// _system/upstream_id			-- contains the store id of the upstream directory the store is synced from
+// _system/upstream_revision		-- contains the last upstream revision applied by sync
+
+//nolint:lll // single line readability more important.
+var ErrUnknownRevisionOrigin = cerr.NewAsertoError("E20079", codes.FailedPrecondition, http.StatusPreconditionFailed, "revision of unknown origin")
+
+var (
+	storeIDKey          = []byte("store_id")
+	upstreamIDKey       = []byte("upstream_id")
+	upstreamRevisionKey = []byte("upstream_revision")
+)
+
+// StoreID, returns the id of the store, an empty string is returned when the id has not been generated.
+func StoreID(tx bdb.Tx) string {
+	v, err := bdb.GetKey(tx, bdb.SystemPath, storeIDKey)
+	if err != nil {
+		return ""
+	}
+
+	return string(v)
+}
+
+// EnsureStoreID, generates the id of the store when not set, returns the id of the store.
+func EnsureStoreID(tx bdb.Tx) (string, error) {
+	if id := StoreID(tx); id != "" {
+		return id, nil
+	}
+
+	id := uuid.NewString()
+
+	if err := bdb.SetKey(tx, bdb.SystemPath, storeIDKey, []byte(id)); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// UpstreamRevision, returns the store id of the upstream directory and the last upstream revision applied by sync,
+// an empty id is returned when the store has not been synced.
+func UpstreamRevision(tx bdb.Tx) (string, uint64) {
+	id, err := bdb.GetKey(tx, bdb.SystemPath, upstreamIDKey)
+	if err != nil || len(id) == 0 {
+		return "", 0
+	}
+
+	v, err := bdb.GetKey(tx, bdb.SystemPath, upstreamRevisionKey)
+	if err != nil || len(v) != revisionSize {
+		return "", 0
+	}
+
+	return string(id), binary.BigEndian.Uint64(v)
+}
+
+// SetUpstreamRevision, records the upstream revision applied by sync, and the store id of the upstream directory.
+func SetUpstreamRevision(tx bdb.Tx, upstreamID string, rev uint64) error {
+	if err := bdb.SetKey(tx, bdb.SystemPath, upstreamIDKey, []byte(upstreamID)); err != nil {
+		return err
+	}
+
+	return bdb.SetKey(tx, bdb.SystemPath, upstreamRevisionKey, revisionBytes(rev))
+}
+
+// OriginRevision, returns the revision of the origin store observed by the store, which is the store revision
+// when the origin is empty or the id of the store, or the last applied upstream revision when the origin is the id
+// of the upstream directory. Returns ErrUnknownRevisionOrigin for any other origin.
+func OriginRevision(tx bdb.Tx, origin string) (uint64, error) {
+	if origin == "" || origin == StoreID(tx) {
+		return Revision(tx), nil
+	}
+
+	if upstreamID, rev := UpstreamRevision(tx); upstreamID == origin {
+		return rev, nil
+	}
+
+	return 0, ErrUnknownRevisionOrigin.Msgf("origin %q", origin)
+}
@@ -0,0 +1,278 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Property indexes map the values of declared object properties to the objects carrying them, an index is declared
+// per object type and property name, and maintained by the write path (SetObject, DeleteObject).
+//
+// store layout:
+// _system/property_indexes	key: len(obj_type) obj_type | len(property) property		value: empty
+// objects_properties		key: type_id | len(property) property | len(value) value | len(id) id	value: object key
+//
+// string, number and boolean property values are indexed using their string representation,
+// the elements of list property values are indexed individually, other values are not indexed.
+
+var (
+	ErrPropertyNotIndexed    = cerr.NewAsertoError("E20067", codes.InvalidArgument, http.StatusBadRequest, "property not indexed")
+	ErrInvalidPropertyFilter = cerr.NewAsertoError("E20068", codes.InvalidArgument, http.StatusBadRequest, "invalid property filter")
+)
+
+// declaredPropertyIndexes, returns the declared property indexes, by object type.
+func declaredPropertyIndexes(tx bdb.Tx) map[string][]string {
+	indexes := map[string][]string{}
+
+	b, err := bdb.SetBucket(tx, bdb.PropertyIndexesPath)
+	if err != nil {
+		return indexes
+	}
+
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		r := keyReader{key: k, ok: true}
+
+		objType, prop := r.bytes(), r.bytes()
+		if !r.done() {
+			continue
+		}
+
+		indexes[string(objType)] = append(indexes[string(objType)], string(prop))
+	}
+
+	return indexes
+}
+
+// SyncPropertyIndexes, aligns the declared property indexes with the given declarations, indexes which are no longer
+// declared are dropped, newly declared indexes are built from the objects of the object type.
+func SyncPropertyIndexes(ctx context.Context, tx bdb.Tx, indexes map[string][]string) error {
+	declared := declaredPropertyIndexes(tx)
+
+	for objType, props := range declared {
+		for _, prop := range props {
+			if slices.Contains(indexes[objType], prop) {
+				continue
+			}
+
+			if err := dropPropertyIndex(tx, objType, prop); err != nil {
+				return err
+			}
+		}
+	}
+
+	for objType, props := range indexes {
+		for _, prop := range props {
+			if slices.Contains(declared[objType], prop) {
+				continue
+			}
+
+			if err := buildPropertyIndex(ctx, tx, objType, prop); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// PropertyKeyFilter, returns the key prefix of the objects of the object type with the property value,
+// returns ErrPropertyNotIndexed when no index is declared for the property of the object type.
+func PropertyKeyFilter(tx bdb.Tx, objType, prop, value string) ([]byte, error) {
+	if !slices.Contains(indexedProperties(tx, objType), prop) {
+		return nil, ErrPropertyNotIndexed.Msgf("%s.%s", objType, prop)
+	}
+
+	var buf bytes.Buffer
+
+	writePropertyPrefix(&buf, NameID(tx, objType), prop)
+	writeString(&buf, value)
+
+	return buf.Bytes(), nil
+}
+
+// setPropertyKeys, adds the object key to the declared property indexes of the object type.
+func setPropertyKeys(tx bdb.Tx, obj *dsc3.Object, key []byte) error {
+	return updatePropertyKeys(tx, obj, key, func(b bdb.Bucket, k []byte) error { return b.Put(k, key) })
+}
+
+// deletePropertyKeys, removes the object key from the declared property indexes of the object type.
+func deletePropertyKeys(tx bdb.Tx, obj *dsc3.Object, key []byte) error {
+	return updatePropertyKeys(tx, obj, key, func(b bdb.Bucket, k []byte) error { return b.Delete(k) })
+}
+
+func updatePropertyKeys(tx bdb.Tx, obj *dsc3.Object, key []byte, fn func(bdb.Bucket, []byte) error) error {
+	props := indexedProperties(tx, obj.GetType())
+	if len(props) == 0 {
+		return nil
+	}
+
+	b, err := bdb.SetBucket(tx, bdb.ObjectPropertiesPath)
+	if err != nil {
+		return err
+	}
+
+	typeID := NameID(tx, obj.GetType())
+
+	for _, prop := range props {
+		for _, value := range propertyValues(obj.GetProperties().GetFields()[prop]) {
+			if err := fn(b, propertyKey(typeID, prop, value, obj.GetId())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexedProperties, returns the properties of the object type with a declared index.
+func indexedProperties(tx bdb.Tx, objType string) []string {
+	b, err := bdb.SetBucket(tx, bdb.PropertyIndexesPath)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		buf   bytes.Buffer
+		props []string
+	)
+
+	writeString(&buf, objType)
+	prefix := buf.Bytes()
+
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		r := keyReader{key: k[len(prefix):], ok: true}
+
+		if prop := r.bytes(); r.done() {
+			props = append(props, string(prop))
+		}
+	}
+
+	return props
+}
+
+// buildPropertyIndex, declares the property index and indexes the property values of the objects of the object type.
+func buildPropertyIndex(ctx context.Context, tx bdb.Tx, objType, prop string) error {
+	if err := Intern(tx, objType); err != nil {
+		return err
+	}
+
+	if err := bdb.SetKey(tx, bdb.PropertyIndexesPath, declarationKey(objType, prop), []byte{}); err != nil {
+		return err
+	}
+
+	typeID := NameID(tx, objType)
+
+	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath,
+		bdb.WithKeyFilter(ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: objType}).Key(tx)),
+	)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		obj := iter.Value()
+		key := bytes.Clone(iter.RawKey())
+
+		for _, value := range propertyValues(obj.GetProperties().GetFields()[prop]) {
+			if err := bdb.SetKey(tx, bdb.ObjectPropertiesPath, propertyKey(typeID, prop, value, obj.GetId()), key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dropPropertyIndex, removes the property index declaration and the index entries of the property.
+func dropPropertyIndex(tx bdb.Tx, objType, prop string) error {
+	if err := bdb.DeleteKey(tx, bdb.PropertyIndexesPath, declarationKey(objType, prop)); err != nil {
+		return err
+	}
+
+	b, err := bdb.SetBucket(tx, bdb.ObjectPropertiesPath)
+	if err != nil {
+		return nil //nolint:nilerr // nothing to drop.
+	}
+
+	var buf bytes.Buffer
+
+	writePropertyPrefix(&buf, NameID(tx, objType), prop)
+	prefix := buf.Bytes()
+
+	// collect the keys before deleting them, deleting keys while iterating a cursor skips keys.
+	var keys [][]byte
+
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, bytes.Clone(k))
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func declarationKey(objType, prop string) []byte {
+	var buf bytes.Buffer
+
+	writeString(&buf, objType)
+	writeString(&buf, prop)
+
+	return buf.Bytes()
+}
+
+func writePropertyPrefix(buf *bytes.Buffer, typeID uint64, prop string) {
+	writeUvarint(buf, typeID)
+	writeString(buf, prop)
+}
+
+func propertyKey(typeID uint64, prop, value, id string) []byte {
+	var buf bytes.Buffer
+
+	writePropertyPrefix(&buf, typeID, prop)
+	writeString(&buf, value)
+	writeString(&buf, id)
+
+	return buf.Bytes()
+}
+
+// propertyValues, returns the indexed string representations of the property value.
+func propertyValues(v *structpb.Value) []string {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return []string{k.StringValue}
+	case *structpb.Value_NumberValue:
+		return []string{strconv.FormatFloat(k.NumberValue, 'g', -1, 64)}
+	case *structpb.Value_BoolValue:
+		return []string{strconv.FormatBool(k.BoolValue)}
+	case *structpb.Value_ListValue:
+		values := []string{}
+
+		for _, e := range k.ListValue.GetValues() {
+			if _, ok := e.GetKind().(*structpb.Value_ListValue); ok {
+				continue
+			}
+
+			values = append(values, propertyValues(e)...)
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package ds
+
+import (
+	"context"
+	"net/http"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ReferentialIntegrity, enforcement mode of the existence of the object and subject of relation writes.
+type ReferentialIntegrity string
+
+const (
+	// ReferentialIntegrityNone, relations are stored without checking their object and subject exist (default).
+	ReferentialIntegrityNone ReferentialIntegrity = ""
+	// ReferentialIntegrityReject, relation writes referencing a non-existing object or subject are rejected.
+	ReferentialIntegrityReject ReferentialIntegrity = "reject"
+	// ReferentialIntegrityCreate, stub objects are created for the non-existing object and subject of relation writes.
+	ReferentialIntegrityCreate ReferentialIntegrity = "create"
+)
+
+var (
+	ErrReferencedObjectNotFound = cerr.NewAsertoError("E20076", codes.FailedPrecondition, http.StatusPreconditionFailed,
+		"relation references non-existing object")
+	ErrInvalidReferentialIntegrity = cerr.NewAsertoError("E20077", codes.InvalidArgument, http.StatusBadRequest,
+		"invalid referential integrity mode")
+)
+
+// Validate, returns ErrInvalidReferentialIntegrity when the mode is unknown.
+func (m ReferentialIntegrity) Validate() error {
+	switch m {
+	case ReferentialIntegrityNone, ReferentialIntegrityReject, ReferentialIntegrityCreate:
+		return nil
+	default:
+		return ErrInvalidReferentialIntegrity.Msg(string(m))
+	}
+}
+
+// EnsureRelationObjects, enforces the referential integrity mode for the object and subject of the relation,
+// inside the transaction writing the relation.
+// The reject mode returns ErrReferencedObjectNotFound when the object or subject does not exist,
+// the create mode creates a stub object, with only the type and id set, for the object or subject which does not exist.
+// The wildcard subject (subject id "*") does not reference an object, and is not checked.
+func EnsureRelationObjects(ctx context.Context, tx bdb.Tx, rel *dsc3.Relation, mode ReferentialIntegrity) error {
+	if mode == ReferentialIntegrityNone {
+		return nil
+	}
+
+	refs := []*dsc3.ObjectIdentifier{{ObjectType: rel.GetObjectType(), ObjectId: rel.GetObjectId()}}
+	if rel.GetSubjectId() != "*" {
+		refs = append(refs, &dsc3.ObjectIdentifier{ObjectType: rel.GetSubjectType(), ObjectId: rel.GetSubjectId()})
+	}
+
+	for _, ref := range refs {
+		obj, err := getObject(ctx, tx, ObjectIdentifier(ref).Key(tx))
+		if err != nil {
+			return err
+		}
+
+		if obj != nil {
+			continue
+		}
+
+		if mode == ReferentialIntegrityReject {
+			return ErrReferencedObjectNotFound.Msgf("object [%s:%s]", ref.GetObjectType(), ref.GetObjectId())
+		}
+
+		if err := setStubObject(ctx, tx, ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setStubObject(ctx context.Context, tx bdb.Tx, ref *dsc3.ObjectIdentifier) error {
+	stub := &dsc3.Object{Type: ref.GetObjectType(), Id: ref.GetObjectId()}
+	etag := Object(stub).Hash()
+
+	updObj, err := UpdateMetadataObject(ctx, tx, bdb.ObjectsPath, Object(stub).Key(tx), stub)
+	if err != nil {
+		return err
+	}
+
+	updObj.Etag = etag
+
+	_, err = SetObject(ctx, tx, updObj)
+
+	return err
+}
@@ -5,6 +5,7 @@ package ds
 import (
 	"bytes"
 	"strings"
+	"time"
 
 	"github.com/aserto-dev/azm/safe"
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
@@ -51,63 +52,104 @@ func GetRelations(i *dsr3.GetRelationsRequest) *relations {
 	return &relations{r, relation{r.SafeRelation}}
 }
 
-func (i *relation) Key() []byte {
-	return i.ObjKey()
+func (i *relation) Key(tx bdb.Tx) []byte {
+	return i.ObjKey(tx)
 }
 
-func (i *relation) ObjKey() []byte {
-	buf := newRelationBuffer()
+// relationIDs, interned name ids of the relation.
+type relationIDs struct {
+	objectType      uint64
+	relation        uint64
+	subjectType     uint64
+	subjectRelation uint64
+}
+
+// ids, resolves the interned name ids of the relation, returns false when a name which is set has not been interned.
+func (i *relation) ids(r nameResolver) (relationIDs, bool) {
+	ids := relationIDs{
+		objectType:      r.id(i.GetObjectType()),
+		relation:        r.id(i.GetRelation()),
+		subjectType:     r.id(i.GetSubjectType()),
+		subjectRelation: r.id(i.GetSubjectRelation()),
+	}
+
+	ok := (ids.objectType != 0 || i.GetObjectType() == "") &&
+		(ids.relation != 0 || i.GetRelation() == "") &&
+		(ids.subjectType != 0 || i.GetSubjectType() == "") &&
+		(ids.subjectRelation != 0 || i.GetSubjectRelation() == "")
 
-	buf.WriteString(i.GetObjectType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetObjectId())
+	return ids, ok
+}
 
-	buf.WriteByte(InstanceSeparator)
-	buf.WriteString(i.GetRelation())
-	buf.WriteByte(InstanceSeparator)
+// ObjKey
+// format: obj_type_id | len(obj_id) obj_id | relation_id | sub_type_id | len(sub_id) sub_id | sub_relation_id.
+func (i *relation) ObjKey(tx bdb.Tx) []byte {
+	return i.objKey(newNameResolver(tx))
+}
 
-	buf.WriteString(i.GetSubjectType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetSubjectId())
+func (i *relation) objKey(names nameResolver) []byte {
+	buf := newRelationBuffer()
 
-	if i.GetSubjectRelation() != "" {
-		buf.WriteByte(InstanceSeparator)
-		buf.WriteString(i.GetSubjectRelation())
+	ids, ok := i.ids(names)
+	if !ok {
+		writeNoMatch(buf)
+		return buf.Bytes()
 	}
 
+	writeObjectKey(buf, ids.objectType, i.GetObjectId())
+	writeUvarint(buf, ids.relation)
+	writeObjectKey(buf, ids.subjectType, i.GetSubjectId())
+	writeUvarint(buf, ids.subjectRelation)
+
 	return buf.Bytes()
 }
 
-func (i *relation) SubKey() []byte {
+// SubKey
+// format: sub_type_id | len(sub_id) sub_id | relation_id | obj_type_id | len(obj_id) obj_id | sub_relation_id.
+func (i *relation) SubKey(tx bdb.Tx) []byte {
 	buf := newRelationBuffer()
 
-	buf.WriteString(i.GetSubjectType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetSubjectId())
+	ids, ok := i.ids(newNameResolver(tx))
+	if !ok {
+		writeNoMatch(buf)
+		return buf.Bytes()
+	}
+
+	writeObjectKey(buf, ids.subjectType, i.GetSubjectId())
+	writeUvarint(buf, ids.relation)
+	writeObjectKey(buf, ids.objectType, i.GetObjectId())
+	writeUvarint(buf, ids.subjectRelation)
 
-	buf.WriteByte(InstanceSeparator)
-	buf.WriteString(i.GetRelation())
-	buf.WriteByte(InstanceSeparator)
+	return buf.Bytes()
+}
 
-	buf.WriteString(i.GetObjectType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetObjectId())
+// RelKey, relation name index key
+// format: obj_type_id | relation_id | sub_type_id | len(sub_id) sub_id | sub_relation_id | len(obj_id) obj_id.
+func (i *relation) RelKey(tx bdb.Tx) []byte {
+	buf := newRelationBuffer()
 
-	if i.GetSubjectRelation() != "" {
-		buf.WriteByte(InstanceSeparator)
-		buf.WriteString(i.GetSubjectRelation())
+	ids, ok := i.ids(newNameResolver(tx))
+	if !ok {
+		writeNoMatch(buf)
+		return buf.Bytes()
 	}
 
+	writeUvarint(buf, ids.objectType)
+	writeUvarint(buf, ids.relation)
+	writeObjectKey(buf, ids.subjectType, i.GetSubjectId())
+	writeUvarint(buf, ids.subjectRelation)
+	writeString(buf, i.GetObjectId())
+
 	return buf.Bytes()
 }
 
-func (i *relation) PathAndFilter(filter *bytes.Buffer) ([]string, error) {
+func (i *relation) PathAndFilter(tx bdb.Tx, filter *bytes.Buffer) ([]string, error) {
 	switch {
 	case ObjectSelector(i.Object()).IsComplete():
-		i.ObjFilter(filter)
+		i.ObjFilter(tx, filter)
 		return bdb.RelationsObjPath, nil
 	case ObjectSelector(i.Subject()).IsComplete():
-		i.SubFilter(filter)
+		i.SubFilter(tx, filter)
 		return bdb.RelationsSubPath, nil
 	default:
 		return []string{}, ErrNoCompleteObjectIdentifier
@@ -115,66 +157,110 @@ func (i *relation) PathAndFilter(filter *bytes.Buffer) ([]string, error) {
 }
 
 // ObjFilter
-// format: obj_type : obj_id # relation @ sub_type : sub_id (# sub_relation).
-func (i *relation) ObjFilter(buf *bytes.Buffer) {
-	buf.WriteString(i.GetObjectType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetObjectId())
-	buf.WriteByte(InstanceSeparator)
+// format: obj_type_id | len(obj_id) obj_id | relation_id | sub_type_id | len(sub_id) sub_id | sub_relation_id.
+func (i *relation) ObjFilter(tx bdb.Tx, buf *bytes.Buffer) {
+	ids, ok := i.ids(newNameResolver(tx))
+	if !ok {
+		writeNoMatch(buf)
+		return
+	}
+
+	writeObjectKey(buf, ids.objectType, i.GetObjectId())
 
 	if IsNotSet(i.GetRelation()) {
 		return
 	}
 
-	buf.WriteString(i.GetRelation())
-	buf.WriteByte(InstanceSeparator)
+	writeUvarint(buf, ids.relation)
 
 	if IsNotSet(i.GetSubjectType()) {
 		return
 	}
 
-	buf.WriteString(i.GetSubjectType())
-	buf.WriteByte(TypeIDSeparator)
+	writeUvarint(buf, ids.subjectType)
 
 	if IsNotSet(i.GetSubjectId()) {
 		return
 	}
 
-	buf.WriteString(i.GetSubjectId())
+	writeString(buf, i.GetSubjectId())
+
+	if i.HasSubjectRelation {
+		writeUvarint(buf, ids.subjectRelation)
+	}
 }
 
 // SubFilter
-// format: sub_type : sub_id (# sub_relation) | obj_type : obj_id # relation.
-func (i *relation) SubFilter(buf *bytes.Buffer) {
-	buf.WriteString(i.GetSubjectType())
-	buf.WriteByte(TypeIDSeparator)
-	buf.WriteString(i.GetSubjectId())
-	buf.WriteByte(InstanceSeparator)
+// format: sub_type_id | len(sub_id) sub_id | relation_id | obj_type_id | len(obj_id) obj_id | sub_relation_id.
+func (i *relation) SubFilter(tx bdb.Tx, buf *bytes.Buffer) {
+	ids, ok := i.ids(newNameResolver(tx))
+	if !ok {
+		writeNoMatch(buf)
+		return
+	}
+
+	writeObjectKey(buf, ids.subjectType, i.GetSubjectId())
 
 	if IsNotSet(i.GetRelation()) {
 		return
 	}
 
-	buf.WriteString(i.GetRelation())
-	buf.WriteByte(InstanceSeparator)
+	writeUvarint(buf, ids.relation)
 
 	if IsNotSet(i.GetObjectType()) {
 		return
 	}
 
-	buf.WriteString(i.GetObjectType())
-	buf.WriteByte(TypeIDSeparator)
+	writeUvarint(buf, ids.objectType)
 
 	if IsNotSet(i.GetObjectId()) {
 		return
 	}
 
-	buf.WriteString(i.GetObjectId())
+	writeString(buf, i.GetObjectId())
+
+	if i.HasSubjectRelation {
+		writeUvarint(buf, ids.subjectRelation)
+	}
+}
+
+// RelFilter
+// format: obj_type_id | relation_id | sub_type_id | len(sub_id) sub_id | sub_relation_id | len(obj_id) obj_id.
+func (i *relation) RelFilter(tx bdb.Tx, buf *bytes.Buffer) {
+	ids, ok := i.ids(newNameResolver(tx))
+	if !ok {
+		writeNoMatch(buf)
+		return
+	}
+
+	writeUvarint(buf, ids.objectType)
+	writeUvarint(buf, ids.relation)
+
+	if IsNotSet(i.GetSubjectType()) {
+		return
+	}
+
+	writeUvarint(buf, ids.subjectType)
+
+	if IsNotSet(i.GetSubjectId()) {
+		return
+	}
+
+	writeString(buf, i.GetSubjectId())
+
+	if i.HasSubjectRelation {
+		writeUvarint(buf, ids.subjectRelation)
+	}
+}
+
+// hasTypeAndRelation, returns true when the object type and relation are set, which allows using the relation name index.
+func (i *relation) hasTypeAndRelation() bool {
+	return IsSet(i.GetObjectType()) && IsSet(i.GetRelation())
 }
 
 const relationFilterCount int = 6
 
-func (i *relation) Filter(keyFilter *bytes.Buffer) (bdb.Path, func(*dsc3.RelationIdentifier) bool) {
+func (i *relation) Filter(tx bdb.Tx, keyFilter *bytes.Buffer) (bdb.Path, func(*dsc3.RelationIdentifier) bool) {
 	var (
 		path        bdb.Path
 		valueFilter func(*dsc3.RelationIdentifier) bool
@@ -183,16 +269,21 @@ func (i *relation) Filter(keyFilter *bytes.Buffer) (bdb.Path, func(*dsc3.Relatio
 	// #1  determine if object identifier is complete (has type+id)
 	// set index path accordingly
 	// set keyFilter to match covering path
-	// when no complete object identifier, fallback to a full table scan
+	// when no complete object identifier, use the relation name index when the object type and relation are set,
+	// otherwise fallback to a full table scan
 	switch {
 	case ObjectIdentifier(i.Object()).IsComplete():
 		path = bdb.RelationsObjPath
 
-		i.ObjFilter(keyFilter)
+		i.ObjFilter(tx, keyFilter)
 	case ObjectIdentifier(i.Subject()).IsComplete():
 		path = bdb.RelationsSubPath
 
-		i.SubFilter(keyFilter)
+		i.SubFilter(tx, keyFilter)
+	case i.hasTypeAndRelation():
+		path = bdb.RelationsRelPath
+
+		i.RelFilter(tx, keyFilter)
 	default:
 		path = bdb.RelationsObjPath
 	}
@@ -257,7 +348,66 @@ func (i *relation) Filter(keyFilter *bytes.Buffer) (bdb.Path, func(*dsc3.Relatio
 	return path, valueFilter
 }
 
-func (i *relation) RelationValueFilter(keyFilter *bytes.Buffer) (bdb.Path, func(*dsc3.Relation) bool) {
+// KeyMatch, returns a key match function for the path returned by Filter, which decodes the key and rejects
+// keys which do not match the relation filter, without unmarshalling the value.
+// Returns nil when the relation filter does not constrain the key.
+func (i *relation) KeyMatch(tx bdb.Tx, path bdb.Path) func([]byte) bool {
+	ids, ok := i.ids(newNameResolver(tx))
+	if !ok {
+		return func(_ []byte) bool { return false }
+	}
+
+	var (
+		objectID  = []byte(i.GetObjectId())
+		subjectID = []byte(i.GetSubjectId())
+		checks    = make([]func(*relationKey) bool, 0, relationFilterCount)
+	)
+
+	if IsSet(i.GetObjectType()) {
+		checks = append(checks, func(k *relationKey) bool { return k.objectType == ids.objectType })
+	}
+
+	if IsSet(i.GetObjectId()) {
+		checks = append(checks, func(k *relationKey) bool { return bytes.Equal(k.objectID, objectID) })
+	}
+
+	if IsSet(i.GetRelation()) {
+		checks = append(checks, func(k *relationKey) bool { return k.relation == ids.relation })
+	}
+
+	if IsSet(i.GetSubjectType()) {
+		checks = append(checks, func(k *relationKey) bool { return k.subjectType == ids.subjectType })
+	}
+
+	if IsSet(i.GetSubjectId()) {
+		checks = append(checks, func(k *relationKey) bool { return bytes.Equal(k.subjectID, subjectID) })
+	}
+
+	if i.HasSubjectRelation {
+		checks = append(checks, func(k *relationKey) bool { return k.subjectRelation == ids.subjectRelation })
+	}
+
+	if len(checks) == 0 {
+		return nil
+	}
+
+	return func(key []byte) bool {
+		k, ok := decodeRelationKey(path, key)
+		if !ok {
+			return false
+		}
+
+		for _, check := range checks {
+			if !check(&k) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+func (i *relation) RelationValueFilter(tx bdb.Tx, keyFilter *bytes.Buffer) (bdb.Path, func(*dsc3.Relation) bool) {
 	var (
 		path        bdb.Path
 		valueFilter func(*dsc3.Relation) bool
@@ -266,16 +416,21 @@ func (i *relation) RelationValueFilter(keyFilter *bytes.Buffer) (bdb.Path, func(
 	// #1  determine if object identifier is complete (has type+id)
 	// set index path accordingly
 	// set keyFilter to match covering path
-	// when no complete object identifier, fallback to a full table scan
+	// when no complete object identifier, use the relation name index when the object type and relation are set,
+	// otherwise fallback to a full table scan
 	switch {
 	case ObjectIdentifier(i.Object()).IsComplete():
 		path = bdb.RelationsObjPath
 
-		i.ObjFilter(keyFilter)
+		i.ObjFilter(tx, keyFilter)
 	case ObjectIdentifier(i.Subject()).IsComplete():
 		path = bdb.RelationsSubPath
 
-		i.SubFilter(keyFilter)
+		i.SubFilter(tx, keyFilter)
+	case i.hasTypeAndRelation():
+		path = bdb.RelationsRelPath
+
+		i.RelFilter(tx, keyFilter)
 	default:
 		path = bdb.RelationsObjPath
 	}
@@ -337,7 +492,8 @@ func (i *relation) RelationValueFilter(keyFilter *bytes.Buffer) (bdb.Path, func(
 		return true
 	}
 
-	return path, valueFilter
+	// expired relations, which are not deleted by the reaper yet, are ignored like they are by checks.
+	return path, withoutExpired(tx, time.Now().UTC(), valueFilter)
 }
 
 func newRelationBuffer() *bytes.Buffer {
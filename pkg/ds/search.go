@@ -0,0 +1,331 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+
+	cerr "github.com/aserto-dev/errors"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"google.golang.org/grpc/codes"
+)
+
+// The search index is an inverted index mapping the terms of the display name and of the declared search properties
+// of an object to the object, declared per object type and maintained by the write path (SetObject, DeleteObject).
+//
+// store layout:
+// _system/search_properties	key: len(obj_type) obj_type | len(property) property		value: empty
+// objects_search		key: term | 0x00 | type_id | id					value: object key
+//
+// terms are the lower cased sequences of letters and digits of the indexed values, the terms are not length prefixed,
+// which allows scanning the index by term prefix.
+
+const (
+	SearchModePrefix string = "prefix"
+	SearchModeTerm   string = "term"
+
+	termSeparator byte = 0x00
+)
+
+var ErrInvalidSearch = cerr.NewAsertoError("E20072", codes.InvalidArgument, http.StatusBadRequest, "invalid search")
+
+// Search, full-text search of the objects of the object type (all object types when empty),
+// matching all the terms of the query, in SearchModeTerm a query term matches equal object terms,
+// in SearchModePrefix a query term matches object terms starting with the query term.
+type Search struct {
+	ObjectType string
+	Mode       string
+	Terms      []string
+}
+
+// NewSearch, returns the search of the query, returns ErrInvalidSearch when the query contains no terms
+// or the search mode is unknown.
+func NewSearch(objType, query, mode string) (*Search, error) {
+	if mode == "" {
+		mode = SearchModePrefix
+	}
+
+	if mode != SearchModePrefix && mode != SearchModeTerm {
+		return nil, ErrInvalidSearch.Msgf("unknown search mode %q", mode)
+	}
+
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, ErrInvalidSearch.Msgf("query %q contains no terms", query)
+	}
+
+	return &Search{ObjectType: objType, Mode: mode, Terms: terms}, nil
+}
+
+// Fingerprint, returns the fields binding a page token to the search.
+func (s *Search) Fingerprint() []string {
+	return append([]string{s.ObjectType, s.Mode}, s.Terms...)
+}
+
+// KeyFilter, returns the key prefix of the index entries of the scanned term,
+// the longest query term, which is the most selective one.
+func (s *Search) KeyFilter() []byte {
+	buf := bytes.NewBufferString(s.scanTerm())
+
+	if s.Mode == SearchModeTerm {
+		buf.WriteByte(termSeparator)
+	}
+
+	return buf.Bytes()
+}
+
+// Match, returns true when the index entry, read by scanning the key filter, is the single matching entry of the object.
+func (s *Search) Match(tx bdb.Tx, key []byte, obj *dsc3.Object) bool {
+	if s.ObjectType != "" && obj.GetType() != s.ObjectType {
+		return false
+	}
+
+	term, _, ok := bytes.Cut(key, []byte{termSeparator})
+	if !ok {
+		return false
+	}
+
+	terms := objectTerms(obj, searchProperties(tx, obj.GetType()))
+
+	for _, t := range s.Terms {
+		if !slices.ContainsFunc(terms, func(o string) bool { return s.matchTerm(t, o) }) {
+			return false
+		}
+	}
+
+	// an object is indexed by every term matching the scanned prefix, only the first one (in key order) is returned.
+	scanTerm := s.scanTerm()
+	first := slices.IndexFunc(terms, func(o string) bool { return s.matchTerm(scanTerm, o) })
+
+	return first >= 0 && terms[first] == string(term)
+}
+
+func (s *Search) matchTerm(query, term string) bool {
+	if s.Mode == SearchModeTerm {
+		return term == query
+	}
+
+	return strings.HasPrefix(term, query)
+}
+
+func (s *Search) scanTerm() string {
+	term := s.Terms[0]
+
+	for _, t := range s.Terms[1:] {
+		if len(t) > len(term) {
+			term = t
+		}
+	}
+
+	return term
+}
+
+// SyncSearchProperties, aligns the declared search properties with the given declarations, the objects of the
+// object types of which the declared search properties changed are reindexed.
+func SyncSearchProperties(ctx context.Context, tx bdb.Tx, props map[string][]string) error {
+	declared := declaredSearchProperties(tx)
+
+	objTypes := slices.Collect(maps.Keys(declared))
+	for objType := range props {
+		objTypes = append(objTypes, objType)
+	}
+
+	slices.Sort(objTypes)
+
+	for _, objType := range slices.Compact(objTypes) {
+		if slices.Equal(sorted(declared[objType]), sorted(props[objType])) {
+			continue
+		}
+
+		if err := reindexSearchProperties(ctx, tx, objType, declared[objType], props[objType]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BuildSearchIndex, indexes the display names and declared search properties of all objects.
+func BuildSearchIndex(ctx context.Context, tx bdb.Tx) error {
+	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		if err := setSearchKeys(tx, iter.Value(), bytes.Clone(iter.RawKey())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setSearchKeys, adds the object key to the search index entries of the terms of the object.
+func setSearchKeys(tx bdb.Tx, obj *dsc3.Object, key []byte) error {
+	return updateSearchKeys(tx, obj, searchProperties(tx, obj.GetType()), func(b bdb.Bucket, k []byte) error { return b.Put(k, key) })
+}
+
+// deleteSearchKeys, removes the object key from the search index entries of the terms of the object.
+func deleteSearchKeys(tx bdb.Tx, obj *dsc3.Object) error {
+	return updateSearchKeys(tx, obj, searchProperties(tx, obj.GetType()), func(b bdb.Bucket, k []byte) error { return b.Delete(k) })
+}
+
+func updateSearchKeys(tx bdb.Tx, obj *dsc3.Object, props []string, fn func(bdb.Bucket, []byte) error) error {
+	terms := objectTerms(obj, props)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	b, err := bdb.SetBucket(tx, bdb.ObjectsSearchPath)
+	if err != nil {
+		return err
+	}
+
+	typeID := NameID(tx, obj.GetType())
+
+	for _, term := range terms {
+		if err := fn(b, searchKey(term, typeID, obj.GetId())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reindexSearchProperties, replaces the search index entries of the objects of the object type,
+// derived from the previously declared properties, by the entries derived from the newly declared properties.
+func reindexSearchProperties(ctx context.Context, tx bdb.Tx, objType string, prev, next []string) error {
+	if err := Intern(tx, objType); err != nil {
+		return err
+	}
+
+	for _, prop := range prev {
+		if err := bdb.DeleteKey(tx, bdb.SearchPropertiesPath, declarationKey(objType, prop)); err != nil {
+			return err
+		}
+	}
+
+	for _, prop := range next {
+		if err := bdb.SetKey(tx, bdb.SearchPropertiesPath, declarationKey(objType, prop), []byte{}); err != nil {
+			return err
+		}
+	}
+
+	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath,
+		bdb.WithKeyFilter(ObjectIdentifier(&dsc3.ObjectIdentifier{ObjectType: objType}).Key(tx)),
+	)
+	if err != nil {
+		return err
+	}
+
+	for iter.Next() {
+		obj := iter.Value()
+		key := bytes.Clone(iter.RawKey())
+
+		if err := updateSearchKeys(tx, obj, prev, func(b bdb.Bucket, k []byte) error { return b.Delete(k) }); err != nil {
+			return err
+		}
+
+		if err := updateSearchKeys(tx, obj, next, func(b bdb.Bucket, k []byte) error { return b.Put(k, key) }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// declaredSearchProperties, returns the declared search properties, by object type.
+func declaredSearchProperties(tx bdb.Tx) map[string][]string {
+	props := map[string][]string{}
+
+	b, err := bdb.SetBucket(tx, bdb.SearchPropertiesPath)
+	if err != nil {
+		return props
+	}
+
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		r := keyReader{key: k, ok: true}
+
+		objType, prop := r.bytes(), r.bytes()
+		if !r.done() {
+			continue
+		}
+
+		props[string(objType)] = append(props[string(objType)], string(prop))
+	}
+
+	return props
+}
+
+// searchProperties, returns the declared search properties of the object type.
+func searchProperties(tx bdb.Tx, objType string) []string {
+	b, err := bdb.SetBucket(tx, bdb.SearchPropertiesPath)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		buf   bytes.Buffer
+		props []string
+	)
+
+	writeString(&buf, objType)
+	prefix := buf.Bytes()
+
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		r := keyReader{key: k[len(prefix):], ok: true}
+
+		if prop := r.bytes(); r.done() {
+			props = append(props, string(prop))
+		}
+	}
+
+	return props
+}
+
+// objectTerms, returns the sorted, distinct terms of the display name and the search properties of the object.
+func objectTerms(obj *dsc3.Object, props []string) []string {
+	terms := searchTerms(obj.GetDisplayName())
+
+	for _, prop := range props {
+		for _, value := range propertyValues(obj.GetProperties().GetFields()[prop]) {
+			terms = append(terms, searchTerms(value)...)
+		}
+	}
+
+	slices.Sort(terms)
+
+	return slices.Compact(terms)
+}
+
+// searchTerms, returns the lower cased sequences of letters and digits of the text.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func searchKey(term string, typeID uint64, id string) []byte {
+	buf := bytes.NewBufferString(term)
+
+	buf.WriteByte(termSeparator)
+	writeUvarint(buf, typeID)
+	buf.WriteString(id)
+
+	return buf.Bytes()
+}
+
+func sorted(s []string) []string {
+	s = slices.Clone(s)
+	slices.Sort(s)
+
+	return s
+}
@@ -1,19 +1,53 @@
 package ds
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"strings"
 	"sync/atomic"
 
 	"github.com/aserto-dev/azm/model"
 	"github.com/aserto-dev/azm/stats"
 	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
 	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+)
+
+// Statistics count the objects per object type and the relations per object type, relation, subject type
+// and subject relation. The counters are maintained by the write path, inside the transaction of the write,
+// so reading the statistics does not require a scan of the objects and relations.
+//
+// store layout:
+// stats/o|{obj_type}						-- contains the big-endian object count of the object type
+// stats/r|{obj_type}|{relation}|{sub_type}|{sub_relation}	-- contains the big-endian relation count
+//
+// The subject type of relations with a wildcard subject id is suffixed with ":*", a relation without
+// subject relation has an empty sub_relation component. Counters which drop to zero are removed.
 
-	bolt "go.etcd.io/bbolt"
+const (
+	statsObjectPrefix   byte = 'o'
+	statsRelationPrefix byte = 'r'
+	statsCounterSize    int  = 8
 )
 
-// CalculateStats returns a Stats object with the counts of all objects and relations.
-func CalculateStats(ctx context.Context, tx *bolt.Tx) (*stats.Stats, error) {
+// CalculateStats, returns the statistics of the objects and relations, read from the stats counters.
+func CalculateStats(ctx context.Context, tx bdb.Tx) (*stats.Stats, error) {
+	s := NewStats()
+
+	if err := s.LoadObjects(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if err := s.LoadRelations(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	return s.Stats, nil
+}
+
+// RepairStats, recomputes the stats counters by counting all objects and relations,
+// returns the recomputed statistics.
+func RepairStats(ctx context.Context, tx bdb.Tx) (*stats.Stats, error) {
 	s := NewStats()
 
 	if err := s.CountObjects(ctx, tx); err != nil {
@@ -24,9 +58,171 @@ func CalculateStats(ctx context.Context, tx *bolt.Tx) (*stats.Stats, error) {
 		return nil, err
 	}
 
+	if err := bdb.DeleteBucket(tx, bdb.StatsPath); err != nil {
+		return nil, err
+	}
+
+	b, err := bdb.CreateBucket(tx, bdb.StatsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for on, ot := range s.ObjectTypes {
+		if ot.ObjCount == 0 {
+			continue
+		}
+
+		if err := b.Put(objectStatsKey(string(on)), counterBytes(int64(ot.ObjCount))); err != nil {
+			return nil, err
+		}
+	}
+
+	for on, ot := range s.ObjectTypes {
+		for rn, re := range ot.Relations {
+			for sn, st := range re.SubjectTypes {
+				// relations without subject relation, are the subject type relations not counted by a subject relation.
+				count := st.Count
+
+				for srn, sr := range st.SubjectRelations {
+					count -= sr.Count
+
+					if err := b.Put(relationStatsKey(string(on), string(rn), string(sn), string(srn)), counterBytes(int64(sr.Count))); err != nil {
+						return nil, err
+					}
+				}
+
+				if count == 0 {
+					continue
+				}
+
+				if err := b.Put(relationStatsKey(string(on), string(rn), string(sn), ""), counterBytes(int64(count))); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	return s.Stats, nil
 }
 
+// LoadObjects, adds the object counts of the stats counters.
+func (s *Stats) LoadObjects(ctx context.Context, tx bdb.Tx) error {
+	return scanStatsCounters(ctx, tx, statsObjectPrefix, func(names []string, count int64) {
+		if len(names) != 1 {
+			return
+		}
+
+		ot := s.objectType(model.ObjectName(names[0]))
+		ot.ObjCount += int32(count) //nolint:gosec // G115: counts are bound by the store size.
+	})
+}
+
+// LoadRelations, adds the relation counts of the stats counters.
+func (s *Stats) LoadRelations(ctx context.Context, tx bdb.Tx) error {
+	const components = 4
+
+	return scanStatsCounters(ctx, tx, statsRelationPrefix, func(names []string, count int64) {
+		if len(names) != components {
+			return
+		}
+
+		s.addRelation(
+			model.ObjectName(names[0]),
+			model.RelationName(names[1]),
+			model.ObjectName(names[2]),
+			model.RelationName(names[3]),
+			int32(count), //nolint:gosec // G115: counts are bound by the store size.
+		)
+	})
+}
+
+func scanStatsCounters(ctx context.Context, tx bdb.Tx, prefix byte, fn func(names []string, count int64)) error {
+	b, err := bdb.SetBucket(tx, bdb.StatsPath)
+	if err != nil {
+		return err
+	}
+
+	seek := []byte{prefix, InstanceSeparator}
+
+	c := b.Cursor()
+	for k, v := c.Seek(seek); k != nil && bytes.HasPrefix(k, seek); k, v = c.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if len(v) != statsCounterSize {
+			continue
+		}
+
+		fn(strings.Split(string(k[len(seek):]), string(InstanceSeparator)), counterValue(v))
+	}
+
+	return nil
+}
+
+// incObjectStats, adds delta to the object count of the object type.
+func incObjectStats(tx bdb.Tx, obj *dsc3.Object, delta int64) error {
+	return addStatsCounter(tx, objectStatsKey(obj.GetType()), delta)
+}
+
+// incRelationStats, adds delta to the relation count of the object type, relation, subject type and subject relation.
+func incRelationStats(tx bdb.Tx, rel *dsc3.Relation, delta int64) error {
+	return addStatsCounter(tx, relationStatsKey(
+		rel.GetObjectType(),
+		rel.GetRelation(),
+		statsSubjectType(rel),
+		rel.GetSubjectRelation(),
+	), delta)
+}
+
+func addStatsCounter(tx bdb.Tx, key []byte, delta int64) error {
+	b, err := bdb.CreateBucket(tx, bdb.StatsPath)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if v := b.Get(key); len(v) == statsCounterSize {
+		count = counterValue(v)
+	}
+
+	count += delta
+
+	if count <= 0 {
+		return b.Delete(key)
+	}
+
+	return b.Put(key, counterBytes(count))
+}
+
+func objectStatsKey(objType string) []byte {
+	return append([]byte{statsObjectPrefix, InstanceSeparator}, objType...)
+}
+
+func relationStatsKey(objType, relation, subType, subRelation string) []byte {
+	return []byte(strings.Join([]string{string(statsRelationPrefix), objType, relation, subType, subRelation}, string(InstanceSeparator)))
+}
+
+// statsSubjectType, returns the subject type name used by the statistics, wildcard subjects are counted separately.
+func statsSubjectType(rel *dsc3.Relation) string {
+	if rel.GetSubjectId() == "*" {
+		return rel.GetSubjectType() + ":*"
+	}
+
+	return rel.GetSubjectType()
+}
+
+func counterBytes(count int64) []byte {
+	buf := make([]byte, statsCounterSize)
+	binary.BigEndian.PutUint64(buf, uint64(count)) //nolint:gosec // G115: counters are never negative.
+
+	return buf
+}
+
+func counterValue(v []byte) int64 {
+	return int64(binary.BigEndian.Uint64(v)) //nolint:gosec // G115: counters are never negative.
+}
+
 type Stats struct {
 	*stats.Stats
 }
@@ -35,7 +231,7 @@ func NewStats() *Stats {
 	return &Stats{stats.NewStats()}
 }
 
-func (s *Stats) CountObjects(ctx context.Context, tx *bolt.Tx) error {
+func (s *Stats) CountObjects(ctx context.Context, tx bdb.Tx) error {
 	iter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath)
 	if err != nil {
 		return err
@@ -49,7 +245,7 @@ func (s *Stats) CountObjects(ctx context.Context, tx *bolt.Tx) error {
 	return nil
 }
 
-func (s *Stats) CountRelations(ctx context.Context, tx *bolt.Tx) error {
+func (s *Stats) CountRelations(ctx context.Context, tx bdb.Tx) error {
 	iter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, bdb.RelationsObjPath)
 	if err != nil {
 		return err
@@ -64,28 +260,22 @@ func (s *Stats) CountRelations(ctx context.Context, tx *bolt.Tx) error {
 }
 
 func (s *Stats) incObject(obj *dsc3.Object) {
-	ot, ok := s.ObjectTypes[model.ObjectName(obj.GetType())]
-	if !ok {
-		ot = &stats.ObjectType{
-			Relations: stats.Relations{},
-		}
-		s.ObjectTypes[model.ObjectName(obj.GetType())] = ot
-	}
+	ot := s.objectType(model.ObjectName(obj.GetType()))
 
 	atomic.AddInt32(&ot.ObjCount, 1)
 }
 
 func (s *Stats) incRelation(rel *dsc3.Relation) {
-	objType := model.ObjectName(rel.GetObjectType())
-	relation := model.RelationName(rel.GetRelation())
-	subType := model.ObjectName(rel.GetSubjectType())
-	subRel := model.RelationName(rel.GetSubjectRelation())
-
-	if rel.GetSubjectId() == "*" {
-		subType += ":*"
-	}
+	s.addRelation(
+		model.ObjectName(rel.GetObjectType()),
+		model.RelationName(rel.GetRelation()),
+		model.ObjectName(statsSubjectType(rel)),
+		model.RelationName(rel.GetSubjectRelation()),
+		1,
+	)
+}
 
-	// object_types
+func (s *Stats) objectType(objType model.ObjectName) *stats.ObjectType {
 	ot := s.ObjectTypes[objType]
 	if ot == nil {
 		ot = &stats.ObjectType{
@@ -94,7 +284,14 @@ func (s *Stats) incRelation(rel *dsc3.Relation) {
 		s.ObjectTypes[objType] = ot
 	}
 
-	atomic.AddInt32(&ot.Count, 1)
+	return ot
+}
+
+func (s *Stats) addRelation(objType model.ObjectName, relation model.RelationName, subType model.ObjectName, subRel model.RelationName, n int32) {
+	// object_types
+	ot := s.objectType(objType)
+
+	atomic.AddInt32(&ot.Count, n)
 
 	// relations
 	re := ot.Relations[relation]
@@ -105,7 +302,7 @@ func (s *Stats) incRelation(rel *dsc3.Relation) {
 		ot.Relations[relation] = re
 	}
 
-	atomic.AddInt32(&re.Count, 1)
+	atomic.AddInt32(&re.Count, n)
 
 	// subject_types
 	st := re.SubjectTypes[subType]
@@ -116,7 +313,7 @@ func (s *Stats) incRelation(rel *dsc3.Relation) {
 		re.SubjectTypes[subType] = st
 	}
 
-	atomic.AddInt32(&st.Count, 1)
+	atomic.AddInt32(&st.Count, n)
 
 	// subject_relations
 	if subRel != "" {
@@ -126,6 +323,6 @@ func (s *Stats) incRelation(rel *dsc3.Relation) {
 			st.SubjectRelations[subRel] = sr
 		}
 
-		atomic.AddInt32(&sr.Count, 1)
+		atomic.AddInt32(&sr.Count, n)
 	}
 }
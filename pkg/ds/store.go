@@ -0,0 +1,294 @@
+package ds
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// store contains the single write path for objects and relations.
+//
+// All writers (writer, importer, datasync) persist instances using the functions below,
+// which keep the primary buckets, the derived index buckets, the stats counters and the change log consistent
+// inside the same transaction.
+
+// SetObject, persists the object instance in the objects bucket and maintains the object indexes and counters,
+// the object type is interned before the object key is constructed.
+func SetObject(ctx context.Context, tx bdb.Tx, obj *dsc3.Object) (*dsc3.Object, error) {
+	if err := Intern(tx, obj.GetType()); err != nil {
+		return nil, err
+	}
+
+	key := Object(obj).Key(tx)
+
+	cur, err := getObject(ctx, tx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cur != nil {
+		if err := deleteTimestampKeys(tx, bdb.ObjectsCreatedAtPath, bdb.ObjectsUpdatedAtPath, cur, key); err != nil {
+			return nil, err
+		}
+
+		if err := deletePropertyKeys(tx, cur, key); err != nil {
+			return nil, err
+		}
+
+		if err := deleteSearchKeys(tx, cur); err != nil {
+			return nil, err
+		}
+	} else if err := incObjectStats(tx, obj, 1); err != nil {
+		return nil, err
+	}
+
+	if _, err := bdb.Set(ctx, tx, bdb.ObjectsPath, key, obj); err != nil {
+		return nil, err
+	}
+
+	if err := setTimestampKeys(tx, bdb.ObjectsCreatedAtPath, bdb.ObjectsUpdatedAtPath, obj, key); err != nil {
+		return nil, err
+	}
+
+	if err := setPropertyKeys(tx, obj, key); err != nil {
+		return nil, err
+	}
+
+	if err := setSearchKeys(tx, obj, key); err != nil {
+		return nil, err
+	}
+
+	// a (re)created instance supersedes a previously recorded tombstone.
+	if err := deleteObjectTombstone(ctx, tx, key); err != nil {
+		return nil, err
+	}
+
+	if _, err := AppendChange(tx, ChangeObjectSet, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// DeleteObject, removes the object instance from the objects bucket, the object indexes and counters,
+// and records a tombstone for the deleted instance.
+// Deleting a non-existing object instance does not raise an error.
+func DeleteObject(ctx context.Context, tx bdb.Tx, oid *dsc3.ObjectIdentifier) error {
+	key := ObjectIdentifier(oid).Key(tx)
+
+	cur, err := getObject(ctx, tx, key)
+	if err != nil {
+		return err
+	}
+
+	if cur == nil {
+		return nil
+	}
+
+	if err := deleteTimestampKeys(tx, bdb.ObjectsCreatedAtPath, bdb.ObjectsUpdatedAtPath, cur, key); err != nil {
+		return err
+	}
+
+	if err := deletePropertyKeys(tx, cur, key); err != nil {
+		return err
+	}
+
+	if err := deleteSearchKeys(tx, cur); err != nil {
+		return err
+	}
+
+	if err := setObjectTombstone(ctx, tx, key, cur); err != nil {
+		return err
+	}
+
+	if err := incObjectStats(tx, cur, -1); err != nil {
+		return err
+	}
+
+	if _, err := AppendChange(tx, ChangeObjectDelete, cur); err != nil {
+		return err
+	}
+
+	return bdb.Delete(ctx, tx, bdb.ObjectsPath, key)
+}
+
+// SetRelation, persists the relation instance in the relations_obj, relations_sub and relations_rel buckets
+// and maintains the relation indexes and counters,
+// the object types and relation names are interned before the relation keys are constructed.
+func SetRelation(ctx context.Context, tx bdb.Tx, rel *dsc3.Relation) (*dsc3.Relation, error) {
+	if err := Intern(tx, rel.GetObjectType(), rel.GetRelation(), rel.GetSubjectType(), rel.GetSubjectRelation()); err != nil {
+		return nil, err
+	}
+
+	r := Relation(rel)
+	objKey := r.ObjKey(tx)
+
+	cur, err := getRelation(ctx, tx, objKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if cur != nil {
+		if err := deleteTimestampKeys(tx, bdb.RelationsCreatedAtPath, bdb.RelationsUpdatedAtPath, cur, objKey); err != nil {
+			return nil, err
+		}
+	} else if err := incRelationStats(tx, rel, 1); err != nil {
+		return nil, err
+	}
+
+	if _, err := bdb.Set(ctx, tx, bdb.RelationsObjPath, objKey, rel); err != nil {
+		return nil, err
+	}
+
+	if _, err := bdb.Set(ctx, tx, bdb.RelationsSubPath, r.SubKey(tx), rel); err != nil {
+		return nil, err
+	}
+
+	if _, err := bdb.Set(ctx, tx, bdb.RelationsRelPath, r.RelKey(tx), rel); err != nil {
+		return nil, err
+	}
+
+	if err := setTimestampKeys(tx, bdb.RelationsCreatedAtPath, bdb.RelationsUpdatedAtPath, rel, objKey); err != nil {
+		return nil, err
+	}
+
+	// a (re)created instance supersedes a previously recorded tombstone.
+	if err := deleteRelationTombstone(ctx, tx, objKey); err != nil {
+		return nil, err
+	}
+
+	if _, err := AppendChange(tx, ChangeRelationSet, rel); err != nil {
+		return nil, err
+	}
+
+	return rel, nil
+}
+
+// DeleteRelation, removes the relation instance from the relations_obj, relations_sub and relations_rel buckets, the relation indexes and counters,
+// removes its expiration time, and records a tombstone for the deleted instance.
+// Deleting a non-existing relation instance does not raise an error.
+func DeleteRelation(ctx context.Context, tx bdb.Tx, rel *dsc3.Relation) error {
+	r := Relation(rel)
+	objKey := r.ObjKey(tx)
+
+	cur, err := getRelation(ctx, tx, objKey)
+	if err != nil {
+		return err
+	}
+
+	if cur != nil {
+		if err := deleteTimestampKeys(tx, bdb.RelationsCreatedAtPath, bdb.RelationsUpdatedAtPath, cur, objKey); err != nil {
+			return err
+		}
+
+		if err := setRelationTombstone(ctx, tx, objKey, cur); err != nil {
+			return err
+		}
+
+		if err := incRelationStats(tx, cur, -1); err != nil {
+			return err
+		}
+
+		if _, err := AppendChange(tx, ChangeRelationDelete, cur); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteRelationExpiry(tx, objKey); err != nil {
+		return err
+	}
+
+	if err := bdb.Delete(ctx, tx, bdb.RelationsObjPath, objKey); err != nil {
+		return err
+	}
+
+	if err := bdb.Delete(ctx, tx, bdb.RelationsSubPath, r.SubKey(tx)); err != nil {
+		return err
+	}
+
+	return bdb.Delete(ctx, tx, bdb.RelationsRelPath, r.RelKey(tx))
+}
+
+// timestamped, instance with created_at and updated_at timestamps.
+type timestamped interface {
+	GetCreatedAt() *timestamppb.Timestamp
+	GetUpdatedAt() *timestamppb.Timestamp
+}
+
+// setTimestampKeys, adds the instance key to the created_at and updated_at indexes.
+func setTimestampKeys(tx bdb.Tx, createdAtPath, updatedAtPath bdb.Path, msg timestamped, key []byte) error {
+	if err := bdb.SetKey(tx, createdAtPath, TimestampKey(msg.GetCreatedAt(), key), key); err != nil {
+		return err
+	}
+
+	return bdb.SetKey(tx, updatedAtPath, TimestampKey(msg.GetUpdatedAt(), key), key)
+}
+
+// deleteTimestampKeys, removes the instance key from the created_at and updated_at indexes.
+func deleteTimestampKeys(tx bdb.Tx, createdAtPath, updatedAtPath bdb.Path, msg timestamped, key []byte) error {
+	if err := bdb.DeleteKey(tx, createdAtPath, TimestampKey(msg.GetCreatedAt(), key)); err != nil {
+		return err
+	}
+
+	return bdb.DeleteKey(tx, updatedAtPath, TimestampKey(msg.GetUpdatedAt(), key))
+}
+
+// TimestampKey, returns the index key for a timestamp ordered index,
+// format: big-endian unix nano timestamp | primary key.
+func TimestampKey(ts *timestamppb.Timestamp, key []byte) []byte {
+	buf := make([]byte, timestampSize, timestampSize+len(key))
+	binary.BigEndian.PutUint64(buf, uint64(ts.AsTime().UnixNano())) //nolint:gosec // G115: timestamps are always after the epoch.
+
+	return append(buf, key...)
+}
+
+// TimestampBoundKey, returns the first possible index key of the given time.
+func TimestampBoundKey(ts time.Time) []byte {
+	buf := make([]byte, timestampSize)
+	binary.BigEndian.PutUint64(buf, uint64(ts.UnixNano())) //nolint:gosec // G115: timestamps are always after the epoch.
+
+	return buf
+}
+
+// TimestampSeekKey, returns the first possible index key following the given time.
+func TimestampSeekKey(ts time.Time) []byte {
+	buf := make([]byte, timestampSize)
+	binary.BigEndian.PutUint64(buf, uint64(ts.UnixNano())+1) //nolint:gosec // G115: timestamps are always after the epoch.
+
+	return buf
+}
+
+const timestampSize int = 8
+
+func getObject(ctx context.Context, tx bdb.Tx, key []byte) (*dsc3.Object, error) {
+	cur, err := bdb.Get[dsc3.Object](ctx, tx, bdb.ObjectsPath, key)
+
+	switch {
+	case status.Code(err) == codes.NotFound:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return cur, nil
+	}
+}
+
+func getRelation(ctx context.Context, tx bdb.Tx, key []byte) (*dsc3.Relation, error) {
+	cur, err := bdb.Get[dsc3.Relation](ctx, tx, bdb.RelationsObjPath, key)
+
+	switch {
+	case status.Code(err) == codes.NotFound:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	default:
+		return cur, nil
+	}
+}
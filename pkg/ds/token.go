@@ -0,0 +1,93 @@
+package ds
+
+import (
+	"strings"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+)
+
+// TranslateKey, translates the key of a page token issued using an older key encoding to the current key encoding,
+// implements bdb.KeyTranslator. Migrations which change the key encoding bump bdb.KeyVersion and extend the translation.
+func TranslateKey(tx bdb.Tx, path bdb.Path, version uint64, key []byte) ([]byte, error) {
+	if version != bdb.KeyVersionSeparated {
+		return nil, bdb.ErrInvalidPageToken.Msgf("unsupported key version %d", version)
+	}
+
+	names := newNameResolver(tx)
+
+	switch path[0] {
+	case bdb.ObjectsPath[0]:
+		objType, objID, ok := strings.Cut(string(key), string(TypeIDSeparator))
+		if !ok || names.id(objType) == 0 {
+			return nil, bdb.ErrInvalidPageToken.Msg("untranslatable object key")
+		}
+
+		return Object(&dsc3.Object{Type: objType, Id: objID}).Key(tx), nil
+
+	case bdb.RelationsObjPath[0], bdb.RelationsSubPath[0], bdb.RelationsRelPath[0]:
+		rel, ok := parseSeparatedRelationKey(path, string(key))
+		if !ok || !names.known(rel.GetObjectType(), rel.GetRelation(), rel.GetSubjectType(), rel.GetSubjectRelation()) {
+			return nil, bdb.ErrInvalidPageToken.Msg("untranslatable relation key")
+		}
+
+		switch path[0] {
+		case bdb.RelationsSubPath[0]:
+			return Relation(rel).SubKey(tx), nil
+		case bdb.RelationsRelPath[0]:
+			return Relation(rel).RelKey(tx), nil
+		default:
+			return Relation(rel).ObjKey(tx), nil
+		}
+
+	default:
+		return nil, bdb.ErrInvalidPageToken.Msgf("untranslatable key of path [%s]", path)
+	}
+}
+
+// parseSeparatedRelationKey, parses a relation key of the separator based key encoding
+//
+// relations_obj:	obj_type : obj_id | relation | sub_type : sub_id (| sub_relation)
+// relations_sub:	sub_type : sub_id | relation | obj_type : obj_id (| sub_relation)
+// relations_rel:	obj_type # relation | sub_type : sub_id (# sub_relation) | obj_id.
+func parseSeparatedRelationKey(path bdb.Path, key string) (*dsc3.Relation, bool) {
+	const minParts, maxParts = 3, 4
+
+	parts := strings.Split(key, string(InstanceSeparator))
+	if len(parts) < minParts || len(parts) > maxParts {
+		return nil, false
+	}
+
+	rel := &dsc3.Relation{}
+
+	var ok, okSub bool
+
+	switch path[0] {
+	case bdb.RelationsObjPath[0], bdb.RelationsSubPath[0]:
+		obj, sub := parts[0], parts[2]
+		if path[0] == bdb.RelationsSubPath[0] {
+			obj, sub = sub, obj
+		}
+
+		rel.ObjectType, rel.ObjectId, ok = strings.Cut(obj, string(TypeIDSeparator))
+		rel.SubjectType, rel.SubjectId, okSub = strings.Cut(sub, string(TypeIDSeparator))
+		rel.Relation = parts[1]
+
+		if len(parts) == maxParts {
+			rel.SubjectRelation = parts[3]
+		}
+
+	case bdb.RelationsRelPath[0]:
+		if len(parts) != minParts {
+			return nil, false
+		}
+
+		rel.ObjectType, rel.Relation, ok = strings.Cut(parts[0], string(RelationSeparator))
+		sub, subRel, _ := strings.Cut(parts[1], string(RelationSeparator))
+		rel.SubjectType, rel.SubjectId, okSub = strings.Cut(sub, string(TypeIDSeparator))
+		rel.SubjectRelation = subRel
+		rel.ObjectId = parts[2]
+	}
+
+	return rel, ok && okSub
+}
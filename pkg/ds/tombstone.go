@@ -0,0 +1,218 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Tombstones record the deletion of objects and relations, so deletes can be propagated
+// by incremental (watermark) exports.
+//
+// store layout:
+// objects_tombstones/{object key}		-- contains the deleted dsc3.Object instance
+// relations_tombstones/{relation obj key}	-- contains the deleted dsc3.Relation instance
+// objects_deleted_at/{big-endian unix nano deletion timestamp}{object key}	-- contains the object key
+// relations_deleted_at/{big-endian unix nano deletion timestamp}{relation obj key}	-- contains the relation obj key
+// _system/tombstone_horizon			-- contains the big-endian unix nano timestamp before which tombstones have been purged
+//
+// The updated_at timestamp of a tombstone instance contains the deletion timestamp,
+// the etag is set to TombstoneEtag. The deletion time index is walked by incremental exports from the start_from
+// timestamp, and by the garbage collection up to the retention horizon.
+// Incremental exports starting before the tombstone horizon miss the purged deletes, clients are expected
+// to fall back to a full (diff) sync.
+
+// TombstoneEtag, etag value of exported tombstone instances, identifies the instance as deleted.
+const TombstoneEtag string = "tombstone"
+
+// OptionTombstones, edge specific export option, when set the exporter streams the tombstones
+// recorded after the start_from timestamp, following the objects and relations.
+// The option is not part of the directory export API, sync clients only set it when configured to (datasync.WithTombstones).
+const OptionTombstones uint32 = 1 << 7
+
+// DefaultTombstoneRetention, default duration tombstones are retained before being garbage collected.
+const DefaultTombstoneRetention time.Duration = 7 * 24 * time.Hour
+
+var tombstoneHorizonKey = []byte("tombstone_horizon")
+
+// IsTombstone, returns true when the etag identifies the instance as a tombstone.
+func IsTombstone(etag string) bool {
+	return etag == TombstoneEtag
+}
+
+func setObjectTombstone(ctx context.Context, tx bdb.Tx, key []byte, cur *dsc3.Object) error {
+	tombstone := &dsc3.Object{
+		Type:      cur.GetType(),
+		Id:        cur.GetId(),
+		CreatedAt: cur.GetCreatedAt(),
+		UpdatedAt: timestamppb.New(time.Now().UTC()),
+		Etag:      TombstoneEtag,
+	}
+
+	return setTombstone(ctx, tx, bdb.ObjectTombstonesPath, bdb.ObjectsDeletedAtPath, key, tombstone)
+}
+
+func setRelationTombstone(ctx context.Context, tx bdb.Tx, key []byte, cur *dsc3.Relation) error {
+	tombstone := &dsc3.Relation{
+		ObjectType:      cur.GetObjectType(),
+		ObjectId:        cur.GetObjectId(),
+		Relation:        cur.GetRelation(),
+		SubjectType:     cur.GetSubjectType(),
+		SubjectId:       cur.GetSubjectId(),
+		SubjectRelation: cur.GetSubjectRelation(),
+		CreatedAt:       cur.GetCreatedAt(),
+		UpdatedAt:       timestamppb.New(time.Now().UTC()),
+		Etag:            TombstoneEtag,
+	}
+
+	return setTombstone(ctx, tx, bdb.RelationTombstonesPath, bdb.RelationsDeletedAtPath, key, tombstone)
+}
+
+// setTombstone, records the tombstone instance at path, and its deletion time index entry at idxPath,
+// superseding the tombstone previously recorded for the key.
+func setTombstone[T any, M tombstone[T]](ctx context.Context, tx bdb.Tx, path, idxPath bdb.Path, key []byte, v M) error {
+	if err := deleteTombstone[T, M](ctx, tx, path, idxPath, key); err != nil {
+		return err
+	}
+
+	if _, err := bdb.Set[T, M](ctx, tx, path, key, v); err != nil {
+		return err
+	}
+
+	return bdb.SetKey(tx, idxPath, TimestampKey(v.GetUpdatedAt(), key), key)
+}
+
+// deleteObjectTombstone, removes the tombstone of the object instance, and its deletion time index entry.
+func deleteObjectTombstone(ctx context.Context, tx bdb.Tx, key []byte) error {
+	return deleteTombstone[dsc3.Object](ctx, tx, bdb.ObjectTombstonesPath, bdb.ObjectsDeletedAtPath, key)
+}
+
+// deleteRelationTombstone, removes the tombstone of the relation instance, and its deletion time index entry.
+func deleteRelationTombstone(ctx context.Context, tx bdb.Tx, objKey []byte) error {
+	return deleteTombstone[dsc3.Relation](ctx, tx, bdb.RelationTombstonesPath, bdb.RelationsDeletedAtPath, objKey)
+}
+
+// deleteTombstone, removes the tombstone recorded for the key at path, and its deletion time index entry at idxPath.
+func deleteTombstone[T any, M tombstone[T]](ctx context.Context, tx bdb.Tx, path, idxPath bdb.Path, key []byte) error {
+	cur, err := bdb.Get[T, M](ctx, tx, path, key)
+
+	switch {
+	case status.Code(err) == codes.NotFound:
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if err := bdb.DeleteKey(tx, idxPath, TimestampKey(cur.GetUpdatedAt(), key)); err != nil {
+		return err
+	}
+
+	return bdb.DeleteKey(tx, path, key)
+}
+
+// setTombstones, records tombstones for all objects and relations, used before the data buckets are cleared.
+func setTombstones(ctx context.Context, tx bdb.Tx) error {
+	objIter, err := bdb.NewScanIterator[dsc3.Object](ctx, tx, bdb.ObjectsPath)
+	if err != nil {
+		return err
+	}
+
+	for objIter.Next() {
+		if err := setObjectTombstone(ctx, tx, objIter.RawKey(), objIter.Value()); err != nil {
+			return err
+		}
+	}
+
+	relIter, err := bdb.NewScanIterator[dsc3.Relation](ctx, tx, bdb.RelationsObjPath)
+	if err != nil {
+		return err
+	}
+
+	for relIter.Next() {
+		if err := setRelationTombstone(ctx, tx, relIter.RawKey(), relIter.Value()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type tombstone[T any] interface {
+	bdb.Message[T]
+	GetUpdatedAt() *timestamppb.Timestamp
+}
+
+// TombstoneHorizon, returns the time before which tombstones have been purged,
+// a zero time is returned when no tombstones have been purged.
+func TombstoneHorizon(tx bdb.Tx) time.Time {
+	v, err := bdb.GetKey(tx, bdb.SystemPath, tombstoneHorizonKey)
+	if err != nil || len(v) != timestampSize {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v))).UTC() //nolint:gosec // G115: timestamps are always after the epoch.
+}
+
+// PurgeTombstones, garbage collects the tombstones recorded before the given time, and advances the tombstone horizon
+// when tombstones are purged, returns the number of purged tombstones.
+func PurgeTombstones(ctx context.Context, tx bdb.Tx, before time.Time) (int, error) {
+	objCount, err := purgeTombstones(tx, bdb.ObjectTombstonesPath, bdb.ObjectsDeletedAtPath, before)
+	if err != nil {
+		return 0, err
+	}
+
+	relCount, err := purgeTombstones(tx, bdb.RelationTombstonesPath, bdb.RelationsDeletedAtPath, before)
+	if err != nil {
+		return 0, err
+	}
+
+	if objCount+relCount > 0 && before.After(TombstoneHorizon(tx)) {
+		buf := make([]byte, timestampSize)
+		binary.BigEndian.PutUint64(buf, uint64(before.UnixNano())) //nolint:gosec // G115: timestamps are always after the epoch.
+
+		if err := bdb.SetKey(tx, bdb.SystemPath, tombstoneHorizonKey, buf); err != nil {
+			return 0, err
+		}
+	}
+
+	return objCount + relCount, nil
+}
+
+// purgeTombstones, deletes the tombstones at path recorded before the given time, and their deletion time index entries
+// at idxPath, returns the number of purged tombstones.
+func purgeTombstones(tx bdb.Tx, path, idxPath bdb.Path, before time.Time) (int, error) {
+	b, err := bdb.SetBucket(tx, idxPath)
+	if err != nil {
+		return 0, err
+	}
+
+	// collect keys first, deleting underneath an active cursor skips entries.
+	idxKeys, keys := [][]byte{}, [][]byte{}
+	end := TimestampBoundKey(before)
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+		idxKeys = append(idxKeys, bytes.Clone(k))
+		keys = append(keys, bytes.Clone(v))
+	}
+
+	for i, key := range keys {
+		if err := bdb.DeleteKey(tx, path, key); err != nil {
+			return 0, err
+		}
+
+		if err := bdb.DeleteKey(tx, idxPath, idxKeys[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
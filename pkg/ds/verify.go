@@ -0,0 +1,315 @@
+package ds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/aserto-dev/azm/cache"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Every relation is stored three times, in the relations_obj bucket, which is the primary copy, and in the
+// relations_sub and relations_rel buckets, which index the relation by subject and by object type and relation.
+// VerifyRelations cross-checks the three copies, RepairRelations rebuilds the index copies from the primary copy.
+
+// MaxVerifyIssues, maximum number of issues retained in the verify report, further issues are only counted.
+const MaxVerifyIssues int = 1000
+
+// RepairBatchSize, maximum number of entries repaired by a single RepairRelations call.
+const RepairBatchSize int = 1000
+
+// IssueKind, kind of integrity issue detected by VerifyRelations.
+type IssueKind string
+
+const (
+	// IssueUndecodableValue, the value of the entry is not a relation.
+	IssueUndecodableValue IssueKind = "undecodable_value"
+	// IssueKeyMismatch, the key of the entry does not match the key derived from the relation stored in the entry.
+	IssueKeyMismatch IssueKind = "key_mismatch"
+	// IssueMissingCounterpart, the relations_sub or relations_rel entry of a relations_obj entry does not exist.
+	IssueMissingCounterpart IssueKind = "missing_counterpart"
+	// IssueMismatchedCounterpart, the relation stored in the relations_sub or relations_rel entry differs
+	// from the relation stored in the relations_obj entry.
+	IssueMismatchedCounterpart IssueKind = "mismatched_counterpart"
+	// IssueOrphanedIndex, the relations_obj entry of a relations_sub or relations_rel entry does not exist.
+	IssueOrphanedIndex IssueKind = "orphaned_index"
+	// IssueModelViolation, the relation is not valid according to the current model.
+	IssueModelViolation IssueKind = "model_violation"
+)
+
+// Issue, integrity issue of a relation entry.
+type Issue struct {
+	Kind     IssueKind
+	Path     bdb.Path
+	Key      []byte
+	Relation *dsc3.Relation // nil when the value is undecodable.
+	Detail   string
+}
+
+// VerifyReport, result of VerifyRelations.
+type VerifyReport struct {
+	Relations  int      // number of relations_obj entries.
+	Issues     []*Issue // the first MaxVerifyIssues detected issues.
+	IssueCount int      // number of detected issues, including the issues exceeding MaxVerifyIssues.
+}
+
+// Truncated, reports whether detected issues have been omitted from the report.
+func (r *VerifyReport) Truncated() bool {
+	return r.IssueCount > len(r.Issues)
+}
+
+// RepairReport, result of RepairRelations.
+type RepairReport struct {
+	Relations int // number of relations indexed in relations_sub and relations_rel.
+	Skipped   int // number of relations_obj entries skipped, as their value is undecodable or does not match their key.
+	Removed   int // number of relations_sub and relations_rel entries removed, as they do not index a relations_obj entry.
+}
+
+// RepairPosition, position of a repair, which is performed in batches, the zero value is the start of the repair.
+type RepairPosition struct {
+	phase int    // 0 indexes the relations_obj entries, n removes the stale entries of relationIndexes[n-1].
+	key   []byte // key of the next entry of the phase, nil is the first entry.
+}
+
+// Done, reports whether the repair is complete.
+func (p RepairPosition) Done() bool {
+	return p.phase > len(relationIndexes)
+}
+
+var errNotARelation = errors.New("value does not contain a relation")
+
+// relationIndexes, index buckets of the relations, with the function deriving the index key of a relation.
+var relationIndexes = []struct {
+	path bdb.Path
+	key  func(*relation, bdb.Tx) []byte
+}{
+	{bdb.RelationsSubPath, (*relation).SubKey},
+	{bdb.RelationsRelPath, (*relation).RelKey},
+}
+
+// VerifyRelations, cross-checks the relations_obj, relations_sub and relations_rel buckets, and validates the
+// relations against the model, returns the detected integrity issues, of which the first MaxVerifyIssues are retained.
+// VerifyRelations does not modify the store.
+func VerifyRelations(ctx context.Context, tx bdb.Tx, mc *cache.Cache) (*VerifyReport, error) {
+	report := &VerifyReport{Issues: []*Issue{}}
+
+	err := forEachRelation(ctx, tx, bdb.RelationsObjPath, func(key []byte, rel *dsc3.Relation, decodeErr error) error {
+		report.Relations++
+
+		if decodeErr != nil {
+			report.add(IssueUndecodableValue, bdb.RelationsObjPath, key, nil, decodeErr.Error())
+			return nil
+		}
+
+		r := Relation(rel)
+
+		if !bytes.Equal(key, r.ObjKey(tx)) {
+			report.add(IssueKeyMismatch, bdb.RelationsObjPath, key, rel, "")
+			return nil
+		}
+
+		if err := r.Validate(mc); err != nil {
+			report.add(IssueModelViolation, bdb.RelationsObjPath, key, rel, err.Error())
+		}
+
+		for _, idx := range relationIndexes {
+			idxKey := idx.key(r, tx)
+
+			value, err := bdb.GetKey(tx, idx.path, idxKey)
+			if err != nil {
+				report.add(IssueMissingCounterpart, idx.path, idxKey, rel, "")
+				continue
+			}
+
+			var counterpart dsc3.Relation
+			if err := proto.Unmarshal(value, &counterpart); err != nil || !proto.Equal(rel, &counterpart) {
+				report.add(IssueMismatchedCounterpart, idx.path, idxKey, rel, "")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range relationIndexes {
+		err := forEachRelation(ctx, tx, idx.path, func(key []byte, rel *dsc3.Relation, decodeErr error) error {
+			if decodeErr != nil {
+				report.add(IssueUndecodableValue, idx.path, key, nil, decodeErr.Error())
+				return nil
+			}
+
+			r := Relation(rel)
+
+			if !bytes.Equal(key, idx.key(r, tx)) {
+				report.add(IssueKeyMismatch, idx.path, key, rel, "")
+				return nil
+			}
+
+			if ok, _ := bdb.KeyExists(tx, bdb.RelationsObjPath, r.ObjKey(tx)); !ok {
+				report.add(IssueOrphanedIndex, idx.path, key, rel, "")
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// RepairRelations, repairs the batch of up to RepairBatchSize entries starting at pos, adds the outcome to report,
+// and returns the position following the batch. The repair is complete when the returned position is done.
+//
+// The relations_obj entries are indexed in relations_sub and relations_rel, relations_obj entries which are
+// undecodable or do not match their key are skipped, and left in place. Then the relations_sub and relations_rel
+// entries which are undecodable, do not match their key, or do not index a relations_obj entry are removed.
+// The index buckets are repaired in place, each batch leaves the store consistent, so the batches can be applied
+// in separate write transactions, while the store continues to serve reads and writes.
+func RepairRelations(ctx context.Context, tx bdb.Tx, pos RepairPosition, report *RepairReport) (RepairPosition, error) {
+	if pos.Done() {
+		return pos, nil
+	}
+
+	if pos.phase == 0 {
+		next, err := forEachRelationBatch(ctx, tx, bdb.RelationsObjPath, pos.key, func(key []byte, rel *dsc3.Relation, decodeErr error) error {
+			r := Relation(rel)
+
+			if decodeErr != nil || !bytes.Equal(key, r.ObjKey(tx)) {
+				report.Skipped++
+				return nil
+			}
+
+			for _, idx := range relationIndexes {
+				if _, err := bdb.Set(ctx, tx, idx.path, idx.key(r, tx), rel); err != nil {
+					return err
+				}
+			}
+
+			report.Relations++
+
+			return nil
+		})
+
+		return nextRepairPosition(pos, next), err
+	}
+
+	idx := relationIndexes[pos.phase-1]
+	stale := [][]byte{}
+
+	next, err := forEachRelationBatch(ctx, tx, idx.path, pos.key, func(key []byte, rel *dsc3.Relation, decodeErr error) error {
+		if decodeErr != nil || !bytes.Equal(key, idx.key(Relation(rel), tx)) {
+			stale = append(stale, key)
+			return nil
+		}
+
+		if ok, _ := bdb.KeyExists(tx, bdb.RelationsObjPath, Relation(rel).ObjKey(tx)); !ok {
+			stale = append(stale, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return pos, err
+	}
+
+	// the stale entries are deleted once the batch has been read, deleting while iterating moves the cursor.
+	for _, key := range stale {
+		if err := bdb.DeleteKey(tx, idx.path, key); err != nil {
+			return pos, err
+		}
+	}
+
+	report.Removed += len(stale)
+
+	return nextRepairPosition(pos, next), nil
+}
+
+func nextRepairPosition(pos RepairPosition, next []byte) RepairPosition {
+	if next == nil {
+		return RepairPosition{phase: pos.phase + 1}
+	}
+
+	return RepairPosition{phase: pos.phase, key: next}
+}
+
+// forEachRelation, calls fn for each entry of the relation bucket, with the decoded relation or the decode error.
+func forEachRelation(ctx context.Context, tx bdb.Tx, path bdb.Path, fn func([]byte, *dsc3.Relation, error) error) error {
+	_, err := forEachRelationN(ctx, tx, path, nil, -1, fn)
+	return err
+}
+
+// forEachRelationBatch, calls fn for up to RepairBatchSize entries of the relation bucket starting at the start key,
+// returns the key of the entry following the batch, nil when the bucket is exhausted.
+func forEachRelationBatch(
+	ctx context.Context,
+	tx bdb.Tx,
+	path bdb.Path,
+	start []byte,
+	fn func([]byte, *dsc3.Relation, error) error,
+) ([]byte, error) {
+	return forEachRelationN(ctx, tx, path, start, RepairBatchSize, fn)
+}
+
+// forEachRelationN, calls fn for up to n (unlimited when negative) entries of the relation bucket starting
+// at the start key, returns the key of the entry following the last entry, nil when the bucket is exhausted.
+func forEachRelationN(
+	ctx context.Context,
+	tx bdb.Tx,
+	path bdb.Path,
+	start []byte,
+	n int,
+	fn func([]byte, *dsc3.Relation, error) error,
+) ([]byte, error) {
+	b, err := bdb.SetBucket(tx, path)
+	if err != nil {
+		return nil, nil //nolint:nilerr // a missing bucket contains no relations.
+	}
+
+	c := b.Cursor()
+
+	k, v := c.First()
+	if start != nil {
+		k, v = c.Seek(start)
+	}
+
+	for ; k != nil; k, v = c.Next() {
+		if n == 0 {
+			return bytes.Clone(k), nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var rel dsc3.Relation
+
+		decodeErr := proto.Unmarshal(v, &rel)
+		if decodeErr == nil && rel.GetObjectType() == "" {
+			decodeErr = errNotARelation
+		}
+
+		if err := fn(bytes.Clone(k), &rel, decodeErr); err != nil {
+			return nil, err
+		}
+
+		n--
+	}
+
+	return nil, nil
+}
+
+func (r *VerifyReport) add(kind IssueKind, path bdb.Path, key []byte, rel *dsc3.Relation, detail string) {
+	r.IssueCount++
+
+	if len(r.Issues) < MaxVerifyIssues {
+		r.Issues = append(r.Issues, &Issue{Kind: kind, Path: path, Key: key, Relation: rel, Detail: detail})
+	}
+}
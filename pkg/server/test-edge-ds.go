@@ -2,26 +2,39 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net"
+	"slices"
 
 	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
 	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
 	dsm3 "github.com/aserto-dev/go-directory/aserto/directory/model/v3"
 	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
 	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	dsa1 "github.com/authzen/access.go/api/access/v1"
 
 	"github.com/aserto-dev/aserto-grpc/middlewares/gerr"
 	eds "github.com/aserto-dev/go-edge-ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/admin"
 	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/streamer"
+	"github.com/aserto-dev/go-edge-ds/pkg/tenant"
+	"github.com/aserto-dev/go-edge-ds/pkg/watcher"
 	"github.com/rs/zerolog"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 )
 
 type TestEdgeClient struct {
-	V3 ClientV3
+	Conn      *grpc.ClientConn
+	V3        ClientV3
+	Admin     admin.AdminClient
+	Watcher   watcher.WatcherClient
+	Streamer  streamer.StreamerClient
+	Directory *directory.Directory
 }
 
 type ClientV3 struct {
@@ -30,34 +43,96 @@ type ClientV3 struct {
 	Writer   dsw3.WriterClient
 	Importer dsi3.ImporterClient
 	Exporter dse3.ExporterClient
+	Access   dsa1.AccessClient
 }
 
 const bufferSize int = 1024 * 1024
 
-func NewTestEdgeServer(ctx context.Context, logger *zerolog.Logger, cfg *directory.Config) (*TestEdgeClient, func()) {
-	listener := bufconn.Listen(bufferSize)
+// TestAdminHeader, request metadata which authorizes the Admin calls of the test servers, when set to TestAdminToken.
+const (
+	TestAdminHeader string = "x-admin-token"
+	TestAdminToken  string = "test-admin-token"
+)
+
+// testAdminAuthorizer, authorizes the Admin calls which carry the TestAdminToken in the TestAdminHeader metadata.
+func testAdminAuthorizer(ctx context.Context, fullMethod string) error {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && slices.Contains(md.Get(TestAdminHeader), TestAdminToken) {
+		return nil
+	}
 
+	return admin.ErrNotAuthorized.Msgf("%s: missing admin token", fullMethod)
+}
+
+func NewTestEdgeServer(ctx context.Context, logger *zerolog.Logger, cfg *directory.Config) (*TestEdgeClient, func()) {
 	edgeDSLogger := logger.With().Str("component", "api.edge-directory").Logger()
 
-	edgeDirServer, err := eds.New(context.Background(), cfg, &edgeDSLogger)
+	edgeDirServer, err := eds.Open(context.Background(), cfg, &edgeDSLogger)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to start edge directory server")
 	}
 
+	client, stop := newTestServer(func(s *grpc.Server) {
+		dsm3.RegisterModelServer(s, edgeDirServer.Model3())
+		dsr3.RegisterReaderServer(s, edgeDirServer.Reader3())
+		dsw3.RegisterWriterServer(s, edgeDirServer.Writer3())
+		dse3.RegisterExporterServer(s, edgeDirServer.Exporter3())
+		dsi3.RegisterImporterServer(s, edgeDirServer.Importer3())
+		dsa1.RegisterAccessServer(s, edgeDirServer.Access1())
+		admin.RegisterAdminServer(s, edgeDirServer.Admin(), testAdminAuthorizer)
+		watcher.RegisterWatcherServer(s, edgeDirServer.Watcher3())
+		streamer.RegisterStreamerServer(s, edgeDirServer.Streamer3())
+	})
+
+	client.Directory = edgeDirServer
+
+	return client, func() {
+		stop()
+		edgeDirServer.Close()
+	}
+}
+
+// NewTestTenantServer, starts a test server dispatching the directory services to the tenant
+// identified by the tenant id request header.
+func NewTestTenantServer(ctx context.Context, logger *zerolog.Logger, cfg *tenant.Config) (*TestEdgeClient, *tenant.Router, func()) {
+	routerLogger := logger.With().Str("component", "api.tenant-router").Logger()
+
+	router, err := tenant.New(ctx, cfg, &routerLogger)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to start tenant router")
+	}
+
+	client, stop := newTestServer(func(s *grpc.Server) {
+		dsm3.RegisterModelServer(s, router.Model3())
+		dsr3.RegisterReaderServer(s, router.Reader3())
+		dsw3.RegisterWriterServer(s, router.Writer3())
+		dse3.RegisterExporterServer(s, router.Exporter3())
+		dsi3.RegisterImporterServer(s, router.Importer3())
+		dsa1.RegisterAccessServer(s, router.Access1())
+		admin.RegisterAdminServer(s, router.Admin(), testAdminAuthorizer)
+		watcher.RegisterWatcherServer(s, router.Watcher())
+		streamer.RegisterStreamerServer(s, router.Streamer())
+	})
+
+	return client, router, func() {
+		stop()
+		router.Close()
+	}
+}
+
+func newTestServer(register func(*grpc.Server)) (*TestEdgeClient, func()) {
+	listener := bufconn.Listen(bufferSize)
+
 	errMiddleware := gerr.NewErrorMiddleware()
 	s := grpc.NewServer(
 		grpc.UnaryInterceptor(errMiddleware.Unary()),
 		grpc.StreamInterceptor(errMiddleware.Stream()),
 	)
 
-	dsm3.RegisterModelServer(s, edgeDirServer.Model3())
-	dsr3.RegisterReaderServer(s, edgeDirServer.Reader3())
-	dsw3.RegisterWriterServer(s, edgeDirServer.Writer3())
-	dse3.RegisterExporterServer(s, edgeDirServer.Exporter3())
-	dsi3.RegisterImporterServer(s, edgeDirServer.Importer3())
+	register(s)
 
 	go func() {
-		if err := s.Serve(listener); err != nil {
+		// the server is stopped before serving when the test completes first.
+		if err := s.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 			panic(err)
 		}
 	}()
@@ -75,13 +150,18 @@ func NewTestEdgeServer(ctx context.Context, logger *zerolog.Logger, cfg *directo
 	}
 
 	client := TestEdgeClient{
+		Conn: conn,
 		V3: ClientV3{
 			Model:    dsm3.NewModelClient(conn),
 			Reader:   dsr3.NewReaderClient(conn),
 			Writer:   dsw3.NewWriterClient(conn),
 			Importer: dsi3.NewImporterClient(conn),
 			Exporter: dse3.NewExporterClient(conn),
+			Access:   dsa1.NewAccessClient(conn),
 		},
+		Admin:    admin.NewAdminClient(conn),
+		Watcher:  watcher.NewWatcherClient(conn),
+		Streamer: streamer.NewStreamerClient(conn),
 	}
 
 	return &client, s.Stop
@@ -0,0 +1,184 @@
+package streamer
+
+import (
+	"context"
+
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+
+	"google.golang.org/grpc"
+)
+
+// Streamer, edge directory service streaming the results of GetObjects and GetRelations,
+// and the preview of the relations deleted by DeleteObject.
+//
+// The request messages are the directory reader v3 requests, each response message contains a batch of up to
+// page size (default and maximum x.MaxPageSize) results. The page token of each response message is the resume
+// position of the results following the batch, a stream which is interrupted can be resumed by passing the
+// page token of the last received batch in the request, the token is empty in the last batch.
+//
+// PreviewDeleteObject streams the relations which are deleted with the object instance of the DeleteObject request
+// (with_relations), in batches of x.MaxPageSize relations, after checking the preconditions of the delete.
+//
+//	service Streamer {
+//	  rpc StreamObjects(aserto.directory.reader.v3.GetObjectsRequest) returns (stream aserto.directory.reader.v3.GetObjectsResponse);
+//	  rpc StreamRelations(aserto.directory.reader.v3.GetRelationsRequest) returns (stream aserto.directory.reader.v3.GetRelationsResponse);
+//	  rpc PreviewDeleteObject(aserto.directory.writer.v3.DeleteObjectRequest) returns (stream aserto.directory.reader.v3.GetRelationsResponse);
+//	}
+const (
+	ServiceName                       string = "aserto.directory.edge.streamer.v1.Streamer"
+	StreamObjectsFullMethodName       string = "/" + ServiceName + "/StreamObjects"
+	StreamRelationsFullMethodName     string = "/" + ServiceName + "/StreamRelations"
+	PreviewDeleteObjectFullMethodName string = "/" + ServiceName + "/PreviewDeleteObject"
+)
+
+// StreamerServer, server API of the Streamer service.
+type StreamerServer interface {
+	StreamObjects(*dsr3.GetObjectsRequest, Streamer_StreamObjectsServer) error
+	StreamRelations(*dsr3.GetRelationsRequest, Streamer_StreamRelationsServer) error
+	PreviewDeleteObject(*dsw3.DeleteObjectRequest, Streamer_PreviewDeleteObjectServer) error
+}
+
+type Streamer_StreamObjectsServer = grpc.ServerStreamingServer[dsr3.GetObjectsResponse] //nolint:revive,stylecheck // generated gRPC naming.
+
+type Streamer_StreamRelationsServer = grpc.ServerStreamingServer[dsr3.GetRelationsResponse] //nolint:revive,stylecheck // generated gRPC naming.
+
+type Streamer_PreviewDeleteObjectServer = grpc.ServerStreamingServer[dsr3.GetRelationsResponse] //nolint:revive,stylecheck // generated gRPC naming.
+
+// RegisterStreamerServer, registers the Streamer service implementation with the gRPC server.
+func RegisterStreamerServer(s grpc.ServiceRegistrar, srv StreamerServer) {
+	s.RegisterService(&Streamer_ServiceDesc, srv)
+}
+
+var Streamer_ServiceDesc = grpc.ServiceDesc{ //nolint:revive,stylecheck // follows the naming of generated gRPC code.
+	ServiceName: ServiceName,
+	HandlerType: (*StreamerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamObjects",
+			Handler:       streamObjectsHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamRelations",
+			Handler:       streamRelationsHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PreviewDeleteObject",
+			Handler:       previewDeleteObjectHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func streamObjectsHandler(srv any, stream grpc.ServerStream) error {
+	req := &dsr3.GetObjectsRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(StreamerServer).StreamObjects(
+		req, &grpc.GenericServerStream[dsr3.GetObjectsRequest, dsr3.GetObjectsResponse]{ServerStream: stream},
+	)
+}
+
+func streamRelationsHandler(srv any, stream grpc.ServerStream) error {
+	req := &dsr3.GetRelationsRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(StreamerServer).StreamRelations(
+		req, &grpc.GenericServerStream[dsr3.GetRelationsRequest, dsr3.GetRelationsResponse]{ServerStream: stream},
+	)
+}
+
+func previewDeleteObjectHandler(srv any, stream grpc.ServerStream) error {
+	req := &dsw3.DeleteObjectRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(StreamerServer).PreviewDeleteObject(
+		req, &grpc.GenericServerStream[dsw3.DeleteObjectRequest, dsr3.GetRelationsResponse]{ServerStream: stream},
+	)
+}
+
+// StreamerClient, client API of the Streamer service.
+type StreamerClient interface {
+	StreamObjects(
+		ctx context.Context, in *dsr3.GetObjectsRequest, opts ...grpc.CallOption,
+	) (grpc.ServerStreamingClient[dsr3.GetObjectsResponse], error)
+	StreamRelations(
+		ctx context.Context, in *dsr3.GetRelationsRequest, opts ...grpc.CallOption,
+	) (grpc.ServerStreamingClient[dsr3.GetRelationsResponse], error)
+	PreviewDeleteObject(
+		ctx context.Context, in *dsw3.DeleteObjectRequest, opts ...grpc.CallOption,
+	) (grpc.ServerStreamingClient[dsr3.GetRelationsResponse], error)
+}
+
+type streamerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewStreamerClient, returns a Streamer service client using the client connection.
+func NewStreamerClient(cc grpc.ClientConnInterface) StreamerClient {
+	return &streamerClient{cc: cc}
+}
+
+func (c *streamerClient) StreamObjects(
+	ctx context.Context,
+	in *dsr3.GetObjectsRequest,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[dsr3.GetObjectsResponse], error) {
+	return newClientStream[dsr3.GetObjectsRequest, dsr3.GetObjectsResponse](
+		ctx, c.cc, &Streamer_ServiceDesc.Streams[0], StreamObjectsFullMethodName, in, opts...,
+	)
+}
+
+func (c *streamerClient) StreamRelations(
+	ctx context.Context,
+	in *dsr3.GetRelationsRequest,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[dsr3.GetRelationsResponse], error) {
+	return newClientStream[dsr3.GetRelationsRequest, dsr3.GetRelationsResponse](
+		ctx, c.cc, &Streamer_ServiceDesc.Streams[1], StreamRelationsFullMethodName, in, opts...,
+	)
+}
+
+func (c *streamerClient) PreviewDeleteObject(
+	ctx context.Context,
+	in *dsw3.DeleteObjectRequest,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[dsr3.GetRelationsResponse], error) {
+	return newClientStream[dsw3.DeleteObjectRequest, dsr3.GetRelationsResponse](
+		ctx, c.cc, &Streamer_ServiceDesc.Streams[2], PreviewDeleteObjectFullMethodName, in, opts...,
+	)
+}
+
+func newClientStream[Req, Resp any](
+	ctx context.Context,
+	cc grpc.ClientConnInterface,
+	desc *grpc.StreamDesc,
+	method string,
+	in *Req,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[Resp], error) {
+	stream, err := cc.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &grpc.GenericClientStream[Req, Resp]{ClientStream: stream}
+	if err := x.SendMsg(in); err != nil {
+		return nil, err
+	}
+
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
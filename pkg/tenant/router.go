@@ -0,0 +1,322 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	cerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+//nolint:lll // single line readability more important.
+var (
+	ErrTenantRequired   = cerr.NewAsertoError("E20060", codes.InvalidArgument, http.StatusBadRequest, "tenant id not set")
+	ErrInvalidTenant    = cerr.NewAsertoError("E20061", codes.InvalidArgument, http.StatusBadRequest, "invalid tenant id")
+	ErrRouterClosed     = cerr.NewAsertoError("E20062", codes.Unavailable, http.StatusServiceUnavailable, "tenant router closed")
+	ErrTenantNotAllowed = cerr.NewAsertoError("E20080", codes.PermissionDenied, http.StatusForbidden, "tenant not allowed")
+	ErrTooManyTenants   = cerr.NewAsertoError("E20081", codes.ResourceExhausted, http.StatusTooManyRequests, "maximum number of open tenants reached")
+)
+
+// tenant ids are used as store file names, restrict them to a safe character set.
+var tenantIDRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_\-]{0,63}$`)
+
+// maximum interval between idle tenant checks.
+const reapInterval = time.Minute
+
+type Config struct {
+	// RootPath, directory containing the tenant stores, one store file per tenant ({root_path}/{tenant_id}.db).
+	RootPath string `json:"root_path"`
+	// IdleTimeout, duration after which an unused tenant directory is closed, when not set tenant directories remain open.
+	// In-memory tenant directories are never closed, as closing discards their content.
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	// AllowedTenants, ids of the tenants served by the router, when set requests of other tenants are rejected,
+	// when not set any valid tenant id is served, creating its store on first use.
+	AllowedTenants []string `json:"allowed_tenants"`
+	// MaxTenants, maximum number of open tenant directories, when reached requests of tenants which are not open
+	// are rejected until idle tenants are closed, zero does not limit the number of open tenant directories.
+	MaxTenants int `json:"max_tenants"`
+	// Directory, directory configuration applied to each tenant, the db_path is derived from the root path and tenant id.
+	Directory directory.Config `json:"directory"`
+}
+
+// Router, hosts a directory per tenant, lazily opening the tenant directories on first use,
+// requests are dispatched to the tenant directory identified by the tenant id in the incoming request metadata.
+type Router struct {
+	config  *Config
+	logger  *zerolog.Logger
+	mu      sync.Mutex
+	idle    *sync.Cond // signaled when a tenant directory is released, guarded by mu.
+	allowed map[string]struct{}
+	tenants map[string]*tenant
+	closed  bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+type tenant struct {
+	ready    chan struct{} // closed when the open of the tenant directory has completed.
+	dir      *directory.Directory
+	err      error
+	refs     int
+	lastUsed time.Time
+}
+
+func New(_ context.Context, config *Config, logger *zerolog.Logger) (*Router, error) {
+	newLogger := logger.With().Str("component", "tenant-router").Logger()
+
+	r := &Router{
+		config:  config,
+		logger:  &newLogger,
+		tenants: map[string]*tenant{},
+		done:    make(chan struct{}),
+	}
+
+	r.idle = sync.NewCond(&r.mu)
+
+	if len(config.AllowedTenants) > 0 {
+		r.allowed = make(map[string]struct{}, len(config.AllowedTenants))
+		for _, id := range config.AllowedTenants {
+			r.allowed[id] = struct{}{}
+		}
+	}
+
+	reapCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	go r.reaper(reapCtx)
+
+	return r, nil
+}
+
+// Close, closes all tenant directories, new requests are rejected, and in-flight requests are waited for
+// before the tenant directories are closed, streaming calls are expected to be canceled by the caller (e.g. stopping
+// the gRPC server) before the router is closed.
+func (r *Router) Close() {
+	r.mu.Lock()
+
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+
+	r.closed = true
+	tenants := r.tenants
+	r.tenants = map[string]*tenant{}
+
+	for inUse(tenants) {
+		r.idle.Wait()
+	}
+
+	r.mu.Unlock()
+
+	r.cancel()
+	<-r.done
+
+	for id, t := range tenants {
+		<-t.ready
+
+		if t.dir != nil {
+			r.logger.Info().Str("tenant_id", id).Msg("close")
+			t.dir.Close()
+		}
+	}
+}
+
+// inUse, returns true when any of the tenant directories has not been released, must be called with mu held.
+func inUse(tenants map[string]*tenant) bool {
+	for _, t := range tenants {
+		if t.refs > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TenantID, returns the tenant id from the incoming request metadata.
+func TenantID(ctx context.Context) (string, error) {
+	id := metautils.ExtractIncoming(ctx).Get(x.TenantIDHeader)
+	if id == "" {
+		return "", ErrTenantRequired.Msgf("%s header", x.TenantIDHeader)
+	}
+
+	if !tenantIDRegex.MatchString(id) {
+		return "", ErrInvalidTenant.Msgf("%q", id)
+	}
+
+	return id, nil
+}
+
+// Acquire, returns the directory of the tenant identified by the incoming request metadata,
+// the directory remains open until the returned release function is called.
+func (r *Router) Acquire(ctx context.Context) (*directory.Directory, func(), error) {
+	id, err := TenantID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.acquire(ctx, id)
+}
+
+func (r *Router) acquire(ctx context.Context, id string) (*directory.Directory, func(), error) {
+	r.mu.Lock()
+
+	if r.closed {
+		r.mu.Unlock()
+		return nil, nil, ErrRouterClosed
+	}
+
+	t, ok := r.tenants[id]
+	if !ok {
+		if err := r.admit(id); err != nil {
+			r.mu.Unlock()
+			return nil, nil, err
+		}
+
+		t = &tenant{ready: make(chan struct{})}
+		r.tenants[id] = t
+	}
+
+	t.refs++
+
+	r.mu.Unlock()
+
+	if !ok {
+		t.dir, t.err = r.open(ctx, id)
+		close(t.ready)
+	}
+
+	<-t.ready
+
+	if t.err != nil {
+		r.mu.Lock()
+		if r.tenants[id] == t {
+			delete(r.tenants, id)
+		}
+
+		t.refs--
+		r.idle.Broadcast()
+		r.mu.Unlock()
+
+		return nil, nil, t.err
+	}
+
+	release := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		t.refs--
+		t.lastUsed = time.Now()
+
+		r.idle.Broadcast()
+	}
+
+	return t.dir, release, nil
+}
+
+// admit, checks the tenant is allowed, and the maximum number of open tenant directories has not been reached,
+// must be called with mu held.
+func (r *Router) admit(id string) error {
+	if r.allowed != nil {
+		if _, ok := r.allowed[id]; !ok {
+			return ErrTenantNotAllowed.Msgf("%q", id)
+		}
+	}
+
+	if r.config.MaxTenants > 0 && len(r.tenants) >= r.config.MaxTenants {
+		return ErrTooManyTenants.Msgf("%d", r.config.MaxTenants)
+	}
+
+	return nil
+}
+
+// open, opens the tenant directory, migrating the tenant store when required.
+func (r *Router) open(ctx context.Context, id string) (*directory.Directory, error) {
+	cfg := r.config.Directory
+	cfg.DBPath = r.DBPath(id)
+
+	r.logger.Info().Str("tenant_id", id).Str("db_path", cfg.DBPath).Msg("open")
+
+	logger := r.logger.With().Str("tenant_id", id).Logger()
+
+	return directory.Open(ctx, &cfg, &logger)
+}
+
+// DBPath, returns the store file path of the tenant.
+func (r *Router) DBPath(id string) string {
+	return filepath.Join(r.config.RootPath, id+".db")
+}
+
+// Tenants, returns the ids of the open tenant directories.
+func (r *Router) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (r *Router) reaper(ctx context.Context) {
+	defer close(r.done)
+
+	if r.config.IdleTimeout <= 0 || r.config.Directory.Backend == bdb.MemoryBackend {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(min(r.config.IdleTimeout, reapInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.reap(now)
+		}
+	}
+}
+
+// reap, closes the tenant directories which have not been used during the idle timeout.
+func (r *Router) reap(now time.Time) {
+	idle := map[string]*tenant{}
+
+	r.mu.Lock()
+
+	for id, t := range r.tenants {
+		select {
+		case <-t.ready:
+		default:
+			continue // open in progress.
+		}
+
+		if t.err == nil && t.refs == 0 && now.Sub(t.lastUsed) >= r.config.IdleTimeout {
+			idle[id] = t
+			delete(r.tenants, id)
+		}
+	}
+
+	r.mu.Unlock()
+
+	for id, t := range idle {
+		r.logger.Info().Str("tenant_id", id).Msg("close idle")
+		t.dir.Close()
+	}
+}
@@ -0,0 +1,293 @@
+package tenant
+
+import (
+	"context"
+
+	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
+	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
+	dsm3 "github.com/aserto-dev/go-directory/aserto/directory/model/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	dsa1 "github.com/authzen/access.go/api/access/v1"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/admin"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	v3 "github.com/aserto-dev/go-edge-ds/pkg/directory/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/streamer"
+	"github.com/aserto-dev/go-edge-ds/pkg/watcher"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// unary, dispatches the unary call to the server of the tenant directory.
+func unary[S, Req, Resp any](
+	ctx context.Context,
+	r *Router,
+	server func(*directory.Directory) S,
+	method func(S, context.Context, Req) (Resp, error),
+	req Req,
+) (Resp, error) {
+	dir, release, err := r.Acquire(ctx)
+	if err != nil {
+		var resp Resp
+		return resp, err
+	}
+	defer release()
+
+	return method(server(dir), ctx, req)
+}
+
+// stream, dispatches the streaming call to the tenant directory, which remains open until the stream has finished.
+func stream(ctx context.Context, r *Router, fn func(*directory.Directory) error) error {
+	dir, release, err := r.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn(dir)
+}
+
+func (r *Router) Reader3() dsr3.ReaderServer {
+	return &Reader{router: r}
+}
+
+func (r *Router) Writer3() dsw3.WriterServer {
+	return &Writer{router: r}
+}
+
+func (r *Router) Model3() dsm3.ModelServer {
+	return &Model{router: r}
+}
+
+func (r *Router) Importer3() dsi3.ImporterServer {
+	return &Importer{router: r}
+}
+
+func (r *Router) Exporter3() dse3.ExporterServer {
+	return &Exporter{router: r}
+}
+
+func (r *Router) Access1() dsa1.AccessServer {
+	return &Access{router: r}
+}
+
+// Admin, returns the Admin service dispatching to the tenant directory, Restore replaces the store of that tenant only.
+func (r *Router) Admin() admin.AdminServer {
+	return &Admin{router: r}
+}
+
+func (r *Router) Watcher() watcher.WatcherServer {
+	return &Watcher{router: r}
+}
+
+func (r *Router) Streamer() streamer.StreamerServer {
+	return &Streamer{router: r}
+}
+
+type Reader struct {
+	dsr3.UnimplementedReaderServer
+
+	router *Router
+}
+
+func (s *Reader) GetObject(ctx context.Context, req *dsr3.GetObjectRequest) (*dsr3.GetObjectResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.GetObject, req)
+}
+
+func (s *Reader) GetObjectMany(ctx context.Context, req *dsr3.GetObjectManyRequest) (*dsr3.GetObjectManyResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.GetObjectMany, req)
+}
+
+func (s *Reader) GetObjects(ctx context.Context, req *dsr3.GetObjectsRequest) (*dsr3.GetObjectsResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.GetObjects, req)
+}
+
+func (s *Reader) GetRelation(ctx context.Context, req *dsr3.GetRelationRequest) (*dsr3.GetRelationResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.GetRelation, req)
+}
+
+func (s *Reader) GetRelations(ctx context.Context, req *dsr3.GetRelationsRequest) (*dsr3.GetRelationsResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.GetRelations, req)
+}
+
+func (s *Reader) Check(ctx context.Context, req *dsr3.CheckRequest) (*dsr3.CheckResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.Check, req)
+}
+
+func (s *Reader) Checks(ctx context.Context, req *dsr3.ChecksRequest) (*dsr3.ChecksResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.Checks, req)
+}
+
+//nolint:staticcheck // CheckPermission is deprecated, dispatched for backwards compatibility.
+func (s *Reader) CheckPermission(ctx context.Context, req *dsr3.CheckPermissionRequest) (*dsr3.CheckPermissionResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.CheckPermission, req)
+}
+
+//nolint:staticcheck // CheckRelation is deprecated, dispatched for backwards compatibility.
+func (s *Reader) CheckRelation(ctx context.Context, req *dsr3.CheckRelationRequest) (*dsr3.CheckRelationResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.CheckRelation, req)
+}
+
+func (s *Reader) GetGraph(ctx context.Context, req *dsr3.GetGraphRequest) (*dsr3.GetGraphResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Reader3, dsr3.ReaderServer.GetGraph, req)
+}
+
+type Writer struct {
+	dsw3.UnimplementedWriterServer
+
+	router *Router
+}
+
+func (s *Writer) SetObject(ctx context.Context, req *dsw3.SetObjectRequest) (*dsw3.SetObjectResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Writer3, dsw3.WriterServer.SetObject, req)
+}
+
+func (s *Writer) DeleteObject(ctx context.Context, req *dsw3.DeleteObjectRequest) (*dsw3.DeleteObjectResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Writer3, dsw3.WriterServer.DeleteObject, req)
+}
+
+func (s *Writer) SetRelation(ctx context.Context, req *dsw3.SetRelationRequest) (*dsw3.SetRelationResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Writer3, dsw3.WriterServer.SetRelation, req)
+}
+
+func (s *Writer) DeleteRelation(ctx context.Context, req *dsw3.DeleteRelationRequest) (*dsw3.DeleteRelationResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Writer3, dsw3.WriterServer.DeleteRelation, req)
+}
+
+type Model struct {
+	dsm3.UnimplementedModelServer
+
+	router *Router
+}
+
+func (s *Model) GetManifest(req *dsm3.GetManifestRequest, ss dsm3.Model_GetManifestServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Model3().GetManifest(req, ss)
+	})
+}
+
+func (s *Model) SetManifest(ss dsm3.Model_SetManifestServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Model3().SetManifest(ss)
+	})
+}
+
+func (s *Model) DeleteManifest(ctx context.Context, req *dsm3.DeleteManifestRequest) (*dsm3.DeleteManifestResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Model3, dsm3.ModelServer.DeleteManifest, req)
+}
+
+type Importer struct {
+	dsi3.UnimplementedImporterServer
+
+	router *Router
+}
+
+func (s *Importer) Import(ss dsi3.Importer_ImportServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Importer3().Import(ss)
+	})
+}
+
+type Exporter struct {
+	dse3.UnimplementedExporterServer
+
+	router *Router
+}
+
+func (s *Exporter) Export(req *dse3.ExportRequest, ss dse3.Exporter_ExportServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Exporter3().Export(req, ss)
+	})
+}
+
+// Admin, administers the store of the tenant directory identified by the call context.
+type Admin struct {
+	router *Router
+}
+
+func (s *Admin) Backup(req *emptypb.Empty, ss admin.Admin_BackupServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Admin().Backup(req, ss)
+	})
+}
+
+func (s *Admin) Restore(ss admin.Admin_RestoreServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Admin().Restore(ss)
+	})
+}
+
+func (s *Admin) Verify(ctx context.Context, req *emptypb.Empty) (*structpb.Struct, error) {
+	return unary(ctx, s.router, (*directory.Directory).Admin, admin.AdminServer.Verify, req)
+}
+
+func (s *Admin) Repair(ctx context.Context, req *emptypb.Empty) (*structpb.Struct, error) {
+	return unary(ctx, s.router, (*directory.Directory).Admin, admin.AdminServer.Repair, req)
+}
+
+// Watcher, streams the change feed of the tenant directory identified by the stream context.
+type Watcher struct {
+	router *Router
+}
+
+func (s *Watcher) Watch(req *wrapperspb.UInt64Value, ss watcher.Watcher_WatchServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Watcher3().Watch(req, ss)
+	})
+}
+
+func (s *Watcher) Revision(ctx context.Context, req *emptypb.Empty) (*wrapperspb.UInt64Value, error) {
+	return unary(ctx, s.router, (*directory.Directory).Watcher3, (*v3.Watcher).Revision, req)
+}
+
+// Streamer, streams the objects and relations of the tenant directory identified by the stream context.
+type Streamer struct {
+	router *Router
+}
+
+func (s *Streamer) StreamObjects(req *dsr3.GetObjectsRequest, ss streamer.Streamer_StreamObjectsServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Streamer3().StreamObjects(req, ss)
+	})
+}
+
+func (s *Streamer) StreamRelations(req *dsr3.GetRelationsRequest, ss streamer.Streamer_StreamRelationsServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Streamer3().StreamRelations(req, ss)
+	})
+}
+
+func (s *Streamer) PreviewDeleteObject(req *dsw3.DeleteObjectRequest, ss streamer.Streamer_PreviewDeleteObjectServer) error {
+	return stream(ss.Context(), s.router, func(dir *directory.Directory) error {
+		return dir.Streamer3().PreviewDeleteObject(req, ss)
+	})
+}
+
+type Access struct {
+	dsa1.UnimplementedAccessServer
+
+	router *Router
+}
+
+func (s *Access) Evaluation(ctx context.Context, req *dsa1.EvaluationRequest) (*dsa1.EvaluationResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Access1, dsa1.AccessServer.Evaluation, req)
+}
+
+func (s *Access) Evaluations(ctx context.Context, req *dsa1.EvaluationsRequest) (*dsa1.EvaluationsResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Access1, dsa1.AccessServer.Evaluations, req)
+}
+
+func (s *Access) SubjectSearch(ctx context.Context, req *dsa1.SubjectSearchRequest) (*dsa1.SubjectSearchResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Access1, dsa1.AccessServer.SubjectSearch, req)
+}
+
+func (s *Access) ResourceSearch(ctx context.Context, req *dsa1.ResourceSearchRequest) (*dsa1.ResourceSearchResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Access1, dsa1.AccessServer.ResourceSearch, req)
+}
+
+func (s *Access) ActionSearch(ctx context.Context, req *dsa1.ActionSearchRequest) (*dsa1.ActionSearchResponse, error) {
+	return unary(ctx, s.router, (*directory.Directory).Access1, dsa1.AccessServer.ActionSearch, req)
+}
@@ -0,0 +1,152 @@
+package watcher
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Watcher, edge directory change feed service.
+//
+// Watch streams the changes recorded after the revision resume token, in revision order, and continues streaming
+// new changes as they are committed, a resume token of 0 starts streaming from the current store revision.
+// Revision returns the current store revision, which can be used as the resume token of a subsequent Watch call.
+// The service uses well-known message types only, changes are sent as google.protobuf.Struct:
+//
+//	{
+//	  "revision":  "42",                     // store revision of the change, as string to retain uint64 precision.
+//	  "op":        "OBJECT_SET",             // ds.ChangeOp name.
+//	  "timestamp": "2024-01-01T00:00:00Z",   // RFC 3339 commit timestamp.
+//	  "object":    {...},                    // JSON encoded instance, depending on the op either object,
+//	  "relation":  {...},                    // relation or manifest (metadata) is set.
+//	  "manifest":  {...}
+//	}
+//
+// A RESET change, without instance, is sent when the store has been replaced by a restored snapshot,
+// the changes preceding the reset are not available, subscribers discard their state and re-read the store.
+//
+//	service Watcher {
+//	  rpc Watch(google.protobuf.UInt64Value) returns (stream google.protobuf.Struct);
+//	  rpc Revision(google.protobuf.Empty) returns (google.protobuf.UInt64Value);
+//	}
+const (
+	ServiceName            string = "aserto.directory.edge.watcher.v1.Watcher"
+	WatchFullMethodName    string = "/" + ServiceName + "/Watch"
+	RevisionFullMethodName string = "/" + ServiceName + "/Revision"
+)
+
+// WatcherServer, server API of the Watcher service.
+type WatcherServer interface {
+	Watch(*wrapperspb.UInt64Value, Watcher_WatchServer) error
+	Revision(context.Context, *emptypb.Empty) (*wrapperspb.UInt64Value, error)
+}
+
+type Watcher_WatchServer interface { //nolint:revive,stylecheck // follows the naming of generated gRPC code.
+	Send(*structpb.Struct) error
+	grpc.ServerStream
+}
+
+// RegisterWatcherServer, registers the Watcher service implementation with the gRPC server.
+func RegisterWatcherServer(s grpc.ServiceRegistrar, srv WatcherServer) {
+	s.RegisterService(&Watcher_ServiceDesc, srv)
+}
+
+var Watcher_ServiceDesc = grpc.ServiceDesc{ //nolint:revive,stylecheck // follows the naming of generated gRPC code.
+	ServiceName: ServiceName,
+	HandlerType: (*WatcherServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Revision",
+			Handler:    revisionHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       watchHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func watchHandler(srv any, stream grpc.ServerStream) error {
+	req := &wrapperspb.UInt64Value{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(WatcherServer).Watch(req, &grpc.GenericServerStream[wrapperspb.UInt64Value, structpb.Struct]{ServerStream: stream})
+}
+
+func revisionHandler(
+	srv any,
+	ctx context.Context, //nolint:revive // follows the signature of grpc.MethodHandler.
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	in := &emptypb.Empty{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(WatcherServer).Revision(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: RevisionFullMethodName}
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(WatcherServer).Revision(ctx, req.(*emptypb.Empty))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// WatcherClient, client API of the Watcher service.
+type WatcherClient interface {
+	Watch(ctx context.Context, in *wrapperspb.UInt64Value, opts ...grpc.CallOption) (grpc.ServerStreamingClient[structpb.Struct], error)
+	Revision(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*wrapperspb.UInt64Value, error)
+}
+
+type watcherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewWatcherClient, returns a Watcher service client using the client connection.
+func NewWatcherClient(cc grpc.ClientConnInterface) WatcherClient {
+	return &watcherClient{cc: cc}
+}
+
+func (c *watcherClient) Watch(
+	ctx context.Context,
+	in *wrapperspb.UInt64Value,
+	opts ...grpc.CallOption,
+) (grpc.ServerStreamingClient[structpb.Struct], error) {
+	stream, err := c.cc.NewStream(ctx, &Watcher_ServiceDesc.Streams[0], WatchFullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &grpc.GenericClientStream[wrapperspb.UInt64Value, structpb.Struct]{ClientStream: stream}
+	if err := x.SendMsg(in); err != nil {
+		return nil, err
+	}
+
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (c *watcherClient) Revision(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*wrapperspb.UInt64Value, error) {
+	out := &wrapperspb.UInt64Value{}
+	if err := c.cc.Invoke(ctx, RevisionFullMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
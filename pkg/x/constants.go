@@ -4,3 +4,76 @@ const (
 	MaxPageSize             int32 = 100
 	MaxObjectIdentifierSize int   = 384
 )
+
+const (
+	// RevisionHeader, response header (or trailer for streaming calls) containing the store revision observed by a write,
+	// revisions are local to the store, the store is identified by the RevisionOriginHeader.
+	RevisionHeader string = "aserto-revision"
+	// RevisionOriginHeader, response header containing the id of the store the revision originates from, and request
+	// header identifying the origin of the at-least revision token, absent the token originates from the local store.
+	RevisionOriginHeader string = "aserto-revision-origin"
+	// AtLeastRevisionHeader, request header containing the minimal revision of the origin store a read must observe,
+	// on a directory fed by sync, tokens of the upstream directory are compared against the last applied upstream revision.
+	AtLeastRevisionHeader string = "aserto-at-least-revision"
+	// RevisionTimeoutHeader, request header containing the maximum duration (e.g. "500ms") a read waits for the
+	// store to reach the requested revision, when absent the read fails fast.
+	RevisionTimeoutHeader string = "aserto-revision-timeout"
+)
+
+// TenantIDHeader, request header containing the tenant id, used by the tenant router to dispatch requests.
+const TenantIDHeader string = "aserto-tenant-id"
+
+// ContextualRelationHeader, request header containing a JSON encoded relation identifier, which participates
+// in the evaluation of the checks of the request only, the header can be repeated to pass multiple relations.
+const ContextualRelationHeader string = "aserto-contextual-relation"
+
+// RelationExpiresAtHeader, request header containing the RFC 3339 expiration time of the relation set by the request
+// (SetRelation), or of the relations set by the import stream (Import), the relation is ignored by checks and reads
+// once expired and deleted by the directory expiry reaper.
+// GetRelation returns the expiration time of a time-bounded relation in the response header.
+const RelationExpiresAtHeader string = "aserto-relation-expires-at"
+
+const (
+	// SortByHeader, request header containing the timestamp (created_at or updated_at) by which the results of
+	// GetObjects and GetRelations are ordered, prefixed by "-" for descending order.
+	SortByHeader string = "aserto-sort-by"
+	// CreatedAfterHeader and CreatedBeforeHeader, request headers containing the RFC 3339 bounds (exclusive)
+	// of the created_at time window of the results of GetObjects and GetRelations.
+	CreatedAfterHeader  string = "aserto-created-after"
+	CreatedBeforeHeader string = "aserto-created-before"
+	// UpdatedAfterHeader and UpdatedBeforeHeader, request headers containing the RFC 3339 bounds (exclusive)
+	// of the updated_at time window of the results of GetObjects and GetRelations.
+	UpdatedAfterHeader  string = "aserto-updated-after"
+	UpdatedBeforeHeader string = "aserto-updated-before"
+)
+
+// PropertyHeader, request header containing a property filter ("name=value") of GetObjects, which returns the objects
+// of the requested object type with the property value, using the property index declared for the object type.
+const PropertyHeader string = "aserto-property"
+
+// SubjectAliasHeader, request header flagging the subject id of Check and CheckPermission as an identity alias ("true"),
+// which is resolved to the id of the subject identified by the alias before the check is evaluated.
+const SubjectAliasHeader string = "aserto-subject-alias"
+
+const (
+	// SearchHeader, request header containing a full-text search query of GetObjects, which returns the objects
+	// (of the requested object type, when set) of which the display name or search properties match all query terms.
+	SearchHeader string = "aserto-search"
+	// SearchModeHeader, request header containing the search mode, "prefix" (default) matches the terms starting with
+	// the query terms, "term" matches the terms equal to the query terms.
+	SearchModeHeader string = "aserto-search-mode"
+)
+
+const (
+	// DryRunHeader, request header requesting a dry-run ("true") of DeleteObject, which checks the preconditions of the
+	// delete, and returns the number of relations deleted with the object instance (with_relations) in the
+	// DeletedRelationCountHeader response header, without deleting them.
+	// The relations are only listed by the streaming preview of the delete (Streamer.PreviewDeleteObject).
+	DryRunHeader string = "aserto-dry-run"
+	// DeletedRelationCountHeader, response header containing the number of relations deleted with the object instance.
+	DeletedRelationCountHeader string = "aserto-deleted-relation-count"
+)
+
+// TombstoneHorizonHeader, response header of exports requesting tombstones, containing the RFC 3339 time before which
+// tombstones have been purged, incremental exports starting before the horizon miss the purged deletes.
+const TombstoneHorizonHeader string = "aserto-tombstone-horizon"
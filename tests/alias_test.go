@@ -0,0 +1,142 @@
+package tests_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+	acc1 "github.com/authzen/access.go/api/access/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// identityTypes, identity object type, appended to the types of the test manifest.
+const identityTypes = `
+  identity:
+    relations:
+      identifier: user
+`
+
+func TestSubjectAlias(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, append(manifest, identityTypes...)))
+	t.Cleanup(func() { require.NoError(t, deleteManifest(client)) })
+
+	for _, rel := range []*dsc3.Relation{
+		{ObjectType: "document", ObjectId: "alias-doc", Relation: "writer", SubjectType: "user", SubjectId: "alias-user-1"},
+		{ObjectType: "identity", ObjectId: "alice@acme.com", Relation: "identifier", SubjectType: "user", SubjectId: "alias-user-1"},
+		{ObjectType: "identity", ObjectId: "shared@acme.com", Relation: "identifier", SubjectType: "user", SubjectId: "alias-user-1"},
+		{ObjectType: "identity", ObjectId: "shared@acme.com", Relation: "identifier", SubjectType: "user", SubjectId: "alias-user-2"},
+	} {
+		for _, obj := range []*dsc3.Object{
+			{Type: rel.GetObjectType(), Id: rel.GetObjectId()},
+			{Type: rel.GetSubjectType(), Id: rel.GetSubjectId()},
+		} {
+			_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: obj})
+			require.NoError(t, err)
+		}
+
+		_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+		require.NoError(t, err)
+	}
+
+	aliasCtx := metadata.AppendToOutgoingContext(ctx, x.SubjectAliasHeader, "true")
+
+	check := func(subjectID string) *dsr3.CheckRequest {
+		return &dsr3.CheckRequest{
+			ObjectType: "document", ObjectId: "alias-doc", Relation: "edit", SubjectType: "user", SubjectId: subjectID,
+		}
+	}
+
+	t.Run("check", func(t *testing.T) {
+		resp, err := client.V3.Reader.Check(aliasCtx, check("alice@acme.com"))
+		require.NoError(t, err)
+		require.True(t, resp.GetCheck())
+
+		// without the alias flag, the subject id is not resolved.
+		resp, err = client.V3.Reader.Check(ctx, check("alice@acme.com"))
+		require.NoError(t, err)
+		require.False(t, resp.GetCheck())
+	})
+
+	t.Run("check-permission", func(t *testing.T) {
+		resp, err := client.V3.Reader.CheckPermission(aliasCtx, &dsr3.CheckPermissionRequest{
+			ObjectType: "document", ObjectId: "alias-doc", Permission: "edit", SubjectType: "user", SubjectId: "alice@acme.com",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetCheck())
+	})
+
+	t.Run("evaluation", func(t *testing.T) {
+		props, err := structpb.NewStruct(map[string]any{"alias": true})
+		require.NoError(t, err)
+
+		resp, err := client.V3.Access.Evaluation(ctx, &acc1.EvaluationRequest{
+			Subject:  &acc1.Subject{Type: "user", Id: "alice@acme.com", Properties: props},
+			Action:   &acc1.Action{Name: "edit"},
+			Resource: &acc1.Resource{Type: "document", Id: "alias-doc"},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetDecision())
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		_, err := client.V3.Reader.Check(aliasCtx, check("nobody@acme.com"))
+		require.Equal(t, codes.NotFound, status.Code(err))
+		require.ErrorContains(t, err, "subject alias not found")
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		_, err := client.V3.Reader.Check(aliasCtx, check("shared@acme.com"))
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		require.ErrorContains(t, err, "subject alias ambiguous")
+	})
+
+	t.Run("request-not-mutated", func(t *testing.T) {
+		req := check("alice@acme.com")
+
+		resp, err := client.Directory.Reader3().Check(metadata.NewIncomingContext(ctx, metadata.Pairs(x.SubjectAliasHeader, "true")), req)
+		require.NoError(t, err)
+		require.True(t, resp.GetCheck())
+		require.Equal(t, "alice@acme.com", req.GetSubjectId())
+	})
+
+	t.Run("expired-identifier", func(t *testing.T) {
+		_, err := client.V3.Writer.SetRelation(
+			metadata.AppendToOutgoingContext(ctx, x.RelationExpiresAtHeader, time.Now().Add(100*time.Millisecond).Format(time.RFC3339Nano)),
+			&dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+				ObjectType: "identity", ObjectId: "former@acme.com", Relation: "identifier", SubjectType: "user", SubjectId: "alias-user-1",
+			}},
+		)
+		require.NoError(t, err)
+
+		resp, err := client.V3.Reader.Check(aliasCtx, check("former@acme.com"))
+		require.NoError(t, err)
+		require.True(t, resp.GetCheck())
+
+		time.Sleep(200 * time.Millisecond)
+
+		_, err = client.V3.Reader.Check(aliasCtx, check("former@acme.com"))
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("invalid-flag", func(t *testing.T) {
+		_, err := client.V3.Reader.Check(metadata.AppendToOutgoingContext(ctx, x.SubjectAliasHeader, "maybe"), check("alice@acme.com"))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
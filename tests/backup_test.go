@@ -0,0 +1,225 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb/migrations/common"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+	dir := t.TempDir()
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:         path.Join(dir, "backup.db"),
+		RequestTimeout: 2 * time.Second,
+	})
+	t.Cleanup(cleanup)
+
+	adminCtx := metadata.AppendToOutgoingContext(ctx, server.TestAdminHeader, server.TestAdminToken)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	setUser := func(t *testing.T, id string) {
+		t.Helper()
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id}})
+		require.NoError(t, err)
+	}
+
+	users := func(t *testing.T) []string {
+		t.Helper()
+
+		resp, err := client.V3.Reader.GetObjects(ctx, &dsr3.GetObjectsRequest{ObjectType: "user"})
+		require.NoError(t, err)
+
+		ids := []string{}
+		for _, obj := range resp.GetResults() {
+			ids = append(ids, obj.GetId())
+		}
+
+		return ids
+	}
+
+	backup := func(t *testing.T) []byte {
+		t.Helper()
+
+		stream, err := client.Admin.Backup(adminCtx, &emptypb.Empty{})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return buf.Bytes()
+			}
+
+			require.NoError(t, err)
+			buf.Write(chunk.GetValue())
+		}
+	}
+
+	restore := func(snapshot []byte) error {
+		stream, err := client.Admin.Restore(adminCtx)
+		if err != nil {
+			return err
+		}
+
+		for len(snapshot) > 0 {
+			n := min(len(snapshot), 1000)
+
+			if err := stream.Send(&wrapperspb.BytesValue{Value: snapshot[:n]}); err != nil {
+				return err
+			}
+
+			snapshot = snapshot[n:]
+		}
+
+		_, err = stream.CloseAndRecv()
+
+		return err
+	}
+
+	setUser(t, "backup-alice")
+
+	snapshot := backup(t)
+
+	t.Run("restore-stream", func(t *testing.T) {
+		setUser(t, "backup-bob")
+		require.NoError(t, deleteManifest(client))
+
+		require.NoError(t, restore(snapshot))
+
+		// the model is reloaded from the restored store, objects of the manifest types are readable again.
+		require.Equal(t, []string{"backup-alice"}, users(t))
+	})
+
+	t.Run("restore-reset", func(t *testing.T) {
+		wCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		rev := watchRevision(ctx, t, client)
+		ws := watch(wCtx, t, client, rev)
+
+		setUser(t, "backup-bob")
+		require.NoError(t, restore(snapshot))
+		require.Equal(t, []string{"backup-alice"}, users(t))
+
+		// the restored snapshot is older than the store, the revision continues from the replaced store,
+		// watchers receive the change preceding the restore, followed by the reset.
+		for _, op := range []ds.ChangeOp{ds.ChangeObjectSet, ds.ChangeReset} {
+			select {
+			case change := <-ws.changes:
+				require.Equal(t, op, change.Op)
+				require.Greater(t, change.Revision, rev)
+				rev = change.Revision
+			case err := <-ws.done:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "timeout waiting for change", op.String())
+			}
+		}
+
+		require.Equal(t, rev, watchRevision(ctx, t, client))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := client.Admin.Verify(ctx, &emptypb.Empty{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		stream, err := client.Admin.Restore(ctx)
+		require.NoError(t, err)
+
+		_, err = stream.CloseAndRecv()
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.Equal(t, []string{"backup-alice"}, users(t))
+	})
+
+	t.Run("restore-file", func(t *testing.T) {
+		file := path.Join(dir, "snapshot.db")
+
+		_, err := client.Directory.BackupFile(ctx, file)
+		require.NoError(t, err)
+
+		setUser(t, "backup-carol")
+		require.Equal(t, []string{"backup-alice", "backup-carol"}, users(t))
+
+		require.NoError(t, client.Directory.RestoreFile(ctx, file))
+		require.Equal(t, []string{"backup-alice"}, users(t))
+	})
+
+	t.Run("restore-older-schema", func(t *testing.T) {
+		file := path.Join(dir, "older.db")
+		require.NoError(t, os.WriteFile(file, snapshot, 0o600))
+
+		db, err := bolt.Open(file, 0o600, nil)
+		require.NoError(t, err)
+		require.NoError(t, common.SetVersion(db, semver.MustParse("0.0.18")))
+		require.NoError(t, db.Close())
+
+		setUser(t, "backup-dave")
+
+		require.NoError(t, client.Directory.RestoreFile(ctx, file))
+		require.Equal(t, []string{"backup-alice"}, users(t))
+	})
+
+	t.Run("restore-newer-schema", func(t *testing.T) {
+		file := path.Join(dir, "newer.db")
+		require.NoError(t, os.WriteFile(file, snapshot, 0o600))
+
+		db, err := bolt.Open(file, 0o600, nil)
+		require.NoError(t, err)
+		require.NoError(t, common.SetVersion(db, semver.MustParse("9.0.0")))
+		require.NoError(t, db.Close())
+
+		err = client.Directory.RestoreFile(ctx, file)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		require.Equal(t, []string{"backup-alice"}, users(t))
+	})
+
+	t.Run("restore-invalid-snapshot", func(t *testing.T) {
+		err := restore([]byte("not a snapshot"))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, []string{"backup-alice"}, users(t))
+	})
+
+	t.Run("memory-backend", func(t *testing.T) {
+		mem, err := directory.Open(ctx, &directory.Config{Backend: bdb.MemoryBackend, RequestTimeout: 2 * time.Second}, &logger)
+		require.NoError(t, err)
+		t.Cleanup(mem.Close)
+
+		_, err = mem.Backup(ctx, io.Discard)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		err = mem.Restore(ctx, bytes.NewReader(snapshot))
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
@@ -0,0 +1,228 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/go-http-utils/headers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeleteObjectCascade(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "cascade.db"),
+		RequestTimeout: 2 * time.Second,
+	})
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	member := func(objID, subType, subID, subRel string) *dsc3.Relation {
+		return &dsc3.Relation{
+			ObjectType: "group", ObjectId: objID, Relation: "member", SubjectType: subType, SubjectId: subID, SubjectRelation: subRel,
+		}
+	}
+
+	for _, rel := range []*dsc3.Relation{
+		member("eng", "user", "alice", ""),            // outgoing relation of group:eng.
+		member("eng-leads", "group", "eng", "member"), // userset of group:eng.
+		member("eng", "group", "eng", "member"),       // relation of group:eng with itself.
+		member("eng2", "user", "bob", ""),             // unrelated, the object id has the object id of group:eng as prefix.
+		member("all", "group", "eng2", "member"),      // unrelated userset.
+	} {
+		_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+		require.NoError(t, err)
+	}
+
+	_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "group", Id: "eng"}})
+	require.NoError(t, err)
+
+	countRelations := func(t *testing.T) int {
+		t.Helper()
+
+		resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{ObjectType: "group"})
+		require.NoError(t, err)
+
+		return len(resp.GetResults())
+	}
+
+	deleteEng := func(ctx context.Context, dryRun string, opts ...grpc.CallOption) error {
+		if dryRun != "" {
+			ctx = grpcmd.AppendToOutgoingContext(ctx, x.DryRunHeader, dryRun)
+		}
+
+		_, err := client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{
+			ObjectType: "group", ObjectId: "eng", WithRelations: true,
+		}, opts...)
+
+		return err
+	}
+
+	t.Run("dry-run", func(t *testing.T) {
+		var md grpcmd.MD
+
+		require.NoError(t, deleteEng(ctx, "true", grpc.Header(&md)))
+		require.Equal(t, []string{"3"}, md.Get(x.DeletedRelationCountHeader))
+
+		// the store is unchanged.
+		require.Equal(t, 5, countRelations(t))
+
+		_, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "group", ObjectId: "eng"})
+		require.NoError(t, err)
+	})
+
+	previewEng := func(ctx context.Context) ([]string, error) {
+		stream, err := client.Streamer.PreviewDeleteObject(ctx, &dsw3.DeleteObjectRequest{
+			ObjectType: "group", ObjectId: "eng", WithRelations: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		deleted := []string{}
+
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				sort.Strings(deleted)
+				return deleted, nil
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, rel := range resp.GetResults() {
+				deleted = append(deleted, rel.GetObjectId()+"@"+rel.GetSubjectId()+"#"+rel.GetSubjectRelation())
+			}
+		}
+	}
+
+	t.Run("preview", func(t *testing.T) {
+		deleted, err := previewEng(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"eng-leads@eng#member", "eng@alice#", "eng@eng#member"}, deleted)
+		require.Equal(t, 5, countRelations(t))
+	})
+
+	t.Run("preview-if-match", func(t *testing.T) {
+		obj, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "group", ObjectId: "eng"})
+		require.NoError(t, err)
+
+		// the preview and the dry-run apply the etag precondition of the delete.
+		_, err = previewEng(grpcmd.AppendToOutgoingContext(ctx, headers.IfMatch, "stale"))
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		stale := grpcmd.AppendToOutgoingContext(ctx, headers.IfMatch, "stale")
+		require.Equal(t, codes.FailedPrecondition, status.Code(deleteEng(stale, "true")))
+
+		deleted, err := previewEng(grpcmd.AppendToOutgoingContext(ctx, headers.IfMatch, obj.GetResult().GetEtag()))
+		require.NoError(t, err)
+		require.Len(t, deleted, 3)
+	})
+
+	t.Run("invalid-dry-run", func(t *testing.T) {
+		require.Equal(t, codes.InvalidArgument, status.Code(deleteEng(ctx, "maybe")))
+		require.Equal(t, 5, countRelations(t))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, deleteEng(ctx, "false"))
+
+		resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{ObjectType: "group"})
+		require.NoError(t, err)
+
+		remaining := []string{}
+		for _, rel := range resp.GetResults() {
+			remaining = append(remaining, rel.GetObjectId()+"@"+rel.GetSubjectId())
+		}
+
+		sort.Strings(remaining)
+		require.Equal(t, []string{"all@eng2", "eng2@bob"}, remaining)
+
+		_, err = client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "group", ObjectId: "eng"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		// the relation indexes are consistent after the cascade.
+		report, err := client.Directory.Verify(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Relations)
+		require.Empty(t, report.Issues)
+	})
+}
+
+func TestDeleteObjectCascadeIncomplete(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+	cfg := &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "cascade-incomplete.db"),
+		RequestTimeout: 2 * time.Second,
+	}
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+
+	userset := &dsc3.Relation{
+		ObjectType: "group", ObjectId: "ops-leads", Relation: "member", SubjectType: "group", SubjectId: "ops", SubjectRelation: "member",
+	}
+
+	// populate the store, and close it.
+	func() {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+		defer cleanup()
+
+		require.NoError(t, setManifest(client, manifest))
+
+		_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: userset})
+		require.NoError(t, err)
+	}()
+
+	// drop the subject index entry of the userset, the relation is no longer found through relations_sub.
+	db, err := bolt.Open(cfg.DBPath, 0o600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		return deleteRelationEntry(tx.Bucket([]byte("relations_sub")), "ops")
+	}))
+	require.NoError(t, db.Close())
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+	t.Cleanup(cleanup)
+
+	// the userset remains in relations_rel, the delete is aborted, leaving the store unchanged.
+	_, err = client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{ObjectType: "group", ObjectId: "ops", WithRelations: true})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.V3.Reader.GetRelation(ctx, &dsr3.GetRelationRequest{
+		ObjectType:      userset.GetObjectType(),
+		ObjectId:        userset.GetObjectId(),
+		Relation:        userset.GetRelation(),
+		SubjectType:     userset.GetSubjectType(),
+		SubjectId:       userset.GetSubjectId(),
+		SubjectRelation: userset.GetSubjectRelation(),
+	})
+	require.NoError(t, err)
+}
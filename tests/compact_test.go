@@ -0,0 +1,161 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCompact(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "compact.db"),
+		RequestTimeout: 2 * time.Second,
+	})
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	const count = 2000
+
+	props, err := structpb.NewStruct(map[string]any{"padding": strings.Repeat("x", 512)})
+	require.NoError(t, err)
+
+	// import the objects, and delete all but the first ten, leaving free pages in the store file.
+	importUsers := func(t *testing.T, opCode dsi3.Opcode, from int) {
+		t.Helper()
+
+		g, iCtx := errgroup.WithContext(ctx)
+
+		stream, err := client.V3.Importer.Import(iCtx)
+		require.NoError(t, err)
+
+		g.Go(receiver(stream))
+
+		for i := from; i < count; i++ {
+			require.NoError(t, stream.Send(&dsi3.ImportRequest{
+				OpCode: opCode,
+				Msg: &dsi3.ImportRequest_Object{Object: &dsc3.Object{
+					Type: "user", Id: fmt.Sprintf("compact-user-%04d", i), Properties: props,
+				}},
+			}))
+		}
+
+		require.NoError(t, stream.CloseSend())
+		require.NoError(t, g.Wait())
+	}
+
+	importUsers(t, dsi3.Opcode_OPCODE_SET, 0)
+	importUsers(t, dsi3.Opcode_OPCODE_DELETE, 10)
+
+	countUsers := func(t *testing.T) int {
+		t.Helper()
+
+		n := 0
+		req := &dsr3.GetObjectsRequest{ObjectType: "user", Page: &dsc3.PaginationRequest{Size: 100}}
+
+		for {
+			resp, err := client.V3.Reader.GetObjects(ctx, req)
+			require.NoError(t, err)
+
+			n += len(resp.GetResults())
+
+			if resp.GetPage().GetNextToken() == "" {
+				return n
+			}
+
+			req.Page.Token = resp.GetPage().GetNextToken()
+		}
+	}
+
+	require.Equal(t, 10, countUsers(t))
+
+	t.Run("compact", func(t *testing.T) {
+		stats, err := client.Directory.Compact(ctx)
+		require.NoError(t, err)
+
+		require.Less(t, stats.After.Size, stats.Before.Size)
+		require.Positive(t, stats.Before.FreeAlloc)
+		require.Equal(t, 10, countUsers(t))
+
+		fi, err := os.Stat(client.Directory.Config().DBPath)
+		require.NoError(t, err)
+		require.Equal(t, stats.After.Size, fi.Size())
+	})
+
+	t.Run("concurrent-writes", func(t *testing.T) {
+		wCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var g errgroup.Group
+
+		// writes continue while compacting, the writes made during the copy are copied into the compacted store.
+		written := 0
+
+		g.Go(func() error {
+			for ; wCtx.Err() == nil; written++ {
+				if _, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{
+					Type: "user", Id: fmt.Sprintf("compact-writer-%04d", written),
+				}}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		journaled := 0
+
+		for range 50 {
+			stats, err := client.Directory.Compact(ctx)
+			require.NoError(t, err)
+
+			if journaled += stats.Journaled; journaled > 0 {
+				break
+			}
+		}
+
+		cancel()
+		require.NoError(t, g.Wait())
+
+		require.Positive(t, journaled)
+		require.Equal(t, 10+written, countUsers(t))
+
+		report, err := client.Directory.Verify(ctx)
+		require.NoError(t, err)
+		require.Empty(t, report.Issues)
+	})
+
+	t.Run("memory-backend", func(t *testing.T) {
+		mem, err := directory.Open(ctx, &directory.Config{Backend: bdb.MemoryBackend, RequestTimeout: 2 * time.Second}, &logger)
+		require.NoError(t, err)
+		t.Cleanup(mem.Close)
+
+		_, err = mem.Compact(ctx)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
@@ -0,0 +1,147 @@
+package tests_test
+
+import (
+	"os"
+	"testing"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+	dsa1 "github.com/authzen/access.go/api/access/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestContextualRelations(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	for _, obj := range []*dsc3.Object{
+		{Type: "user", Id: "ctx-user@acmecorp.com"},
+		{Type: "group", Id: "ctx-device-group"},
+		{Type: "document", Id: "ctx-document"},
+	} {
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: obj})
+		require.NoError(t, err)
+	}
+
+	_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+		ObjectType: "document", ObjectId: "ctx-document", Relation: "writer",
+		SubjectType: "user", SubjectId: "writer@acmecorp.com",
+	}})
+	require.NoError(t, err)
+
+	member := `{"object_type":"group","object_id":"ctx-device-group","relation":"member",` +
+		`"subject_type":"user","subject_id":"ctx-user@acmecorp.com"}`
+	reader := `{"object_type":"document","object_id":"ctx-document","relation":"reader",` +
+		`"subject_type":"user","subject_id":"ctx-user@acmecorp.com"}`
+
+	withRelations := func(rels ...string) []string {
+		md := []string{}
+		for _, rel := range rels {
+			md = append(md, x.ContextualRelationHeader, rel)
+		}
+
+		return md
+	}
+
+	check := func(req *dsr3.CheckRequest, md ...string) bool {
+		resp, err := client.V3.Reader.Check(metadata.AppendToOutgoingContext(ctx, md...), req)
+		require.NoError(t, err)
+
+		return resp.GetCheck()
+	}
+
+	memberCheck := &dsr3.CheckRequest{
+		ObjectType: "group", ObjectId: "ctx-device-group", Relation: "member",
+		SubjectType: "user", SubjectId: "ctx-user@acmecorp.com",
+	}
+
+	t.Run("check", func(t *testing.T) {
+		require.False(t, check(memberCheck))
+		require.True(t, check(memberCheck, withRelations(member)...))
+		require.False(t, check(memberCheck), "contextual relations are not persisted")
+	})
+
+	t.Run("check-permission", func(t *testing.T) {
+		req := &dsr3.CheckPermissionRequest{
+			ObjectType: "document", ObjectId: "ctx-document", Permission: "view",
+			SubjectType: "user", SubjectId: "ctx-user@acmecorp.com",
+		}
+
+		_, err := client.V3.Reader.CheckPermission(ctx, req)
+		require.Equal(t, codes.NotFound, status.Code(err), "subject without relations")
+
+		resp, err := client.V3.Reader.CheckPermission(metadata.AppendToOutgoingContext(ctx, withRelations(reader)...), req)
+		require.NoError(t, err)
+		require.True(t, resp.GetCheck())
+	})
+
+	t.Run("checks", func(t *testing.T) {
+		resp, err := client.V3.Reader.Checks(
+			metadata.AppendToOutgoingContext(ctx, withRelations(member, reader)...),
+			&dsr3.ChecksRequest{
+				Default: &dsr3.CheckRequest{SubjectType: "user", SubjectId: "ctx-user@acmecorp.com"},
+				Checks: []*dsr3.CheckRequest{
+					{ObjectType: "group", ObjectId: "ctx-device-group", Relation: "member"},
+					{ObjectType: "document", ObjectId: "ctx-document", Relation: "view"},
+					{ObjectType: "document", ObjectId: "ctx-document", Relation: "edit"},
+				},
+			})
+		require.NoError(t, err)
+		require.Len(t, resp.GetChecks(), 3)
+		require.True(t, resp.GetChecks()[0].GetCheck())
+		require.True(t, resp.GetChecks()[1].GetCheck())
+		require.False(t, resp.GetChecks()[2].GetCheck())
+	})
+
+	t.Run("evaluation", func(t *testing.T) {
+		evalCtx, err := structpb.NewStruct(map[string]any{
+			"contextual_relations": []any{
+				map[string]any{
+					"object_type": "document", "object_id": "ctx-document", "relation": "reader",
+					"subject_type": "user", "subject_id": "ctx-user@acmecorp.com",
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		req := &dsa1.EvaluationRequest{
+			Subject:  &dsa1.Subject{Type: "user", Id: "ctx-user@acmecorp.com"},
+			Action:   &dsa1.Action{Name: "view"},
+			Resource: &dsa1.Resource{Type: "document", Id: "ctx-document"},
+		}
+
+		resp, err := client.V3.Access.Evaluation(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp.GetDecision())
+
+		req.Context = evalCtx
+
+		resp, err = client.V3.Access.Evaluation(ctx, req)
+		require.NoError(t, err)
+		require.True(t, resp.GetDecision())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := client.V3.Reader.Check(metadata.AppendToOutgoingContext(ctx, withRelations("{")...), memberCheck)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		unknown := `{"object_type":"group","object_id":"ctx-device-group","relation":"unknown",` +
+			`"subject_type":"user","subject_id":"ctx-user@acmecorp.com"}`
+
+		_, err = client.V3.Reader.Check(metadata.AppendToOutgoingContext(ctx, withRelations(unknown)...), memberCheck)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
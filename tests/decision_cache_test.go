@@ -0,0 +1,173 @@
+package tests_test
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDecisionCache(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:            path.Join(t.TempDir(), "decision-cache.db"),
+		RequestTimeout:    2 * time.Second,
+		DecisionCacheSize: 2,
+	})
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	rel := &dsc3.Relation{
+		ObjectType:  "group",
+		ObjectId:    "gateway",
+		Relation:    "member",
+		SubjectType: "user",
+		SubjectId:   "cached@acmecorp.com",
+	}
+
+	_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+	require.NoError(t, err)
+
+	memberCheck := &dsr3.CheckRequest{
+		ObjectType:  rel.GetObjectType(),
+		ObjectId:    rel.GetObjectId(),
+		Relation:    rel.GetRelation(),
+		SubjectType: rel.GetSubjectType(),
+		SubjectId:   rel.GetSubjectId(),
+	}
+
+	check := func() bool {
+		resp, err := client.V3.Reader.Check(ctx, memberCheck)
+		require.NoError(t, err)
+
+		return resp.GetCheck()
+	}
+
+	stats := client.Directory.DecisionCacheStats
+
+	t.Run("hit", func(t *testing.T) {
+		require.True(t, check())
+		require.True(t, check())
+		require.Equal(t, ds.DecisionCacheStats{Hits: 1, Misses: 1, Entries: 1}, stats())
+	})
+
+	t.Run("concurrent-checks", func(t *testing.T) {
+		checks := make([]*dsr3.CheckRequest, 32)
+		for i := range checks {
+			checks[i] = memberCheck
+		}
+
+		resp, err := client.V3.Reader.Checks(ctx, &dsr3.ChecksRequest{Checks: checks})
+		require.NoError(t, err)
+
+		for _, c := range resp.GetChecks() {
+			require.True(t, c.GetCheck())
+		}
+
+		require.Equal(t, uint64(33), stats().Hits)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		for _, id := range []string{"other-1@acmecorp.com", "other-2@acmecorp.com"} {
+			_, err := client.V3.Reader.Check(ctx, &dsr3.CheckRequest{
+				ObjectType:  rel.GetObjectType(),
+				ObjectId:    rel.GetObjectId(),
+				Relation:    rel.GetRelation(),
+				SubjectType: "user",
+				SubjectId:   id,
+			})
+			require.NoError(t, err)
+		}
+
+		require.LessOrEqual(t, stats().Entries, 2)
+	})
+
+	t.Run("invalidated-by-write", func(t *testing.T) {
+		// keep the object and subject related, so the check is evaluated after the delete.
+		for _, r := range []*dsc3.Relation{
+			{ObjectType: "group", ObjectId: "other-gateway", Relation: "member", SubjectType: "user", SubjectId: rel.GetSubjectId()},
+			{ObjectType: "group", ObjectId: rel.GetObjectId(), Relation: "member", SubjectType: "user", SubjectId: "other@acmecorp.com"},
+		} {
+			_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: r})
+			require.NoError(t, err)
+		}
+
+		_, err := client.V3.Writer.DeleteRelation(ctx, &dsw3.DeleteRelationRequest{
+			ObjectType:  rel.GetObjectType(),
+			ObjectId:    rel.GetObjectId(),
+			Relation:    rel.GetRelation(),
+			SubjectType: rel.GetSubjectType(),
+			SubjectId:   rel.GetSubjectId(),
+		})
+		require.NoError(t, err)
+
+		before := stats()
+
+		require.False(t, check())
+		require.False(t, check())
+
+		after := stats()
+		require.Equal(t, before.Misses+1, after.Misses)
+		require.Equal(t, before.Hits+1, after.Hits)
+		require.Equal(t, 1, after.Entries)
+	})
+	setExpiringRelation := func(r *dsc3.Relation, expiresAt time.Time) {
+		_, err := client.V3.Writer.SetRelation(
+			metadata.AppendToOutgoingContext(ctx, x.RelationExpiresAtHeader, expiresAt.Format(time.RFC3339Nano)),
+			&dsw3.SetRelationRequest{Relation: r},
+		)
+		require.NoError(t, err)
+	}
+
+	t.Run("unrelated-expiring-relation", func(t *testing.T) {
+		setExpiringRelation(&dsc3.Relation{
+			ObjectType: "group", ObjectId: "break-glass", Relation: "member", SubjectType: "user", SubjectId: "oncall@acmecorp.com",
+		}, time.Now().Add(time.Hour))
+
+		before := stats()
+
+		require.False(t, check())
+		require.False(t, check())
+
+		after := stats()
+		require.Equal(t, before.Misses+1, after.Misses)
+		require.Equal(t, before.Hits+1, after.Hits)
+	})
+
+	t.Run("expired-decision", func(t *testing.T) {
+		expiresAt := time.Now().Add(300 * time.Millisecond)
+		setExpiringRelation(rel, expiresAt)
+
+		before := stats()
+
+		require.True(t, check())
+		require.True(t, check())
+
+		time.Sleep(time.Until(expiresAt))
+
+		// the relation expired without a store mutation, the cached decision is a miss.
+		require.False(t, check())
+
+		after := stats()
+		require.Equal(t, before.Misses+2, after.Misses)
+		require.Equal(t, before.Hits+1, after.Hits)
+	})
+}
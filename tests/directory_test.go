@@ -0,0 +1,73 @@
+package tests_test
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMultipleDirectories(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+
+	open := func(cfg *directory.Config) (*server.TestEdgeClient, func()) {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+		require.NoError(t, setManifest(client, manifest))
+
+		return client, cleanup
+	}
+
+	tenantA := &directory.Config{DBPath: path.Join(t.TempDir(), "tenant-a.db"), RequestTimeout: time.Second * 2}
+	tenantB := &directory.Config{DBPath: path.Join(t.TempDir(), "tenant-b.db"), RequestTimeout: time.Second * 2}
+	tenantC := &directory.Config{Backend: bdb.MemoryBackend}
+
+	clientA, cleanupA := open(tenantA)
+	clientB, cleanupB := open(tenantB)
+	clientC, cleanupC := open(tenantC)
+
+	t.Cleanup(cleanupB)
+	t.Cleanup(cleanupC)
+
+	_, err = clientA.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+		Object: &dsc3.Object{Type: "user", Id: "tenant-a-user@acmecorp.com"},
+	})
+	require.NoError(t, err)
+
+	getObject := func(client *server.TestEdgeClient) error {
+		_, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{
+			ObjectType: "user",
+			ObjectId:   "tenant-a-user@acmecorp.com",
+		})
+
+		return err
+	}
+
+	require.NoError(t, getObject(clientA))
+	require.Equal(t, codes.NotFound, status.Code(getObject(clientB)))
+	require.Equal(t, codes.NotFound, status.Code(getObject(clientC)))
+
+	// closing the directory releases the store, so it can be reopened.
+	cleanupA()
+
+	clientA, cleanupA = server.NewTestEdgeServer(ctx, &logger, tenantA)
+	t.Cleanup(cleanupA)
+
+	require.NoError(t, getObject(clientA))
+}
@@ -0,0 +1,342 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
+	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/datasync"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestRelationExpiry(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "expiry.db"),
+		RequestTimeout: 2 * time.Second,
+		ExpiryInterval: 50 * time.Millisecond,
+	})
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	start := watchRevision(ctx, t, client)
+
+	rel := &dsc3.Relation{
+		ObjectType:  "group",
+		ObjectId:    "break-glass",
+		Relation:    "member",
+		SubjectType: "user",
+		SubjectId:   "oncall@acmecorp.com",
+	}
+
+	setRelation := func(expiresAt time.Time) error {
+		_, err := client.V3.Writer.SetRelation(
+			metadata.AppendToOutgoingContext(ctx, x.RelationExpiresAtHeader, expiresAt.Format(time.RFC3339Nano)),
+			&dsw3.SetRelationRequest{Relation: rel},
+		)
+
+		return err
+	}
+
+	check := func() bool {
+		resp, err := client.V3.Reader.Check(ctx, &dsr3.CheckRequest{
+			ObjectType:  rel.GetObjectType(),
+			ObjectId:    rel.GetObjectId(),
+			Relation:    rel.GetRelation(),
+			SubjectType: rel.GetSubjectType(),
+			SubjectId:   rel.GetSubjectId(),
+		})
+		require.NoError(t, err)
+
+		return resp.GetCheck()
+	}
+
+	t.Run("expiry-in-the-past", func(t *testing.T) {
+		require.Equal(t, codes.InvalidArgument, status.Code(setRelation(time.Now().Add(-time.Minute))))
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		require.NoError(t, setRelation(time.Now().Add(500*time.Millisecond)))
+		require.True(t, check())
+
+		require.Eventually(t, func() bool {
+			_, err := client.V3.Reader.GetRelation(ctx, &dsr3.GetRelationRequest{
+				ObjectType:  rel.GetObjectType(),
+				ObjectId:    rel.GetObjectId(),
+				Relation:    rel.GetRelation(),
+				SubjectType: rel.GetSubjectType(),
+				SubjectId:   rel.GetSubjectId(),
+			})
+
+			return status.Code(err) == codes.NotFound
+		}, 5*time.Second, 20*time.Millisecond)
+
+		require.False(t, check())
+	})
+
+	t.Run("delete-emitted", func(t *testing.T) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+
+		stream := watch(watchCtx, t, client, start)
+
+		ops := []ds.ChangeOp{}
+
+		for len(ops) < 2 {
+			select {
+			case change := <-stream.changes:
+				ops = append(ops, change.Op)
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "timeout waiting for changes", "received %v", ops)
+			}
+		}
+
+		// the rejected set is not recorded, the expired relation is deleted by the reaper.
+		require.Equal(t, []ds.ChangeOp{ds.ChangeRelationSet, ds.ChangeRelationDelete}, ops)
+	})
+
+	t.Run("permanent-on-set-without-expiry", func(t *testing.T) {
+		require.NoError(t, setRelation(time.Now().Add(200*time.Millisecond)))
+
+		_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+		require.NoError(t, err)
+
+		time.Sleep(400 * time.Millisecond)
+
+		require.True(t, check())
+	})
+}
+
+func TestRelationExpiryExportImport(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+
+	newClient := func(name string) *server.TestEdgeClient {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+			DBPath:         path.Join(t.TempDir(), name),
+			RequestTimeout: 2 * time.Second,
+		})
+		t.Cleanup(cleanup)
+
+		require.NoError(t, setManifest(client, manifest))
+
+		return client
+	}
+
+	source := newClient("source.db")
+
+	rel := &dsc3.Relation{
+		ObjectType:  "group",
+		ObjectId:    "break-glass",
+		Relation:    "member",
+		SubjectType: "user",
+		SubjectId:   "oncall@acmecorp.com",
+	}
+
+	getRelationRequest := &dsr3.GetRelationRequest{
+		ObjectType:  rel.GetObjectType(),
+		ObjectId:    rel.GetObjectId(),
+		Relation:    rel.GetRelation(),
+		SubjectType: rel.GetSubjectType(),
+		SubjectId:   rel.GetSubjectId(),
+	}
+
+	// relationExpiry, returns the expiration time of the relation returned in the GetRelation response header.
+	relationExpiry := func(t *testing.T, client *server.TestEdgeClient) time.Time {
+		t.Helper()
+
+		var md metadata.MD
+
+		_, err := client.V3.Reader.GetRelation(ctx, getRelationRequest, grpc.Header(&md))
+		require.NoError(t, err)
+		require.Len(t, md.Get(x.RelationExpiresAtHeader), 1)
+
+		expiresAt, err := time.Parse(time.RFC3339Nano, md.Get(x.RelationExpiresAtHeader)[0])
+		require.NoError(t, err)
+
+		return expiresAt
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+
+	_, err = source.V3.Writer.SetRelation(
+		metadata.AppendToOutgoingContext(ctx, x.RelationExpiresAtHeader, expiresAt.Format(time.RFC3339Nano)),
+		&dsw3.SetRelationRequest{Relation: rel},
+	)
+	require.NoError(t, err)
+
+	require.True(t, expiresAt.Equal(relationExpiry(t, source)))
+
+	t.Run("export", func(t *testing.T) {
+		msgs := exportMessages(ctx, t, source, uint32(dse3.Option_OPTION_DATA_RELATIONS)|ds.OptionRelationExpiry)
+		require.Len(t, msgs, 2)
+
+		// the stats message announcing the expiration time precedes the relation, and survives JSON encoding.
+		buf, err := protojson.Marshal(msgs[0])
+		require.NoError(t, err)
+
+		msg := &dse3.ExportResponse{}
+		require.NoError(t, protojson.Unmarshal(buf, msg))
+
+		exported, ok := ds.ExportedRelationExpiry(msg.GetStats(), msgs[1].GetRelation())
+		require.True(t, ok)
+		require.True(t, expiresAt.Equal(exported))
+
+		// the expiration time is only announced when requested.
+		require.Len(t, exportMessages(ctx, t, source, uint32(dse3.Option_OPTION_DATA_RELATIONS)), 1)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		target := newClient("import.db")
+
+		stream, err := target.V3.Importer.Import(
+			metadata.AppendToOutgoingContext(ctx, x.RelationExpiresAtHeader, expiresAt.Format(time.RFC3339Nano)),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(&dsi3.ImportRequest{
+			OpCode: dsi3.Opcode_OPCODE_SET,
+			Msg:    &dsi3.ImportRequest_Relation{Relation: rel},
+		}))
+		require.NoError(t, stream.CloseSend())
+		require.NoError(t, receiver(stream)())
+
+		require.True(t, expiresAt.Equal(relationExpiry(t, target)))
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		target := newClient("sync.db")
+
+		require.NoError(t, target.Directory.DataSyncClient().Sync(ctx, source.Conn, datasync.WithMode(datasync.Full)))
+
+		require.True(t, expiresAt.Equal(relationExpiry(t, target)))
+	})
+}
+
+// exportMessages, returns the messages of the export stream.
+func exportMessages(ctx context.Context, t *testing.T, client *server.TestEdgeClient, opts uint32) []*dse3.ExportResponse {
+	t.Helper()
+
+	stream, err := client.V3.Exporter.Export(ctx, &dse3.ExportRequest{Options: opts})
+	require.NoError(t, err)
+
+	msgs := []*dse3.ExportResponse{}
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+
+		require.NoError(t, err)
+
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestRelationExpiryReads(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	// the reaper does not run during the test, the expired relation remains stored.
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "expiry-reads.db"),
+		RequestTimeout: 2 * time.Second,
+		ExpiryInterval: time.Hour,
+	})
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	rel := &dsc3.Relation{
+		ObjectType:  "group",
+		ObjectId:    "temporary-share",
+		Relation:    "member",
+		SubjectType: "user",
+		SubjectId:   "guest@acmecorp.com",
+	}
+
+	expiresAt := time.Now().Add(300 * time.Millisecond)
+
+	_, err = client.V3.Writer.SetRelation(
+		metadata.AppendToOutgoingContext(ctx, x.RelationExpiresAtHeader, expiresAt.Format(time.RFC3339Nano)),
+		&dsw3.SetRelationRequest{Relation: rel},
+	)
+	require.NoError(t, err)
+
+	getRelation := func() error {
+		_, err := client.V3.Reader.GetRelation(ctx, &dsr3.GetRelationRequest{
+			ObjectType:  rel.GetObjectType(),
+			ObjectId:    rel.GetObjectId(),
+			Relation:    rel.GetRelation(),
+			SubjectType: rel.GetSubjectType(),
+			SubjectId:   rel.GetSubjectId(),
+		})
+
+		return err
+	}
+
+	getRelations := func() []*dsc3.Relation {
+		resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{
+			ObjectType: rel.GetObjectType(),
+			ObjectId:   rel.GetObjectId(),
+		})
+		require.NoError(t, err)
+
+		return resp.GetResults()
+	}
+
+	check := func() bool {
+		resp, err := client.V3.Reader.Check(ctx, &dsr3.CheckRequest{
+			ObjectType:  rel.GetObjectType(),
+			ObjectId:    rel.GetObjectId(),
+			Relation:    rel.GetRelation(),
+			SubjectType: rel.GetSubjectType(),
+			SubjectId:   rel.GetSubjectId(),
+		})
+		require.NoError(t, err)
+
+		return resp.GetCheck()
+	}
+
+	require.NoError(t, getRelation())
+	require.Len(t, getRelations(), 1)
+	require.True(t, check())
+
+	time.Sleep(time.Until(expiresAt))
+
+	// the reads agree with the check once the relation expired, before it is deleted by the reaper.
+	require.Equal(t, codes.NotFound, status.Code(getRelation()))
+	require.Empty(t, getRelations())
+	require.False(t, check())
+}
@@ -0,0 +1,213 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
+	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExportStartFrom(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+
+	first, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+		Object: &dsc3.Object{Type: "user", Id: "export-user-1@acmecorp.com"},
+	})
+	require.NoError(t, err)
+
+	watermark := first.GetResult().GetUpdatedAt()
+
+	_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+		Object: &dsc3.Object{Type: "user", Id: "export-user-2@acmecorp.com"},
+	})
+	require.NoError(t, err)
+
+	_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{
+		Relation: &dsc3.Relation{
+			ObjectType:  "group",
+			ObjectId:    "export-group",
+			Relation:    "member",
+			SubjectType: "user",
+			SubjectId:   "export-user-2@acmecorp.com",
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("full-export", func(t *testing.T) {
+		objects, relations := export(ctx, t, client, nil)
+		require.Len(t, objects, 2)
+		require.Len(t, relations, 1)
+	})
+
+	t.Run("zero-start-from", func(t *testing.T) {
+		objects, relations := export(ctx, t, client, &timestamppb.Timestamp{})
+		require.Len(t, objects, 2)
+		require.Len(t, relations, 1)
+	})
+
+	t.Run("incremental-export", func(t *testing.T) {
+		objects, relations := export(ctx, t, client, watermark)
+		require.Len(t, objects, 1)
+		require.Equal(t, "export-user-2@acmecorp.com", objects[0].GetId())
+		require.Len(t, relations, 1)
+	})
+
+	t.Run("up-to-date-export", func(t *testing.T) {
+		objects, relations := export(ctx, t, client, timestamppb.Now())
+		require.Empty(t, objects)
+		require.Empty(t, relations)
+	})
+
+	t.Run("tombstones-export", func(t *testing.T) {
+		deletedAfter := timestamppb.Now()
+
+		_, err := client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{
+			ObjectType:    "user",
+			ObjectId:      "export-user-2@acmecorp.com",
+			WithRelations: true,
+		})
+		require.NoError(t, err)
+
+		objects, relations := exportWithOptions(ctx, t, client, uint32(dse3.Option_OPTION_DATA), deletedAfter)
+		require.Empty(t, objects)
+		require.Empty(t, relations)
+
+		objects, relations = exportWithOptions(ctx, t, client, uint32(dse3.Option_OPTION_DATA)|ds.OptionTombstones, deletedAfter)
+		require.Len(t, objects, 1)
+		require.Equal(t, "export-user-2@acmecorp.com", objects[0].GetId())
+		require.True(t, ds.IsTombstone(objects[0].GetEtag()))
+		require.Len(t, relations, 1)
+		require.True(t, ds.IsTombstone(relations[0].GetEtag()))
+	})
+
+	t.Run("tombstones-before-start-from", func(t *testing.T) {
+		// the deletion time index is walked from the start_from timestamp, earlier tombstones are not exported.
+		objects, relations := exportWithOptions(ctx, t, client, uint32(dse3.Option_OPTION_DATA)|ds.OptionTombstones, timestamppb.Now())
+		require.Empty(t, objects)
+		require.Empty(t, relations)
+	})
+
+	t.Run("recreate-clears-tombstone", func(t *testing.T) {
+		deletedAfter := timestamppb.Now()
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+			Object: &dsc3.Object{Type: "user", Id: "export-user-2@acmecorp.com"},
+		})
+		require.NoError(t, err)
+
+		objects, _ := exportWithOptions(ctx, t, client, uint32(dse3.Option_OPTION_DATA_OBJECTS)|ds.OptionTombstones, deletedAfter)
+		require.Len(t, objects, 1)
+		require.False(t, ds.IsTombstone(objects[0].GetEtag()))
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		importedAfter := timestamppb.Now()
+
+		// the export is read in batches of x.MaxPageSize instances, each in its own read transaction.
+		const count = 2*int(x.MaxPageSize) + 50
+
+		g, iCtx := errgroup.WithContext(ctx)
+
+		stream, err := client.V3.Importer.Import(iCtx)
+		require.NoError(t, err)
+
+		g.Go(receiver(stream))
+
+		for i := range count {
+			require.NoError(t, stream.Send(&dsi3.ImportRequest{
+				OpCode: dsi3.Opcode_OPCODE_SET,
+				Msg:    &dsi3.ImportRequest_Object{Object: &dsc3.Object{Type: "user", Id: fmt.Sprintf("export-batch-%04d", i)}},
+			}))
+		}
+
+		require.NoError(t, stream.CloseSend())
+		require.NoError(t, g.Wait())
+
+		batched := func(objects []*dsc3.Object) []string {
+			ids := []string{}
+
+			for _, obj := range objects {
+				if strings.HasPrefix(obj.GetId(), "export-batch-") {
+					ids = append(ids, obj.GetId())
+				}
+			}
+
+			return ids
+		}
+
+		objects, _ := export(ctx, t, client, nil)
+		ids := batched(objects)
+		require.Len(t, ids, count)
+		require.True(t, slices.IsSorted(ids))
+
+		objects, _ = export(ctx, t, client, importedAfter)
+		require.Len(t, batched(objects), count)
+	})
+}
+
+func export(ctx context.Context, t *testing.T, client *server.TestEdgeClient, startFrom *timestamppb.Timestamp) ([]*dsc3.Object, []*dsc3.Relation) {
+	t.Helper()
+
+	return exportWithOptions(ctx, t, client, uint32(dse3.Option_OPTION_DATA), startFrom)
+}
+
+func exportWithOptions(
+	ctx context.Context,
+	t *testing.T,
+	client *server.TestEdgeClient,
+	opts uint32,
+	startFrom *timestamppb.Timestamp,
+) ([]*dsc3.Object, []*dsc3.Relation) {
+	t.Helper()
+
+	stream, err := client.V3.Exporter.Export(ctx, &dse3.ExportRequest{
+		Options:   opts,
+		StartFrom: startFrom,
+	})
+	require.NoError(t, err)
+
+	objects := []*dsc3.Object{}
+	relations := []*dsc3.Relation{}
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		switch m := msg.GetMsg().(type) {
+		case *dse3.ExportResponse_Object:
+			objects = append(objects, m.Object)
+		case *dse3.ExportResponse_Relation:
+			relations = append(relations, m.Relation)
+		}
+	}
+
+	return objects, relations
+}
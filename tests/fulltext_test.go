@@ -0,0 +1,168 @@
+package tests_test
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFullTextSearch(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+	dbPath := path.Join(t.TempDir(), "search.db")
+
+	open := func(props map[string][]string) *server.TestEdgeClient {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+			DBPath:           dbPath,
+			RequestTimeout:   2 * time.Second,
+			SearchProperties: props,
+		})
+		t.Cleanup(cleanup)
+
+		return client
+	}
+
+	setObject := func(t *testing.T, client *server.TestEdgeClient, objType, id, displayName string, props map[string]any) {
+		t.Helper()
+
+		p, err := structpb.NewStruct(props)
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{
+			Type: objType, Id: id, DisplayName: displayName, Properties: p,
+		}})
+		require.NoError(t, err)
+	}
+
+	search := func(t *testing.T, client *server.TestEdgeClient, objType string, size int32, md ...string) ([]string, error) {
+		t.Helper()
+
+		ids := []string{}
+		req := &dsr3.GetObjectsRequest{ObjectType: objType, Page: &dsc3.PaginationRequest{Size: size}}
+
+		for {
+			resp, err := client.V3.Reader.GetObjects(metadata.AppendToOutgoingContext(ctx, md...), req)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, obj := range resp.GetResults() {
+				ids = append(ids, obj.GetType()+":"+obj.GetId())
+			}
+
+			if resp.GetPage().GetNextToken() == "" {
+				return ids, nil
+			}
+
+			req.Page.Token = resp.GetPage().GetNextToken()
+		}
+	}
+
+	client := open(nil)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	setObject(t, client, "user", "alice", "Alice Albright", map[string]any{"email": "alice@acme.com"})
+	setObject(t, client, "user", "bob", "Bob Allen", map[string]any{"email": "bob@acme.com", "title": "Sales Lead"})
+	setObject(t, client, "user", "carol", "Carol", map[string]any{"email": "carol@example.com"})
+	setObject(t, client, "group", "all", "All Staff", nil)
+
+	t.Run("prefix", func(t *testing.T) {
+		// alice is indexed by two terms starting with "al" (alice, albright), and returned once.
+		ids, err := search(t, client, "user", 1, x.SearchHeader, "al")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"user:alice", "user:bob"}, ids)
+
+		ids, err = search(t, client, "", 10, x.SearchHeader, "AL")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"user:alice", "user:bob", "group:all"}, ids)
+	})
+
+	t.Run("all-terms", func(t *testing.T) {
+		ids, err := search(t, client, "user", 10, x.SearchHeader, "bob al")
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:bob"}, ids)
+	})
+
+	t.Run("term", func(t *testing.T) {
+		ids, err := search(t, client, "", 10, x.SearchHeader, "al", x.SearchModeHeader, "term")
+		require.NoError(t, err)
+		require.Empty(t, ids)
+
+		ids, err = search(t, client, "", 10, x.SearchHeader, "allen", x.SearchModeHeader, "term")
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:bob"}, ids)
+	})
+
+	t.Run("maintained-by-writes", func(t *testing.T) {
+		setObject(t, client, "user", "carol", "Carol Alvarez", nil)
+
+		ids, err := search(t, client, "user", 10, x.SearchHeader, "alvarez")
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:carol"}, ids)
+
+		_, err = client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{ObjectType: "user", ObjectId: "carol"})
+		require.NoError(t, err)
+
+		ids, err = search(t, client, "user", 10, x.SearchHeader, "carol")
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("search-properties", func(t *testing.T) {
+		ids, err := search(t, client, "user", 10, x.SearchHeader, "acme")
+		require.NoError(t, err)
+		require.Empty(t, ids)
+
+		client.Directory.Close()
+		client = open(map[string][]string{"user": {"email", "title"}})
+
+		ids, err = search(t, client, "user", 10, x.SearchHeader, "acme")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"user:alice", "user:bob"}, ids)
+
+		ids, err = search(t, client, "user", 10, x.SearchHeader, "sales")
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:bob"}, ids)
+
+		client.Directory.Close()
+		client = open(map[string][]string{"user": {"title"}})
+
+		ids, err = search(t, client, "user", 10, x.SearchHeader, "acme")
+		require.NoError(t, err)
+		require.Empty(t, ids)
+
+		ids, err = search(t, client, "user", 10, x.SearchHeader, "lead")
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:bob"}, ids)
+	})
+
+	t.Run("invalid-search", func(t *testing.T) {
+		for _, md := range [][]string{
+			{x.SearchHeader, "---"},
+			{x.SearchHeader, "al", x.SearchModeHeader, "fuzzy"},
+			{x.SearchHeader, "al", x.SortByHeader, "created_at"},
+		} {
+			_, err := search(t, client, "user", 10, md...)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), md)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package tests_test
+
+import (
+	"os"
+	"testing"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestKeyEncoding, object ids which are a prefix of another id, or which contain separator characters,
+// must not match the keys of other objects.
+func TestKeyEncoding(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+	t.Cleanup(func() { require.NoError(t, deleteManifest(client)) })
+
+	folders := []string{"key-folder", "key-folder|owner|user:key-user", "key-folder:2", "key-folder2"}
+
+	for _, id := range folders {
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "folder", Id: id}})
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+			ObjectType: "folder", ObjectId: id, Relation: "owner", SubjectType: "user", SubjectId: "key-user",
+		}})
+		require.NoError(t, err)
+	}
+
+	t.Run("get-object-with-relations", func(t *testing.T) {
+		for _, id := range folders {
+			resp, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "folder", ObjectId: id, WithRelations: true})
+			require.NoError(t, err)
+			require.Len(t, resp.GetRelations(), 1)
+			require.Equal(t, id, resp.GetRelations()[0].GetObjectId())
+		}
+	})
+
+	t.Run("get-relations-by-object", func(t *testing.T) {
+		for _, id := range folders {
+			resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{ObjectType: "folder", ObjectId: id})
+			require.NoError(t, err)
+			require.Len(t, resp.GetResults(), 1)
+			require.Equal(t, id, resp.GetResults()[0].GetObjectId())
+		}
+	})
+
+	t.Run("get-relation", func(t *testing.T) {
+		resp, err := client.V3.Reader.GetRelation(ctx, &dsr3.GetRelationRequest{
+			ObjectType: "folder", ObjectId: "key-folder", Relation: "owner", SubjectType: "user", SubjectId: "key-user",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "key-folder", resp.GetResult().GetObjectId())
+	})
+
+	t.Run("get-objects-paged", func(t *testing.T) {
+		ids := []string{}
+		req := &dsr3.GetObjectsRequest{ObjectType: "folder", Page: &dsc3.PaginationRequest{Size: 1}}
+
+		for {
+			resp, err := client.V3.Reader.GetObjects(ctx, req)
+			require.NoError(t, err)
+
+			for _, obj := range resp.GetResults() {
+				require.Equal(t, "folder", obj.GetType())
+				ids = append(ids, obj.GetId())
+			}
+
+			if resp.GetPage().GetNextToken() == "" {
+				break
+			}
+
+			req.Page.Token = resp.GetPage().GetNextToken()
+		}
+
+		require.ElementsMatch(t, folders, ids)
+	})
+
+	t.Run("delete-object-with-relations", func(t *testing.T) {
+		_, err := client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{ObjectType: "folder", ObjectId: "key-folder", WithRelations: true})
+		require.NoError(t, err)
+
+		resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{SubjectType: "user", SubjectId: "key-user"})
+		require.NoError(t, err)
+		require.Len(t, resp.GetResults(), len(folders)-1)
+	})
+
+	t.Run("type-without-relations", func(t *testing.T) {
+		resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{ObjectType: "group", Relation: "member"})
+		require.NoError(t, err)
+		require.Empty(t, resp.GetResults())
+	})
+
+	// ids are ordered by length first, then lexically, the unordered listing and its pages follow the key order.
+	t.Run("key-order", func(t *testing.T) {
+		groups := []string{"key-group-b", "key-group-aa", "key-group-a"}
+
+		for _, id := range groups {
+			_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "group", Id: id}})
+			require.NoError(t, err)
+
+			_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+				ObjectType: "group", ObjectId: id, Relation: "member", SubjectType: "user", SubjectId: "key-user",
+			}})
+			require.NoError(t, err)
+		}
+
+		expected := []string{"key-group-a", "key-group-b", "key-group-aa"}
+
+		objects := []string{}
+		objReq := &dsr3.GetObjectsRequest{ObjectType: "group", Page: &dsc3.PaginationRequest{Size: 1}}
+
+		for {
+			resp, err := client.V3.Reader.GetObjects(ctx, objReq)
+			require.NoError(t, err)
+
+			for _, obj := range resp.GetResults() {
+				objects = append(objects, obj.GetId())
+			}
+
+			if resp.GetPage().GetNextToken() == "" {
+				break
+			}
+
+			objReq.Page.Token = resp.GetPage().GetNextToken()
+		}
+
+		require.Equal(t, expected, objects)
+
+		relations := []string{}
+		relReq := &dsr3.GetRelationsRequest{ObjectType: "group", Relation: "member", Page: &dsc3.PaginationRequest{Size: 1}}
+
+		for {
+			resp, err := client.V3.Reader.GetRelations(ctx, relReq)
+			require.NoError(t, err)
+
+			for _, rel := range resp.GetResults() {
+				relations = append(relations, rel.GetObjectId())
+			}
+
+			if resp.GetPage().GetNextToken() == "" {
+				break
+			}
+
+			relReq.Page.Token = resp.GetPage().GetNextToken()
+		}
+
+		require.Equal(t, expected, relations)
+	})
+}
@@ -0,0 +1,157 @@
+package tests_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestListOrder(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+	t.Cleanup(func() { require.NoError(t, deleteManifest(client)) })
+
+	var (
+		ids      []string
+		midpoint time.Time
+	)
+
+	for i := range 5 {
+		if i == 3 {
+			midpoint = time.Now()
+		}
+
+		id := fmt.Sprintf("list-user-%d", 4-i)
+		ids = append(ids, id)
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id}})
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+			ObjectType: "group", ObjectId: "list-group", Relation: "member", SubjectType: "user", SubjectId: id,
+		}})
+		require.NoError(t, err)
+	}
+
+	// updating the first created user moves it to the end of the updated_at order.
+	_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: ids[0], DisplayName: "updated"}})
+	require.NoError(t, err)
+
+	getObjects := func(t *testing.T, size int32, md ...string) []string {
+		t.Helper()
+
+		result := []string{}
+		req := &dsr3.GetObjectsRequest{ObjectType: "user", Page: &dsc3.PaginationRequest{Size: size}}
+
+		for {
+			resp, err := client.V3.Reader.GetObjects(metadata.AppendToOutgoingContext(ctx, md...), req)
+			require.NoError(t, err)
+
+			for _, obj := range resp.GetResults() {
+				result = append(result, obj.GetId())
+			}
+
+			if resp.GetPage().GetNextToken() == "" {
+				return result
+			}
+
+			req.Page.Token = resp.GetPage().GetNextToken()
+		}
+	}
+
+	reversed := func(s []string) []string {
+		r := make([]string, 0, len(s))
+		for i := len(s) - 1; i >= 0; i-- {
+			r = append(r, s[i])
+		}
+
+		return r
+	}
+
+	t.Run("key-order", func(t *testing.T) {
+		require.Equal(t, reversed(ids), getObjects(t, 100))
+	})
+
+	t.Run("created-at", func(t *testing.T) {
+		require.Equal(t, ids, getObjects(t, 100, x.SortByHeader, "created_at"))
+		require.Equal(t, ids, getObjects(t, 2, x.SortByHeader, "created_at"))
+	})
+
+	t.Run("created-at-descending", func(t *testing.T) {
+		require.Equal(t, reversed(ids), getObjects(t, 100, x.SortByHeader, "-created_at"))
+		require.Equal(t, reversed(ids), getObjects(t, 2, x.SortByHeader, "-created_at"))
+	})
+
+	t.Run("updated-at", func(t *testing.T) {
+		require.Equal(t, append(ids[1:], ids[0]), getObjects(t, 2, x.SortByHeader, "updated_at"))
+		require.Equal(t, ids[0], getObjects(t, 1, x.SortByHeader, "-updated_at")[0])
+	})
+
+	t.Run("created-after", func(t *testing.T) {
+		after := midpoint.Format(time.RFC3339Nano)
+
+		require.Equal(t, ids[3:], getObjects(t, 1, x.CreatedAfterHeader, after))
+		require.Equal(t, reversed(ids[3:]), getObjects(t, 1, x.CreatedAfterHeader, after, x.SortByHeader, "-created_at"))
+		require.Equal(t, ids[:3], getObjects(t, 100, x.CreatedBeforeHeader, after))
+	})
+
+	t.Run("updated-window-sorted-by-created-at", func(t *testing.T) {
+		require.Equal(t, []string{ids[0], ids[3], ids[4]}, getObjects(t, 100,
+			x.SortByHeader, "created_at",
+			x.UpdatedAfterHeader, midpoint.Format(time.RFC3339Nano),
+		))
+	})
+
+	t.Run("relations-created-after", func(t *testing.T) {
+		resp, err := client.V3.Reader.GetRelations(
+			metadata.AppendToOutgoingContext(ctx, x.CreatedAfterHeader, midpoint.Format(time.RFC3339Nano)),
+			&dsr3.GetRelationsRequest{ObjectType: "group", Relation: "member", WithObjects: true},
+		)
+		require.NoError(t, err)
+		require.Len(t, resp.GetResults(), 2)
+		require.Equal(t, ids[3], resp.GetResults()[0].GetSubjectId())
+		require.Equal(t, ids[4], resp.GetResults()[1].GetSubjectId())
+		require.Contains(t, resp.GetObjects(), "user:"+ids[3])
+	})
+
+	t.Run("token-bound-to-order", func(t *testing.T) {
+		req := &dsr3.GetObjectsRequest{ObjectType: "user", Page: &dsc3.PaginationRequest{Size: 2}}
+
+		resp, err := client.V3.Reader.GetObjects(metadata.AppendToOutgoingContext(ctx, x.SortByHeader, "created_at"), req)
+		require.NoError(t, err)
+
+		req.Page.Token = resp.GetPage().GetNextToken()
+
+		_, err = client.V3.Reader.GetObjects(metadata.AppendToOutgoingContext(ctx, x.SortByHeader, "-created_at"), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("invalid-options", func(t *testing.T) {
+		for _, md := range [][]string{
+			{x.SortByHeader, "name"},
+			{x.CreatedAfterHeader, "yesterday"},
+			{x.CreatedAfterHeader, "2025-01-02T00:00:00Z", x.CreatedBeforeHeader, "2025-01-01T00:00:00Z"},
+		} {
+			_, err := client.V3.Reader.GetObjects(metadata.AppendToOutgoingContext(ctx, md...), &dsr3.GetObjectsRequest{})
+			require.Equal(t, codes.InvalidArgument, status.Code(err), md)
+		}
+	})
+}
@@ -103,7 +103,11 @@ func testSetManifest(client *server.TestEdgeClient, manifest []byte) func(*testi
 }
 
 func setManifest(client *server.TestEdgeClient, manifest []byte) error {
-	stream, err := client.V3.Model.SetManifest(context.Background())
+	return setManifestContext(context.Background(), client, manifest)
+}
+
+func setManifestContext(ctx context.Context, client *server.TestEdgeClient, manifest []byte) error {
+	stream, err := client.V3.Model.SetManifest(ctx)
 	if err != nil {
 		return err
 	}
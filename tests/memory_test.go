@@ -0,0 +1,79 @@
+package tests_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySnapshotIsolation(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+
+	store, err := bdb.New(&bdb.Config{Backend: bdb.MemoryBackend}, &logger)
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	path := bdb.Path{"isolation"}
+	key := func(i int) []byte { return fmt.Appendf(nil, "key-%04d", i) }
+
+	const keys = 1000
+
+	require.NoError(t, store.DB().Update(func(tx bdb.Tx) error {
+		if _, err := bdb.CreateBucket(tx, path); err != nil {
+			return err
+		}
+
+		for i := range keys {
+			if err := bdb.SetKey(tx, path, key(i), []byte("v1")); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	// a read transaction observes the snapshot committed at its start, while a write transaction commits.
+	require.NoError(t, store.DB().View(func(rtx bdb.Tx) error {
+		require.NoError(t, store.DB().Update(func(tx bdb.Tx) error {
+			if err := bdb.SetKey(tx, path, key(0), []byte("v2")); err != nil {
+				return err
+			}
+
+			return bdb.DeleteKey(tx, path, key(1))
+		}))
+
+		v, err := bdb.GetKey(rtx, path, key(0))
+		require.NoError(t, err)
+		require.Equal(t, []byte("v1"), v)
+
+		_, err = bdb.GetKey(rtx, path, key(1))
+		require.NoError(t, err)
+
+		return nil
+	}))
+
+	// deleting underneath a cursor neither skips nor repeats entries.
+	require.NoError(t, store.DB().Update(func(tx bdb.Tx) error {
+		c := tx.Bucket([]byte(path[0])).Cursor()
+		visited := 0
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+
+			visited++
+		}
+
+		require.Equal(t, keys-1, visited)
+		require.Equal(t, 0, tx.Bucket([]byte(path[0])).KeyN())
+
+		return nil
+	}))
+}
@@ -0,0 +1,156 @@
+package tests_test
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestPropertyIndex(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+	dbPath := path.Join(t.TempDir(), "property-index.db")
+
+	open := func(indexes map[string][]string) *server.TestEdgeClient {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+			DBPath:          dbPath,
+			RequestTimeout:  2 * time.Second,
+			PropertyIndexes: indexes,
+		})
+		t.Cleanup(cleanup)
+
+		return client
+	}
+
+	setUser := func(t *testing.T, client *server.TestEdgeClient, id string, props map[string]any) {
+		t.Helper()
+
+		p, err := structpb.NewStruct(props)
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id, Properties: p}})
+		require.NoError(t, err)
+	}
+
+	findUsers := func(t *testing.T, client *server.TestEdgeClient, filter string, size int32) ([]string, error) {
+		t.Helper()
+
+		ids := []string{}
+		req := &dsr3.GetObjectsRequest{ObjectType: "user", Page: &dsc3.PaginationRequest{Size: size}}
+
+		for {
+			resp, err := client.V3.Reader.GetObjects(metadata.AppendToOutgoingContext(ctx, x.PropertyHeader, filter), req)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, obj := range resp.GetResults() {
+				ids = append(ids, obj.GetId())
+			}
+
+			if resp.GetPage().GetNextToken() == "" {
+				return ids, nil
+			}
+
+			req.Page.Token = resp.GetPage().GetNextToken()
+		}
+	}
+
+	// objects written before the index is declared are indexed when the directory is opened with the index.
+	client := open(nil)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(client, manifest))
+
+	setUser(t, client, "alice", map[string]any{"email": "alice@acme.com", "dept": "sales"})
+	setUser(t, client, "bob", map[string]any{"email": []any{"bob@acme.com", "robert@acme.com"}, "dept": "sales"})
+	setUser(t, client, "carol", map[string]any{"dept": "eng"})
+
+	_, err = findUsers(t, client, "email=alice@acme.com", 10)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	client.Directory.Close()
+
+	client = open(map[string][]string{"user": {"email", "dept"}})
+
+	t.Run("lookup", func(t *testing.T) {
+		ids, err := findUsers(t, client, "email=alice@acme.com", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"alice"}, ids)
+
+		ids, err = findUsers(t, client, "email=robert@acme.com", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"bob"}, ids)
+
+		ids, err = findUsers(t, client, "email=nobody@acme.com", 10)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("paged", func(t *testing.T) {
+		ids, err := findUsers(t, client, "dept=sales", 1)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"alice", "bob"}, ids)
+	})
+
+	t.Run("maintained-by-writes", func(t *testing.T) {
+		setUser(t, client, "alice", map[string]any{"email": "alice@example.com", "dept": "sales"})
+
+		ids, err := findUsers(t, client, "email=alice@acme.com", 10)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+
+		ids, err = findUsers(t, client, "email=alice@example.com", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"alice"}, ids)
+
+		_, err = client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{ObjectType: "user", ObjectId: "alice"})
+		require.NoError(t, err)
+
+		ids, err = findUsers(t, client, "dept=sales", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"bob"}, ids)
+	})
+
+	t.Run("invalid-filter", func(t *testing.T) {
+		for _, filter := range []string{"email", "=bob@acme.com", "name=bob"} {
+			_, err := findUsers(t, client, filter, 10)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), filter)
+		}
+
+		_, err := client.V3.Reader.GetObjects(
+			metadata.AppendToOutgoingContext(ctx, x.PropertyHeader, "email=bob@acme.com"),
+			&dsr3.GetObjectsRequest{},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("dropped-index", func(t *testing.T) {
+		client.Directory.Close()
+		client = open(map[string][]string{"user": {"dept"}})
+
+		_, err := findUsers(t, client, "email=bob@acme.com", 10)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		ids, err := findUsers(t, client, "dept=eng", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"carol"}, ids)
+	})
+}
@@ -0,0 +1,153 @@
+package tests_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReferentialIntegrity(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+
+	newClient := func(t *testing.T, mode ds.ReferentialIntegrity) *server.TestEdgeClient {
+		t.Helper()
+
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+			DBPath:               path.Join(t.TempDir(), "referential.db"),
+			RequestTimeout:       2 * time.Second,
+			ReferentialIntegrity: mode,
+		})
+		t.Cleanup(cleanup)
+
+		require.NoError(t, setManifest(client, manifest))
+
+		return client
+	}
+
+	setObject := func(t *testing.T, client *server.TestEdgeClient, objType, objID string) {
+		t.Helper()
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: objType, Id: objID}})
+		require.NoError(t, err)
+	}
+
+	setRelation := func(client *server.TestEdgeClient, rel *dsc3.Relation) error {
+		_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+		return err
+	}
+
+	// importRelation, imports the relation, returns the status code of the import.
+	importRelation := func(t *testing.T, client *server.TestEdgeClient, rel *dsc3.Relation) codes.Code {
+		t.Helper()
+
+		stream, err := client.V3.Importer.Import(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(&dsi3.ImportRequest{
+			OpCode: dsi3.Opcode_OPCODE_SET,
+			Msg:    &dsi3.ImportRequest_Relation{Relation: rel},
+		}))
+		require.NoError(t, stream.CloseSend())
+
+		code := codes.OK
+
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return code
+			}
+
+			require.NoError(t, err)
+
+			if st := resp.GetStatus(); st != nil {
+				code = codes.Code(st.GetCode())
+			}
+		}
+	}
+
+	manager := func(objID, subID string) *dsc3.Relation {
+		return &dsc3.Relation{ObjectType: "user", ObjectId: objID, Relation: "manager", SubjectType: "user", SubjectId: subID}
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		client := newClient(t, ds.ReferentialIntegrityReject)
+
+		err := setRelation(client, manager("ref-alice", "ref-bob"))
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		setObject(t, client, "user", "ref-alice")
+
+		// the subject does not exist.
+		err = setRelation(client, manager("ref-alice", "ref-bob"))
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		setObject(t, client, "user", "ref-bob")
+		require.NoError(t, setRelation(client, manager("ref-alice", "ref-bob")))
+
+		// the wildcard subject does not reference an object.
+		setObject(t, client, "document", "ref-doc")
+		require.NoError(t, setRelation(client, &dsc3.Relation{
+			ObjectType: "document", ObjectId: "ref-doc", Relation: "reader", SubjectType: "user", SubjectId: "*",
+		}))
+
+		require.Equal(t, codes.FailedPrecondition, importRelation(t, client, manager("ref-alice", "ref-carol")))
+
+		resp, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{ObjectType: "user", ObjectId: "ref-alice"})
+		require.NoError(t, err)
+		require.Len(t, resp.GetResults(), 1)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		client := newClient(t, ds.ReferentialIntegrityCreate)
+
+		require.NoError(t, setRelation(client, manager("ref-alice", "ref-bob")))
+		require.Equal(t, codes.OK, importRelation(t, client, manager("ref-alice", "ref-carol")))
+
+		for _, id := range []string{"ref-alice", "ref-bob", "ref-carol"} {
+			resp, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "user", ObjectId: id})
+			require.NoError(t, err)
+			require.Empty(t, resp.GetResult().GetDisplayName())
+			require.NotEmpty(t, resp.GetResult().GetEtag())
+		}
+
+		// existing objects are left unchanged.
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{
+			Type: "user", Id: "ref-dave", DisplayName: "Dave",
+		}})
+		require.NoError(t, err)
+		require.NoError(t, setRelation(client, manager("ref-alice", "ref-dave")))
+
+		resp, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "user", ObjectId: "ref-dave"})
+		require.NoError(t, err)
+		require.Equal(t, "Dave", resp.GetResult().GetDisplayName())
+	})
+
+	t.Run("invalid-mode", func(t *testing.T) {
+		_, err := directory.Open(ctx, &directory.Config{
+			DBPath:               path.Join(t.TempDir(), "referential.db"),
+			RequestTimeout:       2 * time.Second,
+			ReferentialIntegrity: "cascade",
+		}, &logger)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,103 @@
+package tests_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelationNameIndex(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+
+	const docs = 5
+
+	for i := range docs {
+		id := "index-doc-" + strconv.Itoa(i)
+
+		for _, rel := range []*dsc3.Relation{
+			{ObjectType: "document", ObjectId: id, Relation: "reader", SubjectType: "user", SubjectId: "index-reader@acmecorp.com"},
+			{ObjectType: "document", ObjectId: id, Relation: "writer", SubjectType: "user", SubjectId: "index-writer@acmecorp.com"},
+			{ObjectType: "document", ObjectId: id, Relation: "reader", SubjectType: "user", SubjectId: "*"},
+		} {
+			_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+		ObjectType: "folder", ObjectId: "index-folder", Relation: "owner", SubjectType: "user", SubjectId: "index-reader@acmecorp.com",
+	}})
+	require.NoError(t, err)
+
+	getRelations := func(req *dsr3.GetRelationsRequest) []*dsc3.Relation {
+		results := []*dsc3.Relation{}
+		req.Page = &dsc3.PaginationRequest{Size: 2}
+
+		for {
+			resp, err := client.V3.Reader.GetRelations(ctx, req)
+			require.NoError(t, err)
+
+			results = append(results, resp.GetResults()...)
+
+			if resp.GetPage().GetNextToken() == "" {
+				return results
+			}
+
+			req.Page.Token = resp.GetPage().GetNextToken()
+		}
+	}
+
+	t.Run("type-and-relation", func(t *testing.T) {
+		results := getRelations(&dsr3.GetRelationsRequest{ObjectType: "document", Relation: "reader"})
+		require.Len(t, results, 2*docs)
+
+		for _, rel := range results {
+			require.Equal(t, "document", rel.GetObjectType())
+			require.Equal(t, "reader", rel.GetRelation())
+		}
+	})
+
+	t.Run("type-relation-and-subject-type", func(t *testing.T) {
+		results := getRelations(&dsr3.GetRelationsRequest{ObjectType: "document", Relation: "writer", SubjectType: "user"})
+		require.Len(t, results, docs)
+	})
+
+	t.Run("deletes-maintain-index", func(t *testing.T) {
+		_, err := client.V3.Writer.DeleteRelation(ctx, &dsw3.DeleteRelationRequest{
+			ObjectType: "document", ObjectId: "index-doc-0", Relation: "writer", SubjectType: "user", SubjectId: "index-writer@acmecorp.com",
+		})
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{
+			ObjectType: "document", ObjectId: "index-doc-1", WithRelations: true,
+		})
+		require.NoError(t, err)
+
+		require.Len(t, getRelations(&dsr3.GetRelationsRequest{ObjectType: "document", Relation: "writer"}), docs-2)
+	})
+
+	t.Run("graph-search", func(t *testing.T) {
+		resp, err := client.V3.Reader.GetGraph(ctx, &dsr3.GetGraphRequest{
+			ObjectType:  "document",
+			Relation:    "edit",
+			SubjectType: "user",
+			SubjectId:   "index-writer@acmecorp.com",
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.GetResults(), docs-2)
+	})
+}
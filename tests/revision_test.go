@@ -0,0 +1,110 @@
+package tests_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRevisionConsistency(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	var origin string
+
+	setObject := func(id string) uint64 {
+		var header metadata.MD
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+			Object: &dsc3.Object{Type: "user", Id: id},
+		}, grpc.Header(&header))
+		require.NoError(t, err)
+
+		values := header.Get(x.RevisionHeader)
+		require.Len(t, values, 1)
+
+		origins := header.Get(x.RevisionOriginHeader)
+		require.Len(t, origins, 1)
+		require.NotEmpty(t, origins[0])
+
+		origin = origins[0]
+
+		rev, err := strconv.ParseUint(values[0], 10, 64)
+		require.NoError(t, err)
+
+		return rev
+	}
+
+	first := setObject("revision-user-1@acmecorp.com")
+	second := setObject("revision-user-2@acmecorp.com")
+	require.Greater(t, second, first)
+
+	getObject := func(md ...string) error {
+		_, err := client.V3.Reader.GetObject(
+			metadata.AppendToOutgoingContext(ctx, md...),
+			&dsr3.GetObjectRequest{ObjectType: "user", ObjectId: "revision-user-2@acmecorp.com"},
+		)
+
+		return err
+	}
+
+	t.Run("read-your-write", func(t *testing.T) {
+		require.NoError(t, getObject(x.AtLeastRevisionHeader, strconv.FormatUint(second, 10)))
+	})
+
+	t.Run("fail-fast", func(t *testing.T) {
+		err := getObject(x.AtLeastRevisionHeader, strconv.FormatUint(second+100, 10))
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("wait-timeout", func(t *testing.T) {
+		err := getObject(
+			x.AtLeastRevisionHeader, strconv.FormatUint(second+100, 10),
+			x.RevisionTimeoutHeader, "50ms",
+		)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("wait-for-revision", func(t *testing.T) {
+		done := make(chan error, 1)
+
+		go func() {
+			done <- getObject(
+				x.AtLeastRevisionHeader, strconv.FormatUint(second+1, 10),
+				x.RevisionTimeoutHeader, "5s",
+			)
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		setObject("revision-user-3@acmecorp.com")
+
+		require.NoError(t, <-done)
+	})
+
+	t.Run("local-origin", func(t *testing.T) {
+		require.NoError(t, getObject(x.AtLeastRevisionHeader, strconv.FormatUint(second, 10), x.RevisionOriginHeader, origin))
+	})
+
+	t.Run("unknown-origin", func(t *testing.T) {
+		err := getObject(x.AtLeastRevisionHeader, "1", x.RevisionOriginHeader, "other-directory")
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("invalid-token", func(t *testing.T) {
+		err := getObject(x.AtLeastRevisionHeader, "not-a-revision")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
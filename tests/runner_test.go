@@ -2,6 +2,7 @@ package tests_test
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,7 @@ import (
 	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
 	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
 	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
 	"github.com/aserto-dev/go-edge-ds/pkg/directory"
 	"github.com/aserto-dev/go-edge-ds/pkg/fs"
 	"github.com/aserto-dev/go-edge-ds/pkg/server"
@@ -35,7 +37,12 @@ var (
 	closer func()
 )
 
+// testBackend, store backend of the test run (go test ./tests -backend=memory), defaults to the bolt backend.
+var testBackend = flag.String("backend", bdb.BoltBackend, "store backend of the test run, bolt or memory")
+
 func TestMain(m *testing.M) {
+	flag.Parse()
+
 	zerolog.SetGlobalLevel(zerolog.WarnLevel)
 
 	logger := zerolog.New(io.Discard)
@@ -52,6 +59,7 @@ func TestMain(m *testing.M) {
 	fmt.Println(dbPath)
 
 	cfg := directory.Config{
+		Backend:        *testBackend,
 		DBPath:         dbPath,
 		RequestTimeout: time.Second * 2,
 		Seed:           true,
@@ -0,0 +1,146 @@
+package tests_test
+
+import (
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsi3 "github.com/aserto-dev/go-directory/aserto/directory/importer/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+// BenchmarkScanCheck, checks against a bolt directory where the relation scans reject keys before unmarshalling.
+//
+// direct: direct check of a subject, which has thousands of sibling subject ids (user-1, user-10, ...).
+// userset: check of a subject which is a member through a userset, next to thousands of direct subjects of the same type.
+func BenchmarkScanCheck(b *testing.B) {
+	client := scanClient(b)
+
+	checks := []struct {
+		name      string
+		subjectID string
+	}{
+		{name: "direct", subjectID: "user-1"},
+		{name: "userset", subjectID: "team-user-1"},
+	}
+
+	for _, c := range checks {
+		b.Run(c.name, func(b *testing.B) {
+			req := &dsr3.CheckRequest{
+				ObjectType: "group", ObjectId: "all", Relation: "member", SubjectType: "user", SubjectId: c.subjectID,
+			}
+
+			for b.Loop() {
+				resp, err := client.V3.Reader.Check(b.Context(), req)
+				require.NoError(b, err)
+				require.True(b, resp.GetCheck())
+			}
+		})
+	}
+}
+
+// BenchmarkScanGraph, graph searches against a bolt directory where the relation scans reject keys before unmarshalling.
+//
+// objects: search of the objects a subject is a member of, the subject has thousands of sibling subject ids.
+// subjects: search of the userset subjects of an object, which also has thousands of direct subjects of the same type.
+func BenchmarkScanGraph(b *testing.B) {
+	client := scanClient(b)
+
+	graphs := []struct {
+		name  string
+		req   *dsr3.GetGraphRequest
+		count int
+	}{
+		{
+			name: "objects",
+			req: &dsr3.GetGraphRequest{
+				ObjectType: "group", Relation: "member", SubjectType: "user", SubjectId: "team-user-1",
+			},
+			count: 2,
+		},
+		{
+			name: "subjects",
+			req: &dsr3.GetGraphRequest{
+				ObjectType: "group", ObjectId: "all", Relation: "member", SubjectType: "group", SubjectRelation: "member",
+			},
+			count: scanUsersets,
+		},
+	}
+
+	for _, g := range graphs {
+		b.Run(g.name, func(b *testing.B) {
+			for b.Loop() {
+				resp, err := client.V3.Reader.GetGraph(b.Context(), g.req)
+				require.NoError(b, err)
+				require.Len(b, resp.GetResults(), g.count)
+			}
+		})
+	}
+}
+
+const (
+	scanUsers    int = 5000
+	scanUsersets int = 20
+)
+
+// scanClient, returns a client of a bolt directory where group:all has scanUsers direct user members,
+// and scanUsersets group usersets, each with a single member.
+func scanClient(b *testing.B) *server.TestEdgeClient {
+	b.Helper()
+
+	logger := zerolog.New(io.Discard)
+
+	client, cleanup := server.NewTestEdgeServer(b.Context(), &logger, &directory.Config{
+		Backend:        bdb.BoltBackend,
+		DBPath:         path.Join(b.TempDir(), "scan.db"),
+		RequestTimeout: time.Minute,
+	})
+	b.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./data/check/manifest.yaml")
+	require.NoError(b, err)
+	require.NoError(b, setManifest(client, manifest))
+
+	g, iCtx := errgroup.WithContext(b.Context())
+
+	stream, err := client.V3.Importer.Import(iCtx)
+	require.NoError(b, err)
+
+	g.Go(receiver(stream))
+
+	send := func(rel *dsc3.Relation) {
+		require.NoError(b, stream.Send(&dsi3.ImportRequest{
+			OpCode: dsi3.Opcode_OPCODE_SET,
+			Msg:    &dsi3.ImportRequest_Relation{Relation: rel},
+		}))
+	}
+
+	for i := range scanUsers {
+		send(&dsc3.Relation{ObjectType: "group", ObjectId: "all", Relation: "member", SubjectType: "user", SubjectId: "user-" + strconv.Itoa(i)})
+	}
+
+	for i := range scanUsersets {
+		team := "team-" + strconv.Itoa(i)
+
+		send(&dsc3.Relation{
+			ObjectType: "group", ObjectId: "all", Relation: "member", SubjectType: "group", SubjectId: team, SubjectRelation: "member",
+		})
+		send(&dsc3.Relation{ObjectType: "group", ObjectId: team, Relation: "member", SubjectType: "user", SubjectId: "team-user-" + strconv.Itoa(i)})
+	}
+
+	require.NoError(b, stream.CloseSend())
+	require.NoError(b, g.Wait())
+
+	return client
+}
@@ -0,0 +1,129 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/aserto-dev/azm/stats"
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dse3 "github.com/aserto-dev/go-directory/aserto/directory/exporter/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCounters(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+	t.Cleanup(func() { require.NoError(t, deleteManifest(client)) })
+
+	for _, obj := range []*dsc3.Object{
+		{Type: "user", Id: "stats-user-1"},
+		{Type: "user", Id: "stats-user-2"},
+		{Type: "group", Id: "stats-group-1"},
+		{Type: "group", Id: "stats-group-2"},
+	} {
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: obj})
+		require.NoError(t, err)
+	}
+
+	for _, rel := range []*dsc3.Relation{
+		{ObjectType: "group", ObjectId: "stats-group-1", Relation: "member", SubjectType: "user", SubjectId: "stats-user-1"},
+		{ObjectType: "group", ObjectId: "stats-group-1", Relation: "member", SubjectType: "user", SubjectId: "stats-user-2"},
+		{ObjectType: "group", ObjectId: "stats-group-2", Relation: "member", SubjectType: "group", SubjectId: "stats-group-1", SubjectRelation: "member"},
+		{ObjectType: "document", ObjectId: "stats-doc", Relation: "reader", SubjectType: "user", SubjectId: "*"},
+	} {
+		_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+		require.NoError(t, err)
+	}
+
+	// updating an existing instance does not change the counters.
+	_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+		Object: &dsc3.Object{Type: "user", Id: "stats-user-1", DisplayName: "updated"},
+	})
+	require.NoError(t, err)
+
+	t.Run("counters", func(t *testing.T) {
+		s, err := client.Directory.Stats(ctx)
+		require.NoError(t, err)
+
+		require.Equal(t, int32(2), s.ObjectTypes["user"].ObjCount)
+		require.Equal(t, int32(2), s.ObjectTypes["group"].ObjCount)
+		require.Equal(t, int32(3), s.ObjectTypes["group"].Count)
+		require.Equal(t, int32(2), s.ObjectTypes["group"].Relations["member"].SubjectTypes["user"].Count)
+		require.Equal(t, int32(1), s.ObjectTypes["group"].Relations["member"].SubjectTypes["group"].SubjectRelations["member"].Count)
+		require.Equal(t, int32(1), s.ObjectTypes["document"].Relations["reader"].SubjectTypes["user:*"].Count)
+	})
+
+	t.Run("deletes", func(t *testing.T) {
+		_, err := client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{ObjectType: "user", ObjectId: "stats-user-2", WithRelations: true})
+		require.NoError(t, err)
+
+		s, err := client.Directory.Stats(ctx)
+		require.NoError(t, err)
+
+		require.Equal(t, int32(1), s.ObjectTypes["user"].ObjCount)
+		require.Equal(t, int32(1), s.ObjectTypes["group"].Relations["member"].SubjectTypes["user"].Count)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		before, err := client.Directory.Stats(ctx)
+		require.NoError(t, err)
+
+		repaired, err := client.Directory.RepairStats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, statsJSON(t, before), statsJSON(t, repaired))
+
+		after, err := client.Directory.Stats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, statsJSON(t, repaired), statsJSON(t, after))
+	})
+
+	t.Run("export-stats", func(t *testing.T) {
+		stream, err := client.V3.Exporter.Export(ctx, &dse3.ExportRequest{
+			Options: uint32(dse3.Option_OPTION_STATS | dse3.Option_OPTION_DATA),
+		})
+		require.NoError(t, err)
+
+		var exported []byte
+
+		for {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			exported, err = msg.GetStats().MarshalJSON()
+			require.NoError(t, err)
+		}
+
+		s, err := client.Directory.Stats(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, statsJSON(t, s), string(exported))
+	})
+
+	t.Run("manifest-in-use", func(t *testing.T) {
+		require.ErrorContains(t, setManifest(client, []byte(removeObjectInUse)), "object type in use")
+	})
+}
+
+func statsJSON(t *testing.T, s *stats.Stats) string {
+	t.Helper()
+
+	buf, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	return string(buf)
+}
@@ -0,0 +1,193 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const streamObjectCount = 250
+
+// recvAll, receives the stream messages until the end of the stream.
+func recvAll[T any](t *testing.T, stream grpc.ServerStreamingClient[T]) []*T {
+	t.Helper()
+
+	responses := []*T{}
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return responses
+		}
+
+		require.NoError(t, err)
+
+		responses = append(responses, resp)
+	}
+}
+
+func TestStreamReader(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+	t.Cleanup(func() { require.NoError(t, deleteManifest(client)) })
+
+	_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "group", Id: "stream-group"}})
+	require.NoError(t, err)
+
+	for i := range streamObjectCount {
+		id := fmt.Sprintf("stream-user-%03d", i)
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id}})
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+			ObjectType: "group", ObjectId: "stream-group", Relation: "member", SubjectType: "user", SubjectId: id,
+		}})
+		require.NoError(t, err)
+	}
+
+	t.Run("stream-objects", func(t *testing.T) {
+		stream, err := client.Streamer.StreamObjects(ctx, &dsr3.GetObjectsRequest{ObjectType: "user"})
+		require.NoError(t, err)
+
+		responses := recvAll(t, stream)
+
+		ids := map[string]bool{}
+
+		for _, resp := range responses {
+			require.LessOrEqual(t, len(resp.GetResults()), 100)
+
+			for _, obj := range resp.GetResults() {
+				require.Equal(t, "user", obj.GetType())
+				ids[obj.GetId()] = true
+			}
+		}
+
+		require.Len(t, responses, 3)
+		require.Empty(t, responses[2].GetPage().GetNextToken())
+		require.Len(t, ids, streamObjectCount)
+	})
+
+	t.Run("stream-relations-with-objects", func(t *testing.T) {
+		stream, err := client.Streamer.StreamRelations(ctx, &dsr3.GetRelationsRequest{
+			ObjectType:  "group",
+			ObjectId:    "stream-group",
+			Relation:    "member",
+			WithObjects: true,
+			Page:        &dsc3.PaginationRequest{Size: 50},
+		})
+		require.NoError(t, err)
+
+		count := 0
+
+		for _, resp := range recvAll(t, stream) {
+			require.Len(t, resp.GetResults(), 50)
+			require.Contains(t, resp.GetObjects(), "group:stream-group")
+
+			for _, rel := range resp.GetResults() {
+				require.Contains(t, resp.GetObjects(), "user:"+rel.GetSubjectId())
+			}
+
+			count += len(resp.GetResults())
+		}
+
+		require.Equal(t, streamObjectCount, count)
+	})
+
+	t.Run("stream-resume", func(t *testing.T) {
+		req := &dsr3.GetRelationsRequest{
+			ObjectType: "group",
+			ObjectId:   "stream-group",
+			Page:       &dsc3.PaginationRequest{Size: 10},
+		}
+
+		cctx, cancel := context.WithCancel(ctx)
+
+		stream, err := client.Streamer.StreamRelations(cctx, req)
+		require.NoError(t, err)
+
+		first, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, first.GetResults(), 10)
+		require.NotEmpty(t, first.GetPage().GetNextToken())
+
+		cancel()
+
+		// the interrupted stream is resumed at the page token of the last received batch.
+		req.Page.Token = first.GetPage().GetNextToken()
+
+		stream, err = client.Streamer.StreamRelations(ctx, req)
+		require.NoError(t, err)
+
+		ids := map[string]bool{}
+
+		for _, resp := range append([]*dsr3.GetRelationsResponse{first}, recvAll(t, stream)...) {
+			for _, rel := range resp.GetResults() {
+				require.False(t, ids[rel.GetSubjectId()], "duplicate %s", rel.GetSubjectId())
+				ids[rel.GetSubjectId()] = true
+			}
+		}
+
+		require.Len(t, ids, streamObjectCount)
+	})
+
+	t.Run("stream-cancel-filtered-scan", func(t *testing.T) {
+		// the type filters are matched against the values of a full scan of the relations, no relation matches.
+		req := &dsr3.GetRelationsRequest{ObjectType: "group", SubjectType: "group"}
+
+		stream := &relationStream{ctx: &cancelAfterCtx{Context: ctx, n: 10}}
+
+		err := client.Directory.Streamer3().StreamRelations(req, stream)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Zero(t, stream.sent)
+	})
+}
+
+// relationStream, in-process server stream of StreamRelations, counting the sent messages.
+type relationStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent int
+}
+
+func (s *relationStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *relationStream) Send(*dsr3.GetRelationsResponse) error {
+	s.sent++
+	return nil
+}
+
+// cancelAfterCtx, context reporting its cancellation once Err was called n times, cancelling a stream while it scans.
+type cancelAfterCtx struct {
+	context.Context
+	calls atomic.Int32
+	n     int32
+}
+
+func (c *cancelAfterCtx) Err() error {
+	if c.calls.Add(1) > c.n {
+		return context.Canceled
+	}
+
+	return c.Context.Err()
+}
@@ -0,0 +1,205 @@
+package tests_test
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/datasync"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestSyncTombstones(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	upstream, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "upstream.db"),
+		RequestTimeout: 2 * time.Second,
+	})
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(upstream, manifest))
+
+	newEdge := func(t *testing.T) *server.TestEdgeClient {
+		t.Helper()
+
+		edge, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+			DBPath:         path.Join(t.TempDir(), "edge.db"),
+			RequestTimeout: 2 * time.Second,
+		})
+		t.Cleanup(cleanup)
+
+		return edge
+	}
+
+	sync := func(t *testing.T, edge *server.TestEdgeClient, opts ...datasync.Option) {
+		t.Helper()
+
+		require.NoError(t, edge.Directory.DataSyncClient().Sync(ctx, upstream.Conn, opts...))
+	}
+
+	setUser := func(t *testing.T, id string) {
+		t.Helper()
+
+		_, err := upstream.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id}})
+		require.NoError(t, err)
+	}
+
+	deleteUser := func(t *testing.T, id string) {
+		t.Helper()
+
+		_, err := upstream.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{ObjectType: "user", ObjectId: id})
+		require.NoError(t, err)
+	}
+
+	hasUser := func(t *testing.T, edge *server.TestEdgeClient, id string) bool {
+		t.Helper()
+
+		_, err := edge.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{ObjectType: "user", ObjectId: id})
+		if status.Code(err) == codes.NotFound {
+			return false
+		}
+
+		require.NoError(t, err)
+
+		return true
+	}
+
+	t.Run("watermark-with-tombstones", func(t *testing.T) {
+		edge := newEdge(t)
+
+		setUser(t, "sync-alice")
+		sync(t, edge, datasync.WithMode(datasync.Manifest|datasync.Full))
+		require.True(t, hasUser(t, edge, "sync-alice"))
+
+		deleteUser(t, "sync-alice")
+		sync(t, edge, datasync.WithMode(datasync.Watermark), datasync.WithTombstones())
+		require.False(t, hasUser(t, edge, "sync-alice"))
+	})
+
+	t.Run("watermark-without-tombstones", func(t *testing.T) {
+		edge := newEdge(t)
+
+		setUser(t, "sync-bob")
+		sync(t, edge, datasync.WithMode(datasync.Manifest|datasync.Full))
+
+		// tombstones are not requested from the upstream, the delete is not propagated.
+		deleteUser(t, "sync-bob")
+		sync(t, edge, datasync.WithMode(datasync.Watermark))
+		require.True(t, hasUser(t, edge, "sync-bob"))
+	})
+	t.Run("watermark-before-tombstone-horizon", func(t *testing.T) {
+		edge := newEdge(t)
+
+		setUser(t, "sync-dave")
+		sync(t, edge, datasync.WithMode(datasync.Manifest|datasync.Full))
+
+		// the tombstone of the delete is purged, the edge falls back to a diff sync.
+		deleteUser(t, "sync-dave")
+		_, err := upstream.Directory.PurgeTombstones(ctx, time.Now().UTC())
+		require.NoError(t, err)
+
+		sync(t, edge, datasync.WithMode(datasync.Watermark), datasync.WithTombstones())
+		require.False(t, hasUser(t, edge, "sync-dave"))
+	})
+
+	// runs last, the manifest of the upstream is deleted.
+	t.Run("delete-manifest", func(t *testing.T) {
+		edge := newEdge(t)
+
+		setUser(t, "sync-carol")
+		_, err := upstream.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+			ObjectType: "user", ObjectId: "sync-carol", Relation: "manager", SubjectType: "user", SubjectId: "sync-carol",
+		}})
+		require.NoError(t, err)
+
+		sync(t, edge, datasync.WithMode(datasync.Manifest|datasync.Full))
+		require.True(t, hasUser(t, edge, "sync-carol"))
+
+		require.NoError(t, deleteManifest(upstream))
+
+		sync(t, edge, datasync.WithMode(datasync.Watermark), datasync.WithTombstones())
+		require.False(t, hasUser(t, edge, "sync-carol"))
+
+		resp, err := edge.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{ObjectType: "user", ObjectId: "sync-carol"})
+		require.NoError(t, err)
+		require.Empty(t, resp.GetResults())
+	})
+}
+
+func TestSyncUpstreamRevision(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	newServer := func(name string) *server.TestEdgeClient {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, &directory.Config{
+			DBPath:         path.Join(t.TempDir(), name),
+			RequestTimeout: 2 * time.Second,
+		})
+		t.Cleanup(cleanup)
+
+		return client
+	}
+
+	upstream, edge := newServer("upstream.db"), newServer("edge.db")
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, setManifest(upstream, manifest))
+	require.NoError(t, setManifest(edge, manifest))
+
+	// setUser, returns the upstream revision token of the write.
+	setUser := func(id string) (string, string) {
+		var header metadata.MD
+
+		_, err := upstream.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id}}, grpc.Header(&header))
+		require.NoError(t, err)
+
+		return header.Get(x.RevisionHeader)[0], header.Get(x.RevisionOriginHeader)[0]
+	}
+
+	getUser := func(id, rev, origin string) error {
+		_, err := edge.V3.Reader.GetObject(
+			metadata.AppendToOutgoingContext(ctx, x.AtLeastRevisionHeader, rev, x.RevisionOriginHeader, origin),
+			&dsr3.GetObjectRequest{ObjectType: "user", ObjectId: id},
+		)
+
+		return err
+	}
+
+	sync := func() {
+		require.NoError(t, edge.Directory.DataSyncClient().Sync(ctx, upstream.Conn, datasync.WithMode(datasync.Manifest|datasync.Full|datasync.Diff)))
+	}
+
+	rev, origin := setUser("rev-alice")
+
+	// the edge has not been synced from the upstream.
+	require.Equal(t, codes.FailedPrecondition, status.Code(getUser("rev-alice", rev, origin)))
+
+	sync()
+	require.NoError(t, getUser("rev-alice", rev, origin))
+
+	// the upstream write has not been synced to the edge.
+	rev, origin = setUser("rev-bob")
+	require.Equal(t, codes.Unavailable, status.Code(getUser("rev-bob", rev, origin)))
+
+	sync()
+	require.NoError(t, getUser("rev-bob", rev, origin))
+}
@@ -0,0 +1,156 @@
+package tests_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/fs"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/tenant"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantRouter(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	cfg := &tenant.Config{
+		RootPath:    t.TempDir(),
+		IdleTimeout: 100 * time.Millisecond,
+		Directory:   directory.Config{RequestTimeout: time.Second * 2},
+	}
+
+	client, router, cleanup := server.NewTestTenantServer(ctx, &logger, cfg)
+	t.Cleanup(cleanup)
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+
+	tenantCtx := func(id string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, x.TenantIDHeader, id)
+	}
+
+	acme, globex := tenantCtx("acme"), tenantCtx("globex")
+
+	require.NoError(t, setManifestContext(acme, client, manifest))
+	require.NoError(t, setManifestContext(globex, client, manifest))
+	require.Equal(t, []string{"acme", "globex"}, router.Tenants())
+	require.True(t, fs.FileExists(router.DBPath("acme")))
+	require.True(t, fs.FileExists(router.DBPath("globex")))
+
+	_, err = client.V3.Writer.SetObject(acme, &dsw3.SetObjectRequest{
+		Object: &dsc3.Object{Type: "user", Id: "acme-user@acmecorp.com"},
+	})
+	require.NoError(t, err)
+
+	getObject := func(ctx context.Context) error {
+		_, err := client.V3.Reader.GetObject(ctx, &dsr3.GetObjectRequest{
+			ObjectType: "user",
+			ObjectId:   "acme-user@acmecorp.com",
+		})
+
+		return err
+	}
+
+	t.Run("tenant-isolation", func(t *testing.T) {
+		require.NoError(t, getObject(acme))
+		require.Equal(t, codes.NotFound, status.Code(getObject(globex)))
+	})
+
+	t.Run("missing-tenant", func(t *testing.T) {
+		require.Equal(t, codes.InvalidArgument, status.Code(getObject(ctx)))
+	})
+
+	t.Run("invalid-tenant", func(t *testing.T) {
+		require.Equal(t, codes.InvalidArgument, status.Code(getObject(tenantCtx("../acme"))))
+	})
+
+	t.Run("idle-tenants-closed", func(t *testing.T) {
+		require.Eventually(t, func() bool { return len(router.Tenants()) == 0 }, 5*time.Second, 20*time.Millisecond)
+
+		// reopened on demand, with its persisted content.
+		require.NoError(t, getObject(acme))
+		require.Equal(t, []string{"acme"}, router.Tenants())
+	})
+}
+
+func TestTenantRouterLimits(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+
+	router, err := tenant.New(ctx, &tenant.Config{
+		RootPath:       t.TempDir(),
+		AllowedTenants: []string{"acme", "globex", "initech"},
+		MaxTenants:     2,
+		Directory:      directory.Config{RequestTimeout: time.Second * 2},
+	}, &logger)
+	require.NoError(t, err)
+	t.Cleanup(router.Close)
+
+	acquire := func(id string) (func(), error) {
+		_, release, err := router.Acquire(metadata.NewIncomingContext(ctx, metadata.Pairs(x.TenantIDHeader, id)))
+		return release, err
+	}
+
+	t.Run("not-allowed", func(t *testing.T) {
+		_, err := acquire("umbrella")
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.False(t, fs.FileExists(router.DBPath("umbrella")))
+	})
+
+	t.Run("max-tenants", func(t *testing.T) {
+		releaseAcme, err := acquire("acme")
+		require.NoError(t, err)
+		t.Cleanup(releaseAcme)
+
+		releaseGlobex, err := acquire("globex")
+		require.NoError(t, err)
+		t.Cleanup(releaseGlobex)
+
+		_, err = acquire("initech")
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Equal(t, []string{"acme", "globex"}, router.Tenants())
+	})
+
+	t.Run("close-waits-for-release", func(t *testing.T) {
+		release, err := acquire("acme")
+		require.NoError(t, err)
+
+		closed := make(chan struct{})
+
+		go func() {
+			router.Close()
+			close(closed)
+		}()
+
+		select {
+		case <-closed:
+			require.FailNow(t, "router closed while a tenant directory is in use")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		release()
+
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "router not closed after the tenant directory was released")
+		}
+
+		_, err = acquire("acme")
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -0,0 +1,112 @@
+package tests_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"testing"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/bdb"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPageTokens(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+	require.NoError(t, setManifest(client, manifest))
+	t.Cleanup(func() { require.NoError(t, deleteManifest(client)) })
+
+	for i := range 5 {
+		id := fmt.Sprintf("token-user-%d", i)
+
+		_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{Object: &dsc3.Object{Type: "user", Id: id}})
+		require.NoError(t, err)
+
+		_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: &dsc3.Relation{
+			ObjectType: "group", ObjectId: "token-group", Relation: "member", SubjectType: "user", SubjectId: id,
+		}})
+		require.NoError(t, err)
+	}
+
+	membersReq := func(token string) *dsr3.GetRelationsRequest {
+		return &dsr3.GetRelationsRequest{
+			ObjectType: "group", ObjectId: "token-group", Relation: "member",
+			Page: &dsc3.PaginationRequest{Size: 2, Token: token},
+		}
+	}
+
+	first, err := client.V3.Reader.GetRelations(ctx, membersReq(""))
+	require.NoError(t, err)
+	require.Len(t, first.GetResults(), 2)
+	require.NotEmpty(t, first.GetPage().GetNextToken())
+
+	t.Run("opaque-token", func(t *testing.T) {
+		token, err := bdb.DecodePageToken(first.GetPage().GetNextToken())
+		require.NoError(t, err)
+		require.Equal(t, bdb.KeyVersion, token.KeyVersion)
+		require.NotEmpty(t, token.Path)
+		require.NotZero(t, token.Fingerprint)
+
+		next, err := client.V3.Reader.GetRelations(ctx, membersReq(first.GetPage().GetNextToken()))
+		require.NoError(t, err)
+		require.Len(t, next.GetResults(), 2)
+		require.Equal(t, "token-user-2", next.GetResults()[0].GetSubjectId())
+	})
+
+	t.Run("filter-mismatch", func(t *testing.T) {
+		req := membersReq(first.GetPage().GetNextToken())
+		req.SubjectType = "user"
+
+		_, err := client.V3.Reader.GetRelations(ctx, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = client.V3.Reader.GetObjects(ctx, &dsr3.GetObjectsRequest{
+			ObjectType: "user",
+			Page:       &dsc3.PaginationRequest{Size: 2, Token: first.GetPage().GetNextToken()},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("malformed-token", func(t *testing.T) {
+		token := base64.RawURLEncoding.EncodeToString([]byte{0, 9})
+
+		_, err := client.V3.Reader.GetRelations(ctx, membersReq(token))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("legacy-key-token", func(t *testing.T) {
+		token, err := bdb.DecodePageToken(first.GetPage().GetNextToken())
+		require.NoError(t, err)
+
+		next, err := client.V3.Reader.GetRelations(ctx, membersReq(base64.RawURLEncoding.EncodeToString(token.Key)))
+		require.NoError(t, err)
+		require.Equal(t, "token-user-2", next.GetResults()[0].GetSubjectId())
+		require.NotEmpty(t, next.GetPage().GetNextToken())
+	})
+
+	t.Run("legacy-separated-token", func(t *testing.T) {
+		next, err := client.V3.Reader.GetRelations(ctx, membersReq("group:token-group|member|user:token-user-3"))
+		require.NoError(t, err)
+		require.Equal(t, "token-user-3", next.GetResults()[0].GetSubjectId())
+
+		objects, err := client.V3.Reader.GetObjects(ctx, &dsr3.GetObjectsRequest{
+			ObjectType: "user",
+			Page:       &dsc3.PaginationRequest{Size: 2, Token: "user:token-user-4"},
+		})
+		require.NoError(t, err)
+		require.Len(t, objects.GetResults(), 1)
+		require.Equal(t, "token-user-4", objects.GetResults()[0].GetId())
+	})
+}
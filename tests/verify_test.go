@@ -0,0 +1,227 @@
+package tests_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsr3 "github.com/aserto-dev/go-directory/aserto/directory/reader/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/directory"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestVerifyRepair(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+	cfg := &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "verify.db"),
+		RequestTimeout: 2 * time.Second,
+	}
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+
+	relations := []*dsc3.Relation{
+		{ObjectType: "user", ObjectId: "verify-u1", Relation: "manager", SubjectType: "user", SubjectId: "verify-u2"},
+		{ObjectType: "user", ObjectId: "verify-u1", Relation: "manager", SubjectType: "user", SubjectId: "verify-u3"},
+		{ObjectType: "user", ObjectId: "verify-u1", Relation: "manager", SubjectType: "user", SubjectId: "verify-u4"},
+		{ObjectType: "group", ObjectId: "verify-g1", Relation: "member", SubjectType: "group", SubjectId: "verify-g2", SubjectRelation: "member"},
+	}
+
+	// populate the store, and close it.
+	func() {
+		client, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+		defer cleanup()
+
+		require.NoError(t, setManifest(client, manifest))
+
+		for _, rel := range relations {
+			_, err := client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+			require.NoError(t, err)
+		}
+
+		report, err := client.Directory.Verify(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 4, report.Relations)
+		require.Empty(t, report.Issues)
+	}()
+
+	// corrupt the relation buckets of the closed store,
+	// and restrict group#member to users in the stored model, the relation of verify-g1 violates the restricted model.
+	db, err := bolt.Open(cfg.DBPath, 0o600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		// drop the subject index entry of the relation to verify-u2.
+		if err := deleteRelationEntry(tx.Bucket([]byte("relations_sub")), "verify-u2"); err != nil {
+			return err
+		}
+
+		// drop the primary entry of the relation to verify-u3, orphaning its index entries.
+		if err := deleteRelationEntry(tx.Bucket([]byte("relations_obj")), "verify-u3"); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte("relations_rel")).Put([]byte("garbage"), []byte{0xff}); err != nil {
+			return err
+		}
+
+		b := tx.Bucket([]byte("_manifest")).Bucket([]byte("default"))
+		model := bytes.Replace(b.Get([]byte("model")), []byte(`{"object":"user"},{"object":"group","relation":"member"}`), []byte(`{"object":"user"}`), 1)
+
+		return b.Put([]byte("model"), model)
+	}))
+	require.NoError(t, db.Close())
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+	t.Cleanup(cleanup)
+
+	adminCtx := metadata.AppendToOutgoingContext(ctx, server.TestAdminHeader, server.TestAdminToken)
+
+	issueKinds := func(t *testing.T) []string {
+		t.Helper()
+
+		resp, err := client.Admin.Verify(adminCtx, &emptypb.Empty{})
+		require.NoError(t, err)
+
+		kinds := []string{}
+		for _, issue := range resp.AsMap()["issues"].([]any) {
+			kinds = append(kinds, issue.(map[string]any)["kind"].(string))
+		}
+
+		sort.Strings(kinds)
+
+		return kinds
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		require.Equal(t, []string{
+			string(ds.IssueMissingCounterpart),
+			string(ds.IssueModelViolation),
+			string(ds.IssueOrphanedIndex),
+			string(ds.IssueOrphanedIndex),
+			string(ds.IssueUndecodableValue),
+		}, issueKinds(t))
+
+		report, err := client.Directory.Verify(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Relations)
+
+		for _, issue := range report.Issues {
+			if issue.Kind == ds.IssueMissingCounterpart {
+				require.Equal(t, "verify-u2", issue.Relation.GetSubjectId())
+				require.Equal(t, "relations_sub", issue.Path[0])
+			}
+		}
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		resp, err := client.Admin.Repair(adminCtx, &emptypb.Empty{})
+		require.NoError(t, err)
+		require.InEpsilon(t, 3, resp.AsMap()["relations"], 0)
+		require.Zero(t, resp.AsMap()["skipped"])
+		// the orphaned subject and relation index entries of verify-u3, and the garbage entry.
+		require.InEpsilon(t, 3, resp.AsMap()["removed"], 0)
+
+		// model violations are reported, but not repaired.
+		require.Equal(t, []string{string(ds.IssueModelViolation)}, issueKinds(t))
+
+		// the relation to verify-u2 is found through the rebuilt subject index.
+		rels, err := client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{
+			SubjectType: "user",
+			SubjectId:   "verify-u2",
+		})
+		require.NoError(t, err)
+		require.Len(t, rels.GetResults(), 1)
+
+		// the orphaned relation to verify-u3 is gone.
+		rels, err = client.V3.Reader.GetRelations(ctx, &dsr3.GetRelationsRequest{
+			SubjectType: "user",
+			SubjectId:   "verify-u3",
+		})
+		require.NoError(t, err)
+		require.Empty(t, rels.GetResults())
+	})
+}
+
+func TestVerifyTruncated(t *testing.T) {
+	ctx := t.Context()
+	logger := zerolog.New(io.Discard)
+	cfg := &directory.Config{
+		DBPath:         path.Join(t.TempDir(), "truncated.db"),
+		RequestTimeout: 2 * time.Second,
+	}
+
+	// create the store, and close it.
+	func() {
+		_, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+		cleanup()
+	}()
+
+	// more garbage entries than retained in the verify report, and repaired in a single batch.
+	garbage := ds.MaxVerifyIssues + 10
+
+	db, err := bolt.Open(cfg.DBPath, 0o600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		for i := range garbage {
+			if err := tx.Bucket([]byte("relations_sub")).Put(fmt.Appendf(nil, "garbage-%04d", i), []byte{0xff}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+	require.NoError(t, db.Close())
+
+	client, cleanup := server.NewTestEdgeServer(ctx, &logger, cfg)
+	t.Cleanup(cleanup)
+
+	adminCtx := metadata.AppendToOutgoingContext(ctx, server.TestAdminHeader, server.TestAdminToken)
+
+	resp, err := client.Admin.Verify(adminCtx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.AsMap()["issues"], ds.MaxVerifyIssues)
+	require.InEpsilon(t, garbage, resp.AsMap()["issue_count"], 0)
+	require.True(t, resp.AsMap()["truncated"].(bool))
+
+	resp, err = client.Admin.Repair(adminCtx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.InEpsilon(t, garbage, resp.AsMap()["removed"], 0)
+
+	report, err := client.Directory.Verify(ctx)
+	require.NoError(t, err)
+	require.Zero(t, report.IssueCount)
+	require.False(t, report.Truncated())
+}
+
+// deleteRelationEntry, deletes the entry of the relation bucket whose relation has the given subject id.
+func deleteRelationEntry(b *bolt.Bucket, subjectID string) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var rel dsc3.Relation
+		if err := proto.Unmarshal(v, &rel); err != nil {
+			return err
+		}
+
+		if rel.GetSubjectId() == subjectID {
+			return c.Delete()
+		}
+	}
+
+	return os.ErrNotExist
+}
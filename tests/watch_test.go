@@ -0,0 +1,212 @@
+package tests_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	dsc3 "github.com/aserto-dev/go-directory/aserto/directory/common/v3"
+	dsw3 "github.com/aserto-dev/go-directory/aserto/directory/writer/v3"
+	"github.com/aserto-dev/go-edge-ds/pkg/ds"
+	"github.com/aserto-dev/go-edge-ds/pkg/server"
+	"github.com/aserto-dev/go-edge-ds/pkg/x"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// watchChange, change event received from the watcher service.
+type watchChange struct {
+	Revision uint64
+	Op       ds.ChangeOp
+	ObjectID string
+}
+
+// watchStream, receives the change events of the watch call into the changes channel,
+// the error terminating the watch call is sent to the done channel.
+type watchStream struct {
+	changes chan *watchChange
+	done    chan error
+}
+
+func watch(ctx context.Context, t *testing.T, client *server.TestEdgeClient, fromRevision uint64) *watchStream {
+	t.Helper()
+
+	stream, err := client.Watcher.Watch(ctx, wrapperspb.UInt64(fromRevision))
+	require.NoError(t, err)
+
+	ws := &watchStream{changes: make(chan *watchChange, 16), done: make(chan error, 1)}
+
+	ops := map[string]ds.ChangeOp{}
+	for op := ds.ChangeUnknown; op <= ds.ChangeReset; op++ {
+		ops[op.String()] = op
+	}
+
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				ws.done <- err
+				return
+			}
+
+			rev, err := strconv.ParseUint(msg.GetFields()["revision"].GetStringValue(), 10, 64)
+			if err != nil {
+				ws.done <- err
+				return
+			}
+
+			ws.changes <- &watchChange{
+				Revision: rev,
+				Op:       ops[msg.GetFields()["op"].GetStringValue()],
+				ObjectID: msg.GetFields()["object"].GetStructValue().GetFields()["id"].GetStringValue(),
+			}
+		}
+	}()
+
+	return ws
+}
+
+func watchRevision(ctx context.Context, t *testing.T, client *server.TestEdgeClient) uint64 {
+	t.Helper()
+
+	rev, err := client.Watcher.Revision(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	return rev.GetValue()
+}
+
+func TestWatch(t *testing.T) {
+	client, cleanup := testInit()
+	t.Cleanup(cleanup)
+
+	ctx := t.Context()
+
+	manifest, err := os.ReadFile("./manifest_v3_test.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deleteManifest(client))
+
+	start := watchRevision(ctx, t, client)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	stream := watch(watchCtx, t, client, start)
+
+	require.NoError(t, setManifest(client, manifest))
+
+	_, err = client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+		Object: &dsc3.Object{Type: "user", Id: "watch-user@acmecorp.com"},
+	})
+	require.NoError(t, err)
+
+	rel := &dsc3.Relation{
+		ObjectType:  "group",
+		ObjectId:    "watch-group",
+		Relation:    "member",
+		SubjectType: "user",
+		SubjectId:   "watch-user@acmecorp.com",
+	}
+
+	_, err = client.V3.Writer.SetRelation(ctx, &dsw3.SetRelationRequest{Relation: rel})
+	require.NoError(t, err)
+
+	_, err = client.V3.Writer.DeleteRelation(ctx, &dsw3.DeleteRelationRequest{
+		ObjectType:  rel.GetObjectType(),
+		ObjectId:    rel.GetObjectId(),
+		Relation:    rel.GetRelation(),
+		SubjectType: rel.GetSubjectType(),
+		SubjectId:   rel.GetSubjectId(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.V3.Writer.DeleteObject(ctx, &dsw3.DeleteObjectRequest{
+		ObjectType: "user",
+		ObjectId:   "watch-user@acmecorp.com",
+	})
+	require.NoError(t, err)
+
+	ops := []ds.ChangeOp{}
+	prev := start
+
+	for len(ops) == 0 || ops[len(ops)-1] != ds.ChangeObjectDelete {
+		select {
+		case change := <-stream.changes:
+			require.Greater(t, change.Revision, prev)
+			prev = change.Revision
+
+			if change.Op == ds.ChangeObjectSet && change.ObjectID != "watch-user@acmecorp.com" {
+				// objects created by the manifest (e.g. the group) are not of interest.
+				continue
+			}
+
+			ops = append(ops, change.Op)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for changes, received %v", ops)
+		}
+	}
+
+	require.Equal(t, ds.ChangeManifestSet, ops[0])
+	require.Equal(t, []ds.ChangeOp{
+		ds.ChangeObjectSet,
+		ds.ChangeRelationSet,
+		ds.ChangeRelationDelete,
+		ds.ChangeObjectDelete,
+	}, ops[len(ops)-4:])
+
+	cancel()
+	require.Equal(t, codes.Canceled, status.Code(<-stream.done))
+
+	t.Run("resume", func(t *testing.T) {
+		resumeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		resume := watch(resumeCtx, t, client, prev-1)
+
+		select {
+		case change := <-resume.changes:
+			require.Equal(t, prev, change.Revision)
+			require.Equal(t, ds.ChangeObjectDelete, change.Op)
+			require.Equal(t, "watch-user@acmecorp.com", change.ObjectID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for resumed change")
+		}
+
+		cancel()
+		require.Equal(t, codes.Canceled, status.Code(<-resume.done))
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		from := watchRevision(ctx, t, client)
+
+		// the changes are read in batches of x.MaxPageSize changes, each in its own read transaction.
+		const count = int(x.MaxPageSize) + 10
+
+		for i := range count {
+			_, err := client.V3.Writer.SetObject(ctx, &dsw3.SetObjectRequest{
+				Object: &dsc3.Object{Type: "user", Id: "watch-batch-" + strconv.Itoa(i)},
+			})
+			require.NoError(t, err)
+		}
+
+		batchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		batch := watch(batchCtx, t, client, from)
+
+		for i := range count {
+			select {
+			case change := <-batch.changes:
+				require.Equal(t, from+uint64(i)+1, change.Revision)
+				require.Equal(t, "watch-batch-"+strconv.Itoa(i), change.ObjectID)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout waiting for change %d", i)
+			}
+		}
+	})
+}